@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+)
+
+// runConfig implements the "config" subcommand:
+//
+//	config print [flags]   show the effective configuration with secrets redacted
+//
+// It accepts the same flags as the server, so it shows exactly what the server would use.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "Usage: config print [-config file] [flags]")
+		return 2
+	}
+
+	cfg, err := loadConfig("config print", args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	cfg.Print(os.Stdout)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "\n%v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig loads the configuration from the given arguments and rejects any
+// arguments that are not flags.
+func loadConfig(name string, args []string) (*config.Loaded, error) {
+	cfg, rest, err := config.Load(name, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+	return cfg, nil
+}
+
+// databaseDSN returns the data source name to open: the PostgreSQL URL when
+// one is configured, the SQLite path otherwise.
+func databaseDSN(cfg *config.Config) string {
+	if cfg.Database.DSN != "" {
+		return cfg.Database.DSN
+	}
+	return sqliteDSN(cfg.Database.Path)
+}
+
+// sqliteDSN turns a database path into a connection string. Writers wait for
+// locks instead of failing immediately, since the workers write concurrently.
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path // Caller supplied their own options
+	}
+	return path + "?_busy_timeout=5000"
+}
+
+// docsHost returns a host:port for links to the server, filling in localhost
+// when the listen address has no host.
+func docsHost(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
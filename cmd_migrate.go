@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// runMigrate implements the "migrate" subcommand:
+//
+//	migrate [flags] status          list migrations and whether they are applied
+//	migrate [flags] up [-dry-run]   apply pending migrations
+//
+// The database comes from the regular configuration (e.g. -database.path, DB_PATH
+// or DATABASE_URL).
+func runMigrate(args []string) int {
+	cfg, rest, err := config.Load("migrate", args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: migrate [-config file] [-database.path path | -database.dsn url] <status|up> [-dry-run]")
+	}
+
+	action := "up"
+	if len(rest) > 0 {
+		action = rest[0]
+	}
+
+	store, err := models.Open(databaseDSN(cfg.Config))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	switch action {
+	case "status":
+		statuses, err := store.GetMigrationStatus(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading migration status: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			status, appliedAt := "pending", ""
+			if s.Applied {
+				status, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		w.Flush()
+
+	case "up":
+		upFlags := flag.NewFlagSet("migrate up", flag.ExitOnError)
+		dryRun := upFlags.Bool("dry-run", false, "apply pending migrations in a transaction and roll it back")
+		upFlags.Parse(rest[min(1, len(rest)):])
+
+		migrate, verb := store.Migrate, "Applied"
+		if *dryRun {
+			migrate, verb = store.MigrateDryRun, "Would apply"
+		}
+		applied, err := migrate(ctx)
+		for _, m := range applied {
+			fmt.Printf("%s %04d_%s\n", verb, m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error migrating database: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database schema is up to date.")
+		}
+
+	default:
+		usage()
+		return 2
+	}
+	return 0
+}
@@ -1,6 +1,233 @@
-// config/config.go
 package config
 
-// JwtSecret is a secret key for signing JWTs.
-// In a real application, this should be loaded from a secure environment variable.
-var JwtSecret = []byte("your-highly-secret-and-random-key")
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultJwtSecret is the placeholder JWT secret. It is only accepted in dev mode.
+const DefaultJwtSecret = "your-highly-secret-and-random-key"
+
+// minJwtSecretLength is the shortest JWT secret accepted outside dev mode.
+const minJwtSecretLength = 32
+
+// Config holds every externally configurable setting of the server.
+type Config struct {
+	Env           string              `yaml:"env"` // "dev" or "production"
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	Auth          AuthConfig          `yaml:"auth"`
+	AI            AIConfig            `yaml:"ai"`
+	Workers       WorkersConfig       `yaml:"workers"`
+	Fetcher       FetcherConfig       `yaml:"fetcher"`
+	Import        ImportConfig        `yaml:"import"`
+	Subscriptions SubscriptionsConfig `yaml:"subscriptions"`
+}
+
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// DatabaseConfig configures the database. A PostgreSQL DSN takes precedence
+// over the SQLite path.
+type DatabaseConfig struct {
+	Path string `yaml:"path"`
+	DSN  string `yaml:"dsn"`
+}
+
+// AuthConfig configures token signing and lifetimes.
+type AuthConfig struct {
+	JwtSecret       string        `yaml:"jwt_secret"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+// AIConfig selects and configures the summarizer/tagger provider.
+type AIConfig struct {
+	// Provider is "gemini", "openai" or "local". When empty, Gemini is used if
+	// an API key is set and the offline local provider otherwise.
+	Provider string       `yaml:"provider"`
+	Gemini   GeminiConfig `yaml:"gemini"`
+	OpenAI   OpenAIConfig `yaml:"openai"`
+}
+
+// GeminiConfig configures the Gemini provider.
+type GeminiConfig struct {
+	APIKey string `yaml:"api_key"`
+	Model  string `yaml:"model"`
+}
+
+// OpenAIConfig configures the OpenAI-compatible provider. Point BaseURL at a
+// local model server (e.g. llama.cpp or Ollama) to keep data on-premises.
+type OpenAIConfig struct {
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+	Model   string `yaml:"model"`
+}
+
+// WorkersConfig configures background article processing.
+type WorkersConfig struct {
+	Count       int `yaml:"count"`
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+// FetcherConfig limits how article URLs are downloaded. Private, loopback and
+// link-local addresses are never fetched unless the host is in AllowHosts.
+type FetcherConfig struct {
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	Timeout        time.Duration `yaml:"timeout"` // Whole request, including reading the body
+	MaxBodyBytes   int           `yaml:"max_body_bytes"`
+	MaxRedirects   int           `yaml:"max_redirects"`
+	// Host names (matching subdomains too), IP addresses or CIDR ranges
+	AllowHosts []string `yaml:"allow_hosts"` // Fetched even when they resolve to private addresses
+	DenyHosts  []string `yaml:"deny_hosts"`  // Never fetched
+}
+
+// ImportConfig limits imports of reading lists from other services.
+type ImportConfig struct {
+	MaxUploadBytes int `yaml:"max_upload_bytes"`
+	// EnqueueInterval spaces out the imported articles queued for processing,
+	// across all running imports, so a large import doesn't flood the workers
+	EnqueueInterval time.Duration `yaml:"enqueue_interval"`
+}
+
+// SubscriptionsConfig configures the polling of subscribed feeds.
+type SubscriptionsConfig struct {
+	// PollInterval is how often each feed is checked for new entries. Feeds
+	// that fail are checked less often, up to once a day
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// MaxNewEntries caps the articles one check of a feed adds; the rest of
+	// its new entries are skipped
+	MaxNewEntries int `yaml:"max_new_entries"`
+}
+
+// Default returns the built-in configuration.
+func Default() *Config {
+	return &Config{
+		Env: "production",
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ShutdownTimeout: 5 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Path: "./reading_list.db",
+		},
+		Auth: AuthConfig{
+			JwtSecret:       DefaultJwtSecret,
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		AI: AIConfig{
+			Gemini: GeminiConfig{Model: "gemini-2.5-flash"},
+			OpenAI: OpenAIConfig{BaseURL: "https://api.openai.com/v1", Model: "gpt-4o-mini"},
+		},
+		Workers: WorkersConfig{
+			Count:       4,
+			MaxAttempts: 5,
+		},
+		Fetcher: FetcherConfig{
+			ConnectTimeout: 10 * time.Second,
+			Timeout:        30 * time.Second,
+			MaxBodyBytes:   10 << 20,
+			MaxRedirects:   5,
+		},
+		Import: ImportConfig{
+			MaxUploadBytes:  32 << 20,
+			EnqueueInterval: 500 * time.Millisecond,
+		},
+		Subscriptions: SubscriptionsConfig{
+			PollInterval:  time.Hour,
+			MaxNewEntries: 20,
+		},
+	}
+}
+
+// Current is the configuration in effect. It holds the defaults until main
+// replaces it with the loaded configuration at startup.
+var Current = Default()
+
+// JwtSecret returns the secret key for signing JWTs.
+func JwtSecret() []byte {
+	return []byte(Current.Auth.JwtSecret)
+}
+
+// IsDev reports whether the server runs in dev mode.
+func (c *Config) IsDev() bool {
+	return c.Env == "dev"
+}
+
+// Validate checks the configuration for values the server can't run with.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Env != "dev" && c.Env != "production" {
+		add("env must be 'dev' or 'production', got %q", c.Env)
+	}
+	if c.Server.Addr == "" {
+		add("server.addr is required")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout must be positive")
+	}
+	if c.Database.Path == "" && c.Database.DSN == "" {
+		add("database.path or database.dsn is required")
+	}
+	if !c.IsDev() {
+		if c.Auth.JwtSecret == DefaultJwtSecret {
+			add("auth.jwt_secret must be changed from the default outside dev mode")
+		} else if len(c.Auth.JwtSecret) < minJwtSecretLength {
+			add("auth.jwt_secret must be at least %d characters outside dev mode", minJwtSecretLength)
+		}
+	} else if c.Auth.JwtSecret == "" {
+		add("auth.jwt_secret is required")
+	}
+	if c.Auth.AccessTokenTTL <= 0 || c.Auth.RefreshTokenTTL <= 0 {
+		add("auth.access_token_ttl and auth.refresh_token_ttl must be positive")
+	} else if c.Auth.AccessTokenTTL >= c.Auth.RefreshTokenTTL {
+		add("auth.access_token_ttl must be shorter than auth.refresh_token_ttl")
+	}
+	switch strings.ToLower(c.AI.Provider) {
+	case "", "gemini", "openai", "local":
+	default:
+		add("ai.provider must be 'gemini', 'openai' or 'local', got %q", c.AI.Provider)
+	}
+	if c.Workers.Count < 1 {
+		add("workers.count must be at least 1")
+	}
+	if c.Workers.MaxAttempts < 1 {
+		add("workers.max_attempts must be at least 1")
+	}
+	if c.Fetcher.ConnectTimeout <= 0 || c.Fetcher.Timeout <= 0 {
+		add("fetcher.connect_timeout and fetcher.timeout must be positive")
+	}
+	if c.Fetcher.MaxBodyBytes < 1 {
+		add("fetcher.max_body_bytes must be at least 1")
+	}
+	if c.Fetcher.MaxRedirects < 0 {
+		add("fetcher.max_redirects must not be negative")
+	}
+	if c.Import.MaxUploadBytes < 1 {
+		add("import.max_upload_bytes must be at least 1")
+	}
+	if c.Import.EnqueueInterval <= 0 {
+		add("import.enqueue_interval must be positive")
+	}
+	if c.Subscriptions.PollInterval < time.Minute {
+		add("subscriptions.poll_interval must be at least 1m")
+	}
+	if c.Subscriptions.MaxNewEntries < 1 {
+		add("subscriptions.max_new_entries must be at least 1")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Sources a setting can come from, in increasing order of precedence.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// setting binds one configuration field to its environment variable and
+// command-line flag. The flag name is the field's dotted YAML path.
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	secret bool
+	ptr    interface{} // *string, *int, *time.Duration or *[]string (comma-separated)
+}
+
+// settings lists every field of cfg that can be set from the environment or flags.
+func settings(cfg *Config) []setting {
+	return []setting{
+		{key: "env", env: "APP_ENV", usage: "'dev' or 'production'", ptr: &cfg.Env},
+		{key: "server.addr", env: "LISTEN_ADDR", usage: "HTTP listen address", ptr: &cfg.Server.Addr},
+		{key: "server.shutdown_timeout", env: "SHUTDOWN_TIMEOUT", usage: "time allowed for graceful shutdown", ptr: &cfg.Server.ShutdownTimeout},
+		{key: "database.path", env: "DB_PATH", usage: "path to the SQLite database", ptr: &cfg.Database.Path},
+		{key: "database.dsn", env: "DATABASE_URL", usage: "PostgreSQL connection URL; overrides database.path", secret: true, ptr: &cfg.Database.DSN},
+		{key: "auth.jwt_secret", env: "JWT_SECRET", usage: "secret key for signing JWTs", secret: true, ptr: &cfg.Auth.JwtSecret},
+		{key: "auth.access_token_ttl", env: "ACCESS_TOKEN_TTL", usage: "access token lifetime", ptr: &cfg.Auth.AccessTokenTTL},
+		{key: "auth.refresh_token_ttl", env: "REFRESH_TOKEN_TTL", usage: "refresh token lifetime", ptr: &cfg.Auth.RefreshTokenTTL},
+		{key: "ai.provider", env: "AI_PROVIDER", usage: "summarizer/tagger provider: 'gemini', 'openai' or 'local'", ptr: &cfg.AI.Provider},
+		{key: "ai.gemini.api_key", env: "GEMINI_API_KEY", usage: "Gemini API key", secret: true, ptr: &cfg.AI.Gemini.APIKey},
+		{key: "ai.gemini.model", env: "GEMINI_MODEL", usage: "Gemini model name", ptr: &cfg.AI.Gemini.Model},
+		{key: "ai.openai.base_url", env: "OPENAI_BASE_URL", usage: "base URL of the OpenAI-compatible API", ptr: &cfg.AI.OpenAI.BaseURL},
+		{key: "ai.openai.api_key", env: "OPENAI_API_KEY", usage: "OpenAI-compatible API key", secret: true, ptr: &cfg.AI.OpenAI.APIKey},
+		{key: "ai.openai.model", env: "OPENAI_MODEL", usage: "OpenAI-compatible model name", ptr: &cfg.AI.OpenAI.Model},
+		{key: "workers.count", env: "WORKER_COUNT", usage: "number of article processing workers", ptr: &cfg.Workers.Count},
+		{key: "workers.max_attempts", env: "JOB_MAX_ATTEMPTS", usage: "processing attempts before an article is marked as failed", ptr: &cfg.Workers.MaxAttempts},
+		{key: "fetcher.connect_timeout", env: "FETCH_CONNECT_TIMEOUT", usage: "time allowed to connect to an article's server", ptr: &cfg.Fetcher.ConnectTimeout},
+		{key: "fetcher.timeout", env: "FETCH_TIMEOUT", usage: "time allowed to download an article", ptr: &cfg.Fetcher.Timeout},
+		{key: "fetcher.max_body_bytes", env: "FETCH_MAX_BODY_BYTES", usage: "largest article download, in bytes", ptr: &cfg.Fetcher.MaxBodyBytes},
+		{key: "fetcher.max_redirects", env: "FETCH_MAX_REDIRECTS", usage: "redirects followed when downloading an article", ptr: &cfg.Fetcher.MaxRedirects},
+		{key: "fetcher.allow_hosts", env: "FETCH_ALLOW_HOSTS", usage: "comma-separated hosts, IPs or CIDRs that may be fetched even if private", ptr: &cfg.Fetcher.AllowHosts},
+		{key: "fetcher.deny_hosts", env: "FETCH_DENY_HOSTS", usage: "comma-separated hosts, IPs or CIDRs that are never fetched", ptr: &cfg.Fetcher.DenyHosts},
+		{key: "import.max_upload_bytes", env: "IMPORT_MAX_UPLOAD_BYTES", usage: "largest import file, in bytes", ptr: &cfg.Import.MaxUploadBytes},
+		{key: "import.enqueue_interval", env: "IMPORT_ENQUEUE_INTERVAL", usage: "delay between imported articles queued for processing", ptr: &cfg.Import.EnqueueInterval},
+		{key: "subscriptions.poll_interval", env: "SUBSCRIPTIONS_POLL_INTERVAL", usage: "how often subscribed feeds are checked for new entries", ptr: &cfg.Subscriptions.PollInterval},
+		{key: "subscriptions.max_new_entries", env: "SUBSCRIPTIONS_MAX_NEW_ENTRIES", usage: "most articles one check of a feed adds", ptr: &cfg.Subscriptions.MaxNewEntries},
+	}
+}
+
+// set parses value into the setting's field.
+func (s setting) set(value string) error {
+	switch p := s.ptr.(type) {
+	case *string:
+		*p = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: expected an integer, got %q", s.key, value)
+		}
+		*p = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: expected a duration such as 30s or 15m, got %q", s.key, value)
+		}
+		*p = d
+	case *[]string:
+		*p = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+	}
+	return nil
+}
+
+// String formats the setting's current value.
+func (s setting) String() string {
+	switch p := s.ptr.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *time.Duration:
+		return p.String()
+	case *[]string:
+		return strings.Join(*p, ",")
+	}
+	return ""
+}
+
+// Loaded is a configuration together with where each setting came from.
+type Loaded struct {
+	*Config
+	File    string            // Config file that was read, if any
+	Sources map[string]string // Setting key to Source* constant
+}
+
+// Load builds the configuration from, in increasing order of precedence: the
+// defaults, a YAML file, environment variables and command-line flags.
+//
+// The file is given with -config or CONFIG_FILE. Flags are parsed from args
+// (without the program name); the arguments left after the flags are returned.
+// The result is not validated; call Validate before using it to run the server.
+func Load(name string, args []string) (*Loaded, []string, error) {
+	cfg := Default()
+	loaded := &Loaded{Config: cfg, File: os.Getenv("CONFIG_FILE"), Sources: make(map[string]string)}
+	all := settings(cfg)
+	for _, s := range all {
+		loaded.Sources[s.key] = SourceDefault
+	}
+
+	// Flags are parsed first to find -config, but applied last so they win
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&loaded.File, "config", loaded.File, "path to a YAML config file (env CONFIG_FILE)")
+	var flagValues []func() error
+	for _, s := range all {
+		s := s
+		fs.Func(s.key, fmt.Sprintf("%s (env %s)", s.usage, s.env), func(value string) error {
+			flagValues = append(flagValues, func() error {
+				loaded.Sources[s.key] = SourceFlag
+				return s.set(value)
+			})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if loaded.File != "" {
+		before := snapshot(all)
+		data, err := os.ReadFile(loaded.File)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse config file %s: %w", loaded.File, err)
+		}
+		for i, s := range all {
+			if s.String() != before[i] {
+				loaded.Sources[s.key] = SourceFile
+			}
+		}
+	}
+
+	for _, s := range all {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("environment variable %s: %w", s.env, err)
+			}
+			loaded.Sources[s.key] = SourceEnv
+		}
+	}
+
+	for _, apply := range flagValues {
+		if err := apply(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return loaded, fs.Args(), nil
+}
+
+func snapshot(all []setting) []string {
+	values := make([]string, len(all))
+	for i, s := range all {
+		values[i] = s.String()
+	}
+	return values
+}
+
+// Print writes the effective configuration and the source of each value, with secrets redacted.
+func (l *Loaded) Print(w io.Writer) {
+	if l.File != "" {
+		fmt.Fprintf(w, "# config file: %s\n", l.File)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range settings(l.Config) {
+		value := s.String()
+		if s.secret && value != "" {
+			value = "[redacted]"
+		}
+		if value == "" {
+			value = `""`
+		}
+		fmt.Fprintf(tw, "%s\t%s\t(%s)\n", s.key, value, l.Sources[s.key])
+	}
+	tw.Flush()
+}
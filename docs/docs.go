@@ -35,7 +35,7 @@ const docTemplate = `{
         },
         "/articles": {
             "get": {
-                "description": "Retrieves all articles associated with a user.",
+                "description": "Retrieves the articles associated with a user, one page at a time.\nPass the returned next_cursor as cursor to fetch the following page, keeping the same filters and sort.",
                 "produces": [
                     "application/json"
                 ],
@@ -50,19 +50,82 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Filter by article tag",
+                        "description": "Filter by article tag (exact match, case-insensitive)",
                         "name": "tag",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by site name (exact match, case-insensitive)",
+                        "name": "site",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by author (exact match, case-insensitive)",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only articles that take at least this many minutes to read",
+                        "name": "min_reading_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only articles that take at most this many minutes to read",
+                        "name": "max_reading_time",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "updated_at",
+                            "title",
+                            "reading_time"
+                        ],
+                        "type": "string",
+                        "default": "created_at",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "desc",
+                        "description": "Sort direction",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor returned by the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of articles",
+                        "description": "Page of articles",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Article"
-                            }
+                            "$ref": "#/definitions/handlers.ArticleListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort, order, limit, reading time range or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
@@ -128,6 +191,60 @@ const docTemplate = `{
                 }
             }
         },
+        "/articles/search": {
+            "get": {
+                "description": "Full-text search over the titles, summaries, body text and tags of the user's articles, best matches first.\nSupports \"quoted phrases\", prefix* terms and the AND, OR and NOT operators.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Search articles",
+                "operationId": "search-articles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching articles with highlighted snippets",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Missing or invalid search query",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/articles/{id}": {
             "get": {
                 "description": "Retrieves an article by its ID.",
@@ -225,9 +342,105 @@ const docTemplate = `{
                 }
             }
         },
+        "/articles/{id}/job": {
+            "get": {
+                "description": "Returns the background processing state of an article: attempts, last error and next run time.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an article's processing job",
+                "operationId": "get-article-job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Article ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Processing job retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProcessingJob"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Processing job not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/articles/{id}/reprocess": {
+            "post": {
+                "description": "Fetches, summarizes and tags an article again, e.g. after it failed or when its content is out of date.\nTags added by its previous processing are replaced; tags the user set or imported are kept.\nThe article is \"processing\" until its new job finishes, and \"unread\" once it succeeds.\nRead articles stay \"read\" throughout; the processing job reports their progress.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Reprocess an article",
+                "operationId": "reprocess-article",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Article ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Article queued for processing",
+                        "schema": {
+                            "$ref": "#/definitions/models.Article"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Article not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Article is already being processed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/articles/{id}/status": {
             "put": {
-                "description": "Updates the status of an existing article.",
+                "description": "Updates the status of an existing article. The article.status_changed webhook event is only sent when the status actually changes.",
                 "consumes": [
                     "application/json"
                 ],
@@ -389,7 +602,7 @@ const docTemplate = `{
                     "200": {
                         "description": "User logged in successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/handlers.TokenResponse"
                         }
                     },
                     "400": {
@@ -415,7 +628,7 @@ const docTemplate = `{
         },
         "/auth/logout": {
             "post": {
-                "description": "Logs out a user by invalidating their JWT.",
+                "description": "Logs out a user by invalidating their JWT and the refresh tokens issued with it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -446,6 +659,56 @@ const docTemplate = `{
                 }
             }
         },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token.\nEach refresh token can be used once; presenting a used one again revokes every token from the same login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Refresh an access token",
+                "operationId": "refresh-token",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens refreshed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload or missing fields",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/register": {
             "post": {
                 "description": "Creates a new user account with a unique email address and hashed password.",
@@ -496,22 +759,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/tags": {
+        "/events": {
             "get": {
-                "description": "Retrieves all unique tags associated with articles for a user.",
+                "description": "Streams the progress of the user's articles through background processing as Server-Sent Events.\nEvery event has the event type as its name (queued, fetched, extracted, summarized, tagged, processed or failed) and a models.ArticleEvent as its data.\nReconnect with the Last-Event-ID header to receive the events missed in between, as long as the server still has them.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
+                ],
+                "summary": "Stream article events",
+                "operationId": "stream-events",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get all tags for a user",
-                "operationId": "get-tags-by-user",
                 "responses": {
                     "200": {
-                        "description": "List of tags",
+                        "description": "Stream of article events",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.ArticleEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Last-Event-ID",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
@@ -528,101 +802,1733 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ArticleSubmissionRequest": {
-            "type": "object",
-            "properties": {
-                "url": {
-                    "type": "string",
-                    "example": "https://example.com/article"
-                }
-            }
-        },
-        "handlers.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "An error occurred"
-                }
-            }
-        },
-        "handlers.LoginUserRequest": {
-            "type": "object",
-            "properties": {
-                "password": {
-                    "type": "string",
-                    "example": "verysecurepassword"
-                },
-                "username": {
-                    "type": "string",
-                    "example": "testuser"
-                }
-            }
         },
-        "handlers.MessageResponse": {
-            "type": "object",
-            "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Success message"
-                }
+        "/export": {
+            "get": {
+                "description": "Downloads the user's articles, newest first, with their summaries, tags, status and timestamps.\nFormats: JSON Lines (one models.Article per line), CSV, a Markdown digest, or Netscape bookmark HTML that browsers can import.\nThe export is streamed, so a failure part way through ends it early rather than returning an error status.",
+                "produces": [
+                    "application/x-ndjson",
+                    "text/csv",
+                    "text/markdown",
+                    "text/html"
+                ],
+                "summary": "Export articles",
+                "operationId": "export-articles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jsonl (default), csv, markdown or bookmarks",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only export articles with this status (e.g., read, unread)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only export articles with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Exported articles",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Unsupported format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/export/epub": {
+            "get": {
+                "description": "Builds an EPUB 3 book for e-readers with a chapter per article, newest first, from the content kept when the article was processed.\nEach chapter starts with the article's title, author, source URL, summary and, if a copy was kept, its lead image; the book has a table of contents.\nSelect the articles by ID, or by tag and status; without any of these the book has every article.\nThe book is streamed, so a failure part way through ends it early rather than returning an error status.",
+                "produces": [
+                    "application/epub+zip"
+                ],
+                "summary": "Export articles as an EPUB book",
+                "operationId": "export-epub",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated IDs of the articles, in the order of the chapters",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include articles with this status (e.g., read, unread)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include articles with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "EPUB book",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Too many article IDs",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "An article was not found, or no article matches",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed-tokens": {
+            "get": {
+                "description": "Retrieves the user's feed tokens, oldest first, without the tokens themselves.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all feed tokens for a user",
+                "operationId": "get-feed-tokens",
+                "responses": {
+                    "200": {
+                        "description": "Feed tokens retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FeedToken"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a token for the user's Atom and RSS feeds, which feed readers load without a JWT.\nAnyone with the token can read the feeds, so create one per feed reader and delete it to revoke its access.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a feed token",
+                "operationId": "create-feed-token",
+                "parameters": [
+                    {
+                        "description": "Feed token details",
+                        "name": "feed_token",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FeedTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Feed token created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FeedTokenCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed-tokens/{id}": {
+            "delete": {
+                "description": "Deletes a feed token; feed readers using it can no longer load the feeds.",
+                "summary": "Revoke a feed token",
+                "operationId": "delete-feed-token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Feed token revoked successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Feed token not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feeds/{token}/atom": {
+            "get": {
+                "description": "Lists the user's 50 newest processed articles as an Atom 1.0 feed, with their summaries as content.\nFeed readers can't send a JWT, so the feed is authorized by a feed token in the path; see POST /feed-tokens.\nSupports conditional requests with If-None-Match, using the ETag of the previous response.",
+                "produces": [
+                    "application/atom+xml"
+                ],
+                "summary": "Get the Atom feed of a user's articles",
+                "operationId": "get-atom-feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list articles with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list articles with this status (e.g., read, unread)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Atom feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Feed not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/feeds/{token}/rss": {
+            "get": {
+                "description": "Lists the user's 50 newest processed articles as an RSS 2.0 feed, with their summaries as descriptions.\nFeed readers can't send a JWT, so the feed is authorized by a feed token in the path; see POST /feed-tokens.\nSupports conditional requests with If-None-Match, using the ETag of the previous response.",
+                "produces": [
+                    "application/rss+xml"
+                ],
+                "summary": "Get the RSS feed of a user's articles",
+                "operationId": "get-rss-feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list articles with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only list articles with this status (e.g., read, unread)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "RSS feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Feed not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/import": {
+            "get": {
+                "description": "Retrieves the user's imports, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all imports for a user",
+                "operationId": "get-imports",
+                "responses": {
+                    "200": {
+                        "description": "Imports retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Import"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Imports a reading list exported from Pocket (HTML or CSV), Instapaper (CSV), Omnivore (JSON, or the export ZIP) or a browser (Netscape bookmark HTML).\nThe articles keep the date they were saved, whether they were read, and their tags. URLs already in the reading list are skipped.\nThe import runs in the background, queueing the articles for processing at a limited rate; follow its progress with GET /import/{id}.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Import articles",
+                "operationId": "import-articles",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Export file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pocket, instapaper, omnivore or bookmarks; detected from the file if omitted",
+                        "name": "format",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Import started",
+                        "schema": {
+                            "$ref": "#/definitions/models.Import"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing or unreadable export file",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Export file too large",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/{id}": {
+            "get": {
+                "description": "Retrieves the progress of an import, or its report once completed: how many entries were imported, skipped as duplicates or failed, and why.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get an import by ID",
+                "operationId": "get-import-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Import"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Import not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Retrieves the user's feed subscriptions, oldest first, with the outcome of their last check.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all subscriptions for a user",
+                "operationId": "get-subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Subscription"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes to an RSS, Atom or JSON feed. The feed is checked right away and then periodically,\nand every entry the subscription hasn't seen yet is added to the reading list with the subscription's tags\nand processed like a submitted article. A check adds at most a configured number of entries, the newest.\nWith keywords, only entries whose title or text mentions one of them (ignoring case) are added;\nentries skipped by the filter are not added later.\nFailed checks are retried with exponential backoff; last_error and error_count report them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Subscribe to a feed",
+                "operationId": "create-subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription details",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscribed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload or missing fields",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Already subscribed to this feed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Retrieves one of the user's feed subscriptions.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a subscription by ID",
+                "operationId": "get-subscription-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a subscription's title, tags, keywords or active flag. Fields left out are unchanged.\nThe new tags and keywords apply to entries added from now on.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a subscription",
+                "operationId": "update-subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.SubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a subscription. The articles it added stay in the reading list.",
+                "summary": "Unsubscribe from a feed",
+                "operationId": "delete-subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Unsubscribed successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "Retrieves all unique tags associated with articles for a user, with the number of articles using each tag.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all tags for a user",
+                "operationId": "get-tags-by-user",
+                "responses": {
+                    "200": {
+                        "description": "List of tags with article counts",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TagCount"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "description": "Returns the authenticated user's account and settings.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get the current user",
+                "operationId": "get-current-user",
+                "responses": {
+                    "200": {
+                        "description": "Current user",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/reading-speed": {
+            "put": {
+                "description": "Sets the words per minute used to estimate reading times, and recalculates the reading time of every saved article.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Set the reading speed",
+                "operationId": "update-reading-speed",
+                "parameters": [
+                    {
+                        "description": "Reading speed",
+                        "name": "speed",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReadingSpeedRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload or reading speed",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "Retrieves the user's webhooks. Secrets are not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all webhooks for a user",
+                "operationId": "get-webhooks",
+                "responses": {
+                    "200": {
+                        "description": "Webhooks retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers an endpoint that receives the user's article events as signed JSON POSTs.\nEvery delivery carries X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers.\nThe signature is \"sha256=\" followed by the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret.\nFailed deliveries are retried with exponential backoff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Register a webhook",
+                "operationId": "create-webhook",
+                "parameters": [
+                    {
+                        "description": "Webhook details",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Webhook registered successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WebhookCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload or missing fields",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "Retrieves one of the user's webhooks. The secret is not included.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a webhook by ID",
+                "operationId": "get-webhook-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a webhook's URL, secret, events or active flag. Fields left out are unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a webhook",
+                "operationId": "update-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.WebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Webhook updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request payload or missing fields",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a webhook along with its delivery log. Pending deliveries are not sent.",
+                "summary": "Delete a webhook",
+                "operationId": "delete-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Webhook deleted successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Retrieves the most recent deliveries to a webhook, newest first, with the outcome of their last attempt.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a webhook's deliveries",
+                "operationId": "get-webhook-deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deliveries retrieved successfully",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/test": {
+            "post": {
+                "description": "Sends a \"webhook.test\" event to the webhook right away, even if it is inactive, and returns the logged delivery.\nThe delivery is attempted once; check its status and response_status for the outcome.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Send a test delivery",
+                "operationId": "test-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Webhook ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Test delivery attempted",
+                        "schema": {
+                            "$ref": "#/definitions/models.WebhookDelivery"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized: User ID not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.ArticleListResponse": {
+            "type": "object",
+            "properties": {
+                "articles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Article"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Empty on the last page",
+                    "type": "string",
+                    "example": "eyJzIjoiY3JlYXRlZF9hdCJ9"
+                }
+            }
+        },
+        "handlers.ArticleSubmissionRequest": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/article"
+                }
+            }
+        },
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "An error occurred"
+                }
+            }
+        },
+        "handlers.FeedTokenCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "atom_url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/api/v1/feeds/q3V0bW9rZW4tZXhhbXBsZS12YWx1ZQ/atom"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Feed reader"
+                },
+                "rss_url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/api/v1/feeds/q3V0bW9rZW4tZXhhbXBsZS12YWx1ZQ/rss"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "q3V0bW9rZW4tZXhhbXBsZS12YWx1ZQ"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FeedTokenRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Feed reader"
+                }
+            }
+        },
+        "handlers.LoginUserRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "verysecurepassword"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "testuser@example.com"
+                }
+            }
+        },
+        "handlers.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Success message"
+                }
+            }
+        },
+        "handlers.ReadingSpeedRequest": {
+            "type": "object",
+            "properties": {
+                "words_per_minute": {
+                    "type": "integer",
+                    "example": 300
+                }
+            }
+        },
+        "handlers.RefreshTokenRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "b1Zq...x9Q"
+                }
+            }
+        },
+        "handlers.RegisterUserRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "verysecurepassword"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "testuser@example.com"
+                }
+            }
+        },
+        "handlers.SubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Defaults to true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "keywords": {
+                    "description": "Empty to add every entry",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "generics"
+                    ]
+                },
+                "tags": {
+                    "description": "Given to the articles added",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "go",
+                        "blogs"
+                    ]
+                },
+                "title": {
+                    "description": "Empty for the feed's title",
+                    "type": "string",
+                    "example": "Example Blog"
+                },
+                "url": {
+                    "description": "Can't be changed once subscribed",
+                    "type": "string",
+                    "example": "https://example.com/feed.xml"
+                }
+            }
+        },
+        "handlers.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "description": "Access token lifetime in seconds",
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "b1Zq...x9Q"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIs..."
+                }
+            }
+        },
+        "handlers.UpdateArticleStatusRequest": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "read",
+                        "unread"
+                    ],
+                    "example": "read"
+                }
+            }
+        },
+        "handlers.UpdateArticleTagRequest": {
+            "type": "object",
+            "properties": {
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "[\"tag1\"",
+                        "\"tag2\"]"
+                    ]
+                }
+            }
+        },
+        "handlers.WebhookCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Empty for every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string",
+                    "example": "7f3c9a1e5b2d4f6a8c0e"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.WebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Defaults to true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "events": {
+                    "description": "Empty for every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "article.processed",
+                        "article.failed"
+                    ]
+                },
+                "secret": {
+                    "description": "Generated when creating without one",
+                    "type": "string",
+                    "example": "7f3c9a1e5b2d4f6a8c0e"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/reading-list"
+                }
+            }
+        },
+        "models.Article": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "description": "Page metadata, empty when the page doesn't provide it",
+                    "type": "string"
+                },
+                "canonical_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "description": "Why processing failed, set while the status is \"failed\"",
+                    "type": "string"
+                },
+                "failure_stage": {
+                    "description": "\"fetch\", \"parse\", \"summarize\", \"tag\" or \"save\"",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image_url": {
+                    "description": "Lead image",
+                    "type": "string"
+                },
+                "language": {
+                    "description": "e.g. \"en\" or \"en-US\"",
+                    "type": "string"
+                },
+                "page_count": {
+                    "description": "Only set for PDFs",
+                    "type": "integer"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "reading_minutes": {
+                    "description": "Derived from WordCount and the user's reading speed",
+                    "type": "integer"
+                },
+                "site_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"processing\", \"failed\", \"read\", or \"unread\"",
+                    "type": "string"
+                },
+                "summary": {
+                    "description": "omitempty will hide if empty",
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "word_count": {
+                    "type": "integer"
+                }
             }
         },
-        "handlers.RegisterUserRequest": {
+        "models.ArticleEvent": {
             "type": "object",
             "properties": {
-                "password": {
-                    "type": "string",
-                    "example": "verysecurepassword"
+                "article_id": {
+                    "type": "string"
                 },
-                "username": {
+                "failure_reason": {
+                    "type": "string"
+                },
+                "failure_stage": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Increases with every event, across all users",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "The article's status after the event",
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Known once the article has been fetched",
+                    "type": "string"
+                },
+                "type": {
                     "type": "string",
-                    "example": "testuser@example.com"
+                    "example": "summarized"
                 }
             }
         },
-        "handlers.UpdateArticleStatusRequest": {
+        "models.FeedToken": {
             "type": "object",
             "properties": {
-                "status": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string",
-                    "enum": [
-                        "read",
-                        "unread",
-                        "processing",
-                        " failed"
-                    ],
-                    "example": "read"
+                    "example": "Feed reader"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "handlers.UpdateArticleTagRequest": {
+        "models.Import": {
             "type": "object",
             "properties": {
-                "tags": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "duplicates": {
+                    "description": "Skipped because the URL was already saved",
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "The first MaxImportErrors failed entries",
                     "type": "array",
                     "items": {
-                        "type": "string"
-                    },
-                    "example": [
-                        "[\"tag1\"",
-                        "\"tag2\"]"
-                    ]
+                        "$ref": "#/definitions/models.ImportError"
+                    }
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "format": {
+                    "description": "\"pocket\", \"instapaper\", \"omnivore\" or \"bookmarks\"",
+                    "type": "string",
+                    "example": "pocket"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "description": "Entries handled so far",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "\"running\", \"completed\" or \"failed\"",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Entries found in the file",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.Article": {
+        "models.ImportError": {
+            "type": "object",
+            "properties": {
+                "entry": {
+                    "description": "Position of the entry in the file, from 1",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProcessingJob": {
+            "type": "object",
+            "properties": {
+                "article_id": {
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"pending\", \"running\", \"succeeded\" or \"failed\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SearchResult": {
             "type": "object",
             "properties": {
+                "author": {
+                    "description": "Page metadata, empty when the page doesn't provide it",
+                    "type": "string"
+                },
+                "canonical_url": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "description": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "description": "Why processing failed, set while the status is \"failed\"",
+                    "type": "string"
+                },
+                "failure_stage": {
+                    "description": "\"fetch\", \"parse\", \"summarize\", \"tag\" or \"save\"",
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "image_url": {
+                    "description": "Lead image",
+                    "type": "string"
+                },
+                "language": {
+                    "description": "e.g. \"en\" or \"en-US\"",
+                    "type": "string"
+                },
+                "page_count": {
+                    "description": "Only set for PDFs",
+                    "type": "integer"
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "reading_minutes": {
+                    "description": "Derived from WordCount and the user's reading speed",
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "Higher is more relevant",
+                    "type": "number",
+                    "example": 4.2
+                },
+                "site_name": {
+                    "type": "string"
+                },
+                "snippet": {
+                    "type": "string",
+                    "example": "... using \u003cmark\u003egoroutines\u003c/mark\u003e and channels ..."
+                },
                 "status": {
                     "description": "\"processing\", \"failed\", \"read\", or \"unread\"",
                     "type": "string"
@@ -646,11 +2552,84 @@ const docTemplate = `{
                 "url": {
                     "type": "string"
                 },
+                "user_id": {
+                    "type": "string"
+                },
+                "word_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Subscription": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error_count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "keywords": {
+                    "description": "When not empty, only entries mentioning one are added",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "last_error": {
+                    "description": "Why the checks since the last successful one failed",
+                    "type": "string"
+                },
+                "last_polled_at": {
+                    "description": "Last successful check; nil until the first",
+                    "type": "string"
+                },
+                "next_poll_at": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Given to the articles added",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "description": "The feed's title unless the user sets one",
+                    "type": "string",
+                    "example": "Example Blog"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/feed.xml"
+                },
                 "user_id": {
                     "type": "string"
                 }
             }
         },
+        "models.TagCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "name": {
+                    "type": "string",
+                    "example": "golang"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
             "properties": {
@@ -662,6 +2641,87 @@ const docTemplate = `{
                 },
                 "username": {
                     "type": "string"
+                },
+                "words_per_minute": {
+                    "description": "Reading speed used for reading time estimates",
+                    "type": "integer",
+                    "example": 238
+                }
+            }
+        },
+        "models.Webhook": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Empty for every event",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "response_status": {
+                    "description": "HTTP status of the last attempt",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "\"pending\", \"sending\", \"succeeded\" or \"failed\"",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "webhook_id": {
+                    "type": "string"
                 }
             }
         }
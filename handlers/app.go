@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// ArticleQueue schedules articles for background processing.
+type ArticleQueue interface {
+	EnqueueArticle(ctx context.Context, article *models.Article) error
+}
+
+// EventStream delivers article events to the users they belong to.
+type EventStream interface {
+	// Subscribe returns the user's buffered events after lastEventID and a
+	// channel of new events, which is closed when the subscription ends.
+	Subscribe(userID string, lastEventID int64) ([]models.ArticleEvent, <-chan models.ArticleEvent, func())
+}
+
+// WebhookNotifier delivers article events to the users' webhooks.
+type WebhookNotifier interface {
+	// Notify queues the event for the article owner's subscribed webhooks.
+	Notify(ctx context.Context, event string, article *models.Article) error
+	// SendTest sends a test event to the webhook and returns the logged delivery.
+	SendTest(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error)
+}
+
+// ArticleImporter imports reading lists exported from other services.
+type ArticleImporter interface {
+	// ParseImport reads the entries of an export file, detecting its format if
+	// format is empty. An error means the file can't be imported.
+	ParseImport(format string, data []byte) (string, []models.ImportItem, error)
+	// StartImport records an import of the entries and runs it in the background.
+	StartImport(ctx context.Context, userID, format string, items []models.ImportItem) (*models.Import, error)
+}
+
+// FeedPoller checks subscribed feeds for new entries.
+type FeedPoller interface {
+	// Wake has the subscriptions that are due checked now.
+	Wake()
+}
+
+// App holds the dependencies of the HTTP handlers. Every handler is a method
+// on App, so tests can run them against a models.MemoryStore.
+type App struct {
+	Articles      models.ArticleStore
+	Jobs          models.JobStore
+	Users         models.UserStore
+	Tokens        models.TokenStore
+	Webhooks      models.WebhookStore
+	Imports       models.ImportStore
+	Images        models.ArticleImageStore
+	FeedTokens    models.FeedTokenStore
+	Subscriptions models.SubscriptionStore
+	Queue         ArticleQueue
+	Events        EventStream
+	Notifier      WebhookNotifier
+	Importer      ArticleImporter
+	Poller        FeedPoller
+
+	revocations *revocationCache
+}
+
+// NewApp creates the handlers for a storage backend, a processing queue, the
+// events it publishes, the webhooks notified of article changes, the importer
+// and the poller of subscribed feeds.
+func NewApp(store models.Store, queue ArticleQueue, events EventStream, notifier WebhookNotifier, importer ArticleImporter, poller FeedPoller) *App {
+	return &App{
+		Articles:      store,
+		Jobs:          store,
+		Users:         store,
+		Tokens:        store,
+		Webhooks:      store,
+		Imports:       store,
+		Images:        store,
+		FeedTokens:    store,
+		Subscriptions: store,
+		Queue:         queue,
+		Events:        events,
+		Notifier:      notifier,
+		Importer:      importer,
+		Poller:        poller,
+		revocations:   newRevocationCache(),
+	}
+}
+
+// notifyWebhooks queues an article event for the owner's webhooks. Webhooks
+// are best effort, so a failure is logged rather than failing the request.
+func (app *App) notifyWebhooks(ctx context.Context, event string, article *models.Article) {
+	if app.Notifier == nil {
+		return
+	}
+	if err := app.Notifier.Notify(ctx, event, article); err != nil {
+		log.Printf("Error queueing %s webhooks for article %s: %v", event, article.ID, err)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// testQueue records the articles handlers enqueue instead of processing them.
+type testQueue struct {
+	store models.JobStore
+
+	mu       sync.Mutex
+	enqueued []string // Article IDs
+}
+
+func (q *testQueue) EnqueueArticle(ctx context.Context, article *models.Article) error {
+	if err := q.store.EnqueueProcessingJob(ctx, article.ID, article.UserID, 3); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.enqueued = append(q.enqueued, article.ID)
+	return nil
+}
+
+// testServer is the API routed like main.go, on an App backed by a MemoryStore.
+type testServer struct {
+	t      *testing.T
+	store  *models.MemoryStore
+	queue  *testQueue
+	app    *App
+	router chi.Router
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := models.NewMemoryStore()
+	queue := &testQueue{store: store}
+	app := NewApp(store, queue, nil, nil, nil, nil)
+
+	r := chi.NewRouter()
+	r.Post("/api/v1/auth/register", app.RegisterUser)
+	r.Post("/api/v1/auth/login", app.LoginUser)
+	r.Post("/api/v1/auth/refresh", app.RefreshToken)
+	r.Post("/api/v1/auth/logout", app.LogoutUser)
+	r.Group(func(r chi.Router) {
+		r.Use(app.AuthMiddleware)
+		r.Post("/api/v1/articles", app.SubmitArticle)
+		r.Post("/api/v1/articles/{id}/reprocess", app.ReprocessArticle)
+		r.Get("/api/v1/articles/{id}", app.ReturnArticle)
+		r.Get("/api/v1/articles", app.GetArticlesByUserID)
+		r.Put("/api/v1/articles/{id}/status", app.UpdateArticleStatus)
+		r.Put("/api/v1/articles/{id}/tags", app.UpdateArticleTags)
+		r.Delete("/api/v1/articles/{id}", app.DeleteArticle)
+		r.Get("/api/v1/export/epub", app.ExportEPUB)
+	})
+	return &testServer{t: t, store: store, queue: queue, app: app, router: r}
+}
+
+// do sends a request with an optional bearer token and JSON body.
+func (s *testServer) do(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("encoding request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// login registers a user and returns their access token and ID.
+func (s *testServer) login(username string) (token, userID string) {
+	s.t.Helper()
+	credentials := map[string]string{"username": username, "password": "correct-horse-battery"}
+	if rec := s.do(http.MethodPost, "/api/v1/auth/register", "", credentials); rec.Code != http.StatusCreated {
+		s.t.Fatalf("register %s: status %d: %s", username, rec.Code, rec.Body)
+	}
+	rec := s.do(http.MethodPost, "/api/v1/auth/login", "", credentials)
+	if rec.Code != http.StatusOK {
+		s.t.Fatalf("login %s: status %d: %s", username, rec.Code, rec.Body)
+	}
+	var resp TokenResponse
+	decodeJSON(s.t, rec, &resp)
+	user, err := s.store.GetUserByUsername(context.Background(), username)
+	if err != nil {
+		s.t.Fatalf("looking up %s: %v", username, err)
+	}
+	return resp.Token, user.ID
+}
+
+// saveArticle stores an article directly, bypassing the handlers.
+func (s *testServer) saveArticle(a *models.Article) *models.Article {
+	s.t.Helper()
+	if err := s.store.SaveArticle(context.Background(), a); err != nil {
+		s.t.Fatalf("saving article: %v", err)
+	}
+	return a
+}
+
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body.String(), err)
+	}
+}
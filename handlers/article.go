@@ -2,15 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
 
 	// Import your custom packages
-	"github.com/jeana-hines/personal-reading-list-api/models"   // Import your models package
-	"github.com/jeana-hines/personal-reading-list-api/services" // Import your services package
+	"github.com/jeana-hines/personal-reading-list-api/models" // Import your models package
 )
 
 // Define a struct for the article submission request body
@@ -30,7 +33,7 @@ type ArticleSubmissionRequest struct {
 // @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /articles [post]
-func SubmitArticle(w http.ResponseWriter, r *http.Request) {
+func (app *App) SubmitArticle(w http.ResponseWriter, r *http.Request) {
 	// Get the user ID from the context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok {
@@ -53,6 +56,11 @@ func SubmitArticle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "URL is required", http.StatusBadRequest)
 		return
 	}
+	// Only web pages can be fetched; the fetcher re-checks every redirect as well
+	if !validWebURL(req.URL) {
+		http.Error(w, "URL must be an absolute http or https URL", http.StatusBadRequest)
+		return
+	}
 
 	// Create a new Article model instance
 	article := &models.Article{
@@ -62,13 +70,20 @@ func SubmitArticle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save the article to the database
-	err = article.Save()
+	err = app.Articles.SaveArticle(r.Context(), article)
 	if err != nil {
 		log.Printf("Error creating article in database: %v", err)
 		http.Error(w, "Failed to submit article", http.StatusInternalServerError)
 		return
 	}
-	go services.ProcessNewArticle(article)
+	// Queue the article for background processing; the job survives restarts
+	err = app.Queue.EnqueueArticle(r.Context(), article)
+	if err != nil {
+		log.Printf("Error enqueueing article %s for processing: %v", article.ID, err)
+		http.Error(w, "Failed to queue article for processing", http.StatusInternalServerError)
+		return
+	}
+	app.notifyWebhooks(r.Context(), models.WebhookEventArticleCreated, article)
 	// Respond with success (201 Created) and the created article object
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -87,7 +102,7 @@ func SubmitArticle(w http.ResponseWriter, r *http.Request) {
 // @Failure 404 {object} ErrorResponse "Article not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /articles/{id} [delete]
-func DeleteArticle(w http.ResponseWriter, r *http.Request) {
+func (app *App) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 	// Get the user ID from the context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok || userID == "" {
@@ -103,11 +118,23 @@ func DeleteArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Load the article first, so the webhooks can be told what was deleted
+	article, err := app.Articles.GetArticleByID(r.Context(), articleID, userID)
+	if err != nil {
+		log.Printf("Error retrieving article with ID %s for user %s: %v", articleID, userID, err)
+		http.Error(w, "Failed to delete article", http.StatusInternalServerError)
+		return
+	}
+	if article == nil {
+		http.Error(w, "Article not found or not owned by user", http.StatusNotFound)
+		return
+	}
+
 	// Call the model function to delete the article
-	err := models.DeleteArticle(articleID, userID)
+	err = app.Articles.DeleteArticle(r.Context(), articleID, userID)
 	if err != nil {
 		log.Printf("Error deleting article with ID %s for user %s: %v", articleID, userID, err)
-		if strings.Contains(err.Error(), "not found or not owned") {
+		if errors.Is(err, models.ErrArticleNotFound) {
 			http.Error(w, "Article not found or not owned by user", http.StatusNotFound)
 		} else {
 			http.Error(w, "Failed to delete article", http.StatusInternalServerError)
@@ -115,6 +142,7 @@ func DeleteArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.notifyWebhooks(r.Context(), models.WebhookEventArticleDeleted, article)
 	w.WriteHeader(http.StatusNoContent) // 204 No Content for successful deletion
 }
 
@@ -129,7 +157,7 @@ func DeleteArticle(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /articles/{id} [get]
 // GetArticleByID retrieves an article by its ID and user ID
-func ReturnArticle(w http.ResponseWriter, r *http.Request) {
+func (app *App) ReturnArticle(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok || userID == "" {
 		log.Println("Unauthorized: User ID not found in context")
@@ -145,7 +173,7 @@ func ReturnArticle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch the article from the database
-	article, err := models.GetArticleByID(articleID, userID)
+	article, err := app.Articles.GetArticleByID(r.Context(), articleID, userID)
 	if err != nil {
 		log.Printf("Error fetching article with ID %s: %v", userID, err)
 		http.Error(w, "Failed to fetch article", http.StatusInternalServerError)
@@ -162,17 +190,162 @@ func ReturnArticle(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// @Summary Get an article's processing job
+// @Description Returns the background processing state of an article: attempts, last error and next run time.
+// @ID get-article-job
+// @Produce json
+// @Param id path string true "Article ID"
+// @Success 200 {object} models.ProcessingJob "Processing job retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Processing job not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /articles/{id}/job [get]
+func (app *App) GetArticleJob(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	// Get Article ID from URL path parameter
+	articleID := chi.URLParam(r, "id")
+	if articleID == "" {
+		http.Error(w, "Article ID is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := app.Jobs.GetProcessingJobByArticleID(r.Context(), articleID, userID)
+	if err != nil {
+		log.Printf("Error fetching processing job for article %s: %v", articleID, err)
+		http.Error(w, "Failed to fetch processing job", http.StatusInternalServerError)
+		return
+	}
+	if job == nil {
+		http.Error(w, "Processing job not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary Reprocess an article
+// @Description Fetches, summarizes and tags an article again, e.g. after it failed or when its content is out of date.
+// @Description Tags added by its previous processing are replaced; tags the user set or imported are kept.
+// @Description The article is "processing" until its new job finishes, and "unread" once it succeeds.
+// @Description Read articles stay "read" throughout; the processing job reports their progress.
+// @ID reprocess-article
+// @Produce json
+// @Param id path string true "Article ID"
+// @Success 202 {object} models.Article "Article queued for processing"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Article not found"
+// @Failure 409 {object} ErrorResponse "Article is already being processed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /articles/{id}/reprocess [post]
+func (app *App) ReprocessArticle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	// Get Article ID from URL path parameter
+	articleID := chi.URLParam(r, "id")
+	if articleID == "" {
+		http.Error(w, "Article ID is required", http.StatusBadRequest)
+		return
+	}
+
+	article, err := app.Articles.GetArticleByID(r.Context(), articleID, userID)
+	if err != nil {
+		log.Printf("Error fetching article with ID %s: %v", articleID, err)
+		http.Error(w, "Failed to fetch article", http.StatusInternalServerError)
+		return
+	}
+	if article == nil {
+		http.Error(w, "Article not found", http.StatusNotFound)
+		return
+	}
+
+	// Articles stuck in "processing" without a live job can be reprocessed too.
+	// Checking first leaves a live job's article alone; the enqueue below settles races.
+	job, err := app.Jobs.GetProcessingJobByArticleID(r.Context(), articleID, userID)
+	if err != nil {
+		log.Printf("Error fetching processing job for article %s: %v", articleID, err)
+		http.Error(w, "Failed to fetch processing job", http.StatusInternalServerError)
+		return
+	}
+	if job != nil && (job.Status == models.JobStatusPending || job.Status == models.JobStatusRunning) {
+		http.Error(w, "Article is already being processed", http.StatusConflict)
+		return
+	}
+
+	previousStatus := article.Status
+	if article.Status != "read" { // Processing keeps read articles read
+		article.Status = "processing"
+	}
+	article.FailureReason = ""
+	article.FailureStage = ""
+	err = app.Articles.SaveArticle(r.Context(), article)
+	if err != nil {
+		log.Printf("Error updating article %s for reprocessing: %v", articleID, err)
+		if errors.Is(err, models.ErrArticleNotFound) {
+			http.Error(w, "Article not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to reprocess article", http.StatusInternalServerError)
+		}
+		return
+	}
+	err = app.Queue.EnqueueArticle(r.Context(), article)
+	if err != nil {
+		log.Printf("Error enqueueing article %s for processing: %v", article.ID, err)
+		if errors.Is(err, models.ErrProcessingJobActive) {
+			// A concurrent request queued it first; its job sets the final status
+			http.Error(w, "Article is already being processed", http.StatusConflict)
+		} else {
+			http.Error(w, "Failed to queue article for processing", http.StatusInternalServerError)
+		}
+		return
+	}
+	if previousStatus != article.Status {
+		app.notifyWebhooks(r.Context(), models.WebhookEventArticleStatusChanged, article)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(article)
+}
+
+// ArticleListResponse is one page of a user's articles.
+type ArticleListResponse struct {
+	Articles   []models.Article `json:"articles"`
+	NextCursor string           `json:"next_cursor,omitempty" example:"eyJzIjoiY3JlYXRlZF9hdCJ9"` // Empty on the last page
+}
+
 // @Summary Get all articles for a user
-// @Description Retrieves all articles associated with a user.
+// @Description Retrieves the articles associated with a user, one page at a time.
+// @Description Pass the returned next_cursor as cursor to fetch the following page, keeping the same filters and sort.
 // @ID get-articles-by-user
 // @Produce json
 // @Param status query string false "Filter by article status (e.g., read, unread)"
-// @Param tag query string false "Filter by article tag"
-// @Success 200 {array} models.Article "List of articles"
+// @Param tag query string false "Filter by article tag (exact match, case-insensitive)"
+// @Param site query string false "Filter by site name (exact match, case-insensitive)"
+// @Param author query string false "Filter by author (exact match, case-insensitive)"
+// @Param min_reading_time query int false "Only articles that take at least this many minutes to read"
+// @Param max_reading_time query int false "Only articles that take at most this many minutes to read"
+// @Param sort query string false "Sort field" Enums(created_at, updated_at, title, reading_time) default(created_at)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(desc)
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Success 200 {object} ArticleListResponse "Page of articles"
+// @Failure 400 {object} ErrorResponse "Invalid sort, order, limit, reading time range or cursor"
 // @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /articles [get]
-func GetArticlesByUserID(w http.ResponseWriter, r *http.Request) {
+func (app *App) GetArticlesByUserID(w http.ResponseWriter, r *http.Request) {
 	// This function returns all articles for a user from sqllite3 database
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok || userID == "" {
@@ -181,30 +354,127 @@ func GetArticlesByUserID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statusFilter := r.URL.Query().Get("status") // Optional status filter
-	tagFilter := r.URL.Query().Get("tag")       // Optional tag filter
+	query := r.URL.Query()
+	opts := models.ArticleListOptions{
+		Status: query.Get("status"), // Optional status filter
+		Tag:    query.Get("tag"),    // Optional tag filter
+		Site:   query.Get("site"),
+		Author: query.Get("author"),
+		Sort:   query.Get("sort"),
+		Order:  query.Get("order"),
+		Cursor: query.Get("cursor"),
+	}
+
+	if opts.Sort != "" && !models.ValidArticleSort(opts.Sort) {
+		http.Error(w, "Sort must be one of 'created_at', 'updated_at', 'title' or 'reading_time'", http.StatusBadRequest)
+		return
+	}
+	if opts.Order != "" && opts.Order != "asc" && opts.Order != "desc" {
+		http.Error(w, "Order must be 'asc' or 'desc'", http.StatusBadRequest)
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > models.MaxArticleListLimit {
+			http.Error(w, fmt.Sprintf("Limit must be a number between 1 and %d", models.MaxArticleListLimit), http.StatusBadRequest)
+			return
+		}
+		opts.Limit = limit
+	}
+	for _, bound := range []struct {
+		param string
+		value *int
+	}{{"min_reading_time", &opts.MinReadingMinutes}, {"max_reading_time", &opts.MaxReadingMinutes}} {
+		if str := query.Get(bound.param); str != "" {
+			minutes, err := strconv.Atoi(str)
+			if err != nil || minutes < 0 {
+				http.Error(w, fmt.Sprintf("%s must be a non-negative number of minutes", bound.param), http.StatusBadRequest)
+				return
+			}
+			*bound.value = minutes
+		}
+	}
+	if opts.MaxReadingMinutes > 0 && opts.MinReadingMinutes > opts.MaxReadingMinutes {
+		http.Error(w, "min_reading_time must not be greater than max_reading_time", http.StatusBadRequest)
+		return
+	}
 
-	articles, err := models.GetArticlesByUserID(userID, statusFilter, tagFilter)
+	articles, nextCursor, err := app.Articles.GetArticlesByUserID(r.Context(), userID, opts)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			http.Error(w, "Invalid cursor for this sort order", http.StatusBadRequest)
+			return
+		}
 		log.Printf("Error fetching articles for user %s: %v", userID, err)
 		http.Error(w, "Failed to fetch articles", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(articles) // Encode the articles directly to JSON
+	json.NewEncoder(w).Encode(ArticleListResponse{Articles: articles, NextCursor: nextCursor})
+}
+
+// @Summary Search articles
+// @Description Full-text search over the titles, summaries, body text and tags of the user's articles, best matches first.
+// @Description Supports "quoted phrases", prefix* terms and the AND, OR and NOT operators.
+// @ID search-articles
+// @Produce json
+// @Param q query string true "Search query"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Success 200 {array} models.SearchResult "Matching articles with highlighted snippets"
+// @Failure 400 {object} ErrorResponse "Missing or invalid search query"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /articles/search [get]
+func (app *App) SearchArticles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Search query 'q' is required", http.StatusBadRequest)
+		return
+	}
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > 100 {
+			http.Error(w, "Limit must be a number between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	results, err := app.Articles.SearchArticles(r.Context(), userID, query, limit)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidSearchQuery) {
+			http.Error(w, "Search query has no searchable terms", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error searching articles for user %s: %v", userID, err)
+		http.Error(w, "Failed to search articles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
 }
 
 // @Summary Get all tags for a user
-// @Description Retrieves all unique tags associated with articles for a user.
+// @Description Retrieves all unique tags associated with articles for a user, with the number of articles using each tag.
 // @ID get-tags-by-user
 // @Produce json
-// @Success 200 {array} string "List of tags"
+// @Success 200 {array} models.TagCount "List of tags with article counts"
 // @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /tags [get]
 // GetTagsByUserID retrieves all unique tags for a user
-func GetTagsByUserID(w http.ResponseWriter, r *http.Request) {
+func (app *App) GetTagsByUserID(w http.ResponseWriter, r *http.Request) {
 	// This function returns all tags for a user from sqllite3 database
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok || userID == "" {
@@ -213,7 +483,7 @@ func GetTagsByUserID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tags, err := models.GetTagsByUserID(userID)
+	tags, err := app.Articles.GetTagsByUserID(r.Context(), userID)
 	if err != nil {
 		log.Printf("Error fetching tags for user %s: %v", userID, err)
 		http.Error(w, "Failed to fetch tags for user", http.StatusInternalServerError)
@@ -226,11 +496,11 @@ func GetTagsByUserID(w http.ResponseWriter, r *http.Request) {
 
 // UpdateArticleStatusRequest defines the payload for updating an article's status.
 type UpdateArticleStatusRequest struct {
-	Status string `json:"status" example:"read" enums:"read,unread,processing, failed"`
+	Status string `json:"status" example:"read" enums:"read,unread"`
 }
 
 // @Summary Update an article's status
-// @Description Updates the status of an existing article.
+// @Description Updates the status of an existing article. The article.status_changed webhook event is only sent when the status actually changes.
 // @ID update-article-status
 // @Accept json
 // @Produce json
@@ -244,7 +514,7 @@ type UpdateArticleStatusRequest struct {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /articles/{id}/status [put]
 // UpdateArticleStatus updates the status of an existing article
-func UpdateArticleStatus(w http.ResponseWriter, r *http.Request) {
+func (app *App) UpdateArticleStatus(w http.ResponseWriter, r *http.Request) {
 	// Get the user ID from the context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok {
@@ -268,16 +538,15 @@ func UpdateArticleStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Status != "read" && req.Status != "unread" {
-		http.Error(w, "Status must be 'processing', 'read' or 'unread'", http.StatusBadRequest)
+		http.Error(w, "Status must be 'read' or 'unread'", http.StatusBadRequest)
 		return
 	}
 
-	// Call the new model function to update the status
-	err = models.UpdateArticleStatus(articleID, userID, req.Status)
+	changed, err := app.Articles.UpdateArticleStatus(r.Context(), articleID, userID, req.Status)
 	if err != nil {
 		log.Printf("Error updating article status for user %s, article %s: %v", userID, articleID, err)
 		// Check for the "not found" error from the model and return 404
-		if strings.Contains(err.Error(), "not found or not owned") {
+		if errors.Is(err, models.ErrArticleNotFound) {
 			http.Error(w, "Article not found or not owned by user", http.StatusNotFound)
 		} else {
 			http.Error(w, "Failed to update article status", http.StatusInternalServerError)
@@ -285,6 +554,16 @@ func UpdateArticleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The webhooks receive the whole article, so load it after the update.
+	// Setting the status an article already has is no change to report.
+	if changed {
+		if article, err := app.Articles.GetArticleByID(r.Context(), articleID, userID); err != nil {
+			log.Printf("Error fetching article %s for webhooks: %v", articleID, err)
+		} else if article != nil {
+			app.notifyWebhooks(r.Context(), models.WebhookEventArticleStatusChanged, article)
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(MessageResponse{Message: "Status updated successfully"})
 
@@ -309,7 +588,7 @@ type UpdateArticleTagRequest struct {
 // @Failure 404 {object} ErrorResponse "Article not found"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /articles/{id}/tags [put]
-func UpdateArticleTags(w http.ResponseWriter, r *http.Request) {
+func (app *App) UpdateArticleTags(w http.ResponseWriter, r *http.Request) {
 	// Get the user ID from the context (set by AuthMiddleware)
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok {
@@ -338,10 +617,10 @@ func UpdateArticleTags(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the new model function to update the tags
-	err = models.UpdateArticleTags(articleID, userID, req.Tags)
+	err = app.Articles.UpdateArticleTags(r.Context(), articleID, userID, req.Tags)
 	if err != nil {
 		log.Printf("Error updating article tags for user %s, article %s: %v", userID, articleID, err)
-		if strings.Contains(err.Error(), "not found or not owned") {
+		if errors.Is(err, models.ErrArticleNotFound) {
 			http.Error(w, "Article not found or not owned by user", http.StatusNotFound)
 		} else {
 			http.Error(w, "Failed to update article tags", http.StatusInternalServerError)
@@ -357,3 +636,9 @@ func UpdateArticleTags(w http.ResponseWriter, r *http.Request) {
 type MessageResponse struct {
 	Message string `json:"message" example:"Success message"`
 }
+
+// validWebURL reports whether rawURL is an absolute http or https URL.
+func validWebURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
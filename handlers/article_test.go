@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+func TestSubmitArticle(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+
+	rec := s.do(http.MethodPost, "/api/v1/articles", token, ArticleSubmissionRequest{URL: "https://example.com/post"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	var article models.Article
+	decodeJSON(t, rec, &article)
+	if article.ID == "" || article.UserID != userID || article.URL != "https://example.com/post" || article.Status != "processing" {
+		t.Errorf("submitted article = %+v", article)
+	}
+	if !reflect.DeepEqual(s.queue.enqueued, []string{article.ID}) {
+		t.Errorf("enqueued = %v, want [%s]", s.queue.enqueued, article.ID)
+	}
+	job, err := s.store.GetProcessingJobByArticleID(context.Background(), article.ID, userID)
+	if err != nil || job == nil || job.Status != models.JobStatusPending {
+		t.Errorf("processing job = %+v, %v; want a pending job", job, err)
+	}
+
+	for _, body := range []interface{}{
+		ArticleSubmissionRequest{},
+		ArticleSubmissionRequest{URL: "ftp://example.com/file"},
+		ArticleSubmissionRequest{URL: "/relative/path"},
+		"not an object",
+	} {
+		if rec := s.do(http.MethodPost, "/api/v1/articles", token, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("submitting %#v: status = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+	if len(s.queue.enqueued) != 1 {
+		t.Errorf("invalid submissions were enqueued: %v", s.queue.enqueued)
+	}
+}
+
+func TestListArticlesWithCursor(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+	_, otherID := s.login("other@example.com")
+
+	// Newest first is the default order
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var want []string
+	for i := 0; i < 5; i++ {
+		a := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/" + string(rune('a'+i)), Status: "unread", CreatedAt: start.Add(time.Duration(i) * time.Hour)})
+		want = append([]string{a.ID}, want...)
+	}
+	s.saveArticle(&models.Article{UserID: otherID, URL: "https://example.com/other", Status: "unread"})
+
+	var got []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(want) {
+			t.Fatalf("pagination didn't end after %d pages", pages)
+		}
+		path := "/api/v1/articles?limit=2"
+		if cursor != "" {
+			path += "&cursor=" + url.QueryEscape(cursor)
+		}
+		rec := s.do(http.MethodGet, path, token, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", path, rec.Code, rec.Body)
+		}
+		var page ArticleListResponse
+		decodeJSON(t, rec, &page)
+		if len(page.Articles) > 2 {
+			t.Fatalf("page has %d articles, want at most 2", len(page.Articles))
+		}
+		for _, a := range page.Articles {
+			got = append(got, a.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paged through %v, want %v", got, want)
+	}
+
+	// A cursor only fits the sort it was made for
+	rec := s.do(http.MethodGet, "/api/v1/articles?limit=2", token, nil)
+	var page ArticleListResponse
+	decodeJSON(t, rec, &page)
+	path := "/api/v1/articles?sort=title&cursor=" + url.QueryEscape(page.NextCursor)
+	if rec := s.do(http.MethodGet, path, token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("cursor with another sort: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/articles?cursor=garbage", token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed cursor: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/articles?limit=0", token, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("limit=0: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestUpdateArticleStatusAndTags(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+	otherToken, _ := s.login("other@example.com")
+	article := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/post", Status: "unread", Tags: []string{"go"}})
+	articlePath := "/api/v1/articles/" + article.ID
+
+	rec := s.do(http.MethodPut, articlePath+"/status", token, UpdateArticleStatusRequest{Status: "read"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status update: status %d: %s", rec.Code, rec.Body)
+	}
+	rec = s.do(http.MethodPut, articlePath+"/tags", token, UpdateArticleTagRequest{Tags: []string{" Databases ", "GO", "go"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("tags update: status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = s.do(http.MethodGet, articlePath, token, nil)
+	var got models.Article
+	decodeJSON(t, rec, &got)
+	if got.Status != "read" {
+		t.Errorf("status = %q, want read", got.Status)
+	}
+	if want := []string{"databases", "go"}; !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("tags = %v, want %v", got.Tags, want)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		body  interface{}
+		want  int
+	}{
+		{"unknown status", token, articlePath + "/status", UpdateArticleStatusRequest{Status: "archived"}, http.StatusBadRequest},
+		{"processing isn't set by hand", token, articlePath + "/status", UpdateArticleStatusRequest{Status: "processing"}, http.StatusBadRequest},
+		{"malformed status body", token, articlePath + "/status", "read", http.StatusBadRequest},
+		{"no tags", token, articlePath + "/tags", UpdateArticleTagRequest{}, http.StatusBadRequest},
+		{"another user's status", otherToken, articlePath + "/status", UpdateArticleStatusRequest{Status: "unread"}, http.StatusNotFound},
+		{"another user's tags", otherToken, articlePath + "/tags", UpdateArticleTagRequest{Tags: []string{"x"}}, http.StatusNotFound},
+		{"missing article", token, "/api/v1/articles/missing/status", UpdateArticleStatusRequest{Status: "read"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := s.do(http.MethodPut, tt.path, tt.token, tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+
+	// The failed updates changed nothing
+	rec = s.do(http.MethodGet, articlePath, token, nil)
+	decodeJSON(t, rec, &got)
+	if got.Status != "read" || !reflect.DeepEqual(got.Tags, []string{"databases", "go"}) {
+		t.Errorf("after rejected updates, article = %+v", got)
+	}
+}
+
+func TestDeleteArticle(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+	otherToken, _ := s.login("other@example.com")
+	article := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/post", Status: "unread"})
+	articlePath := "/api/v1/articles/" + article.ID
+
+	if rec := s.do(http.MethodDelete, articlePath, otherToken, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("deleting another user's article: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := s.do(http.MethodDelete, articlePath, token, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, want %d: %s", rec.Code, http.StatusNoContent, rec.Body)
+	}
+	if rec := s.do(http.MethodGet, articlePath, token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := s.do(http.MethodDelete, articlePath, token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestReprocessArticle(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+	article := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/post", Status: "read"})
+	path := "/api/v1/articles/" + article.ID + "/reprocess"
+
+	rec := s.do(http.MethodPost, path, token, nil)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("reprocess: status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body)
+	}
+	var got models.Article
+	decodeJSON(t, rec, &got)
+	if got.Status != "read" {
+		t.Errorf("reprocessed read article has status %q, want read", got.Status)
+	}
+
+	// The job is pending until a worker runs it
+	if rec := s.do(http.MethodPost, path, token, nil); rec.Code != http.StatusConflict {
+		t.Errorf("reprocess while pending: status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if len(s.queue.enqueued) != 1 {
+		t.Errorf("enqueued %d times, want once", len(s.queue.enqueued))
+	}
+}
+
+func TestArticleRoutesRejectUnauthenticated(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+	article := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/post", Status: "unread"})
+
+	if rec := s.do(http.MethodPost, "/api/v1/auth/logout", token, nil); rec.Code != http.StatusOK {
+		t.Fatalf("logout: status %d: %s", rec.Code, rec.Body)
+	}
+	revoked := token
+
+	routes := []struct {
+		method, path string
+		body         interface{}
+	}{
+		{http.MethodPost, "/api/v1/articles", ArticleSubmissionRequest{URL: "https://example.com/new"}},
+		{http.MethodGet, "/api/v1/articles", nil},
+		{http.MethodGet, "/api/v1/articles/" + article.ID, nil},
+		{http.MethodPut, "/api/v1/articles/" + article.ID + "/status", UpdateArticleStatusRequest{Status: "read"}},
+		{http.MethodPut, "/api/v1/articles/" + article.ID + "/tags", UpdateArticleTagRequest{Tags: []string{"x"}}},
+		{http.MethodDelete, "/api/v1/articles/" + article.ID, nil},
+	}
+	tokens := []struct {
+		name  string
+		token string
+	}{
+		{"no token", ""},
+		{"malformed token", "not-a-jwt"},
+		{"revoked token", revoked},
+	}
+	for _, route := range routes {
+		for _, tok := range tokens {
+			rec := s.do(route.method, route.path, tok.token, route.body)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("%s %s with %s: status = %d, want %d", route.method, route.path, tok.name, rec.Code, http.StatusUnauthorized)
+			}
+		}
+	}
+
+	// Nothing changed
+	got, err := s.store.GetArticleByID(context.Background(), article.ID, userID)
+	if err != nil || got == nil || got.Status != "unread" {
+		t.Errorf("article after rejected requests = %+v, %v", got, err)
+	}
+	if len(s.queue.enqueued) != 0 {
+		t.Errorf("rejected submission was enqueued: %v", s.queue.enqueued)
+	}
+}
+
+// recordingNotifier records the webhook events handlers send.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, event string, article *models.Article) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event+" "+article.Status)
+	return nil
+}
+
+func (n *recordingNotifier) SendTest(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func TestUpdateArticleStatusNotifiesChanges(t *testing.T) {
+	s := newTestServer(t)
+	notifier := &recordingNotifier{}
+	s.app.Notifier = notifier
+	token, userID := s.login("reader@example.com")
+	article := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/post", Status: "unread"})
+	path := "/api/v1/articles/" + article.ID + "/status"
+
+	for _, status := range []string{"read", "read", "unread", "unread"} {
+		if rec := s.do(http.MethodPut, path, token, UpdateArticleStatusRequest{Status: status}); rec.Code != http.StatusOK {
+			t.Fatalf("setting status %q: status %d: %s", status, rec.Code, rec.Body)
+		}
+	}
+	want := []string{models.WebhookEventArticleStatusChanged + " read", models.WebhookEventArticleStatusChanged + " unread"}
+	if !reflect.DeepEqual(notifier.events, want) {
+		t.Errorf("webhook events = %v, want %v", notifier.events, want)
+	}
+
+	rec := s.do(http.MethodPut, path, token, UpdateArticleStatusRequest{Status: "processing"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("setting status processing: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if got, want := strings.TrimSpace(rec.Body.String()), "Status must be 'read' or 'unread'"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
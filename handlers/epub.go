@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// epubImageExtensions maps the types of cached lead images to file extensions.
+var epubImageExtensions = map[string]string{"image/jpeg": "jpg", "image/png": "png", "image/gif": "gif", "image/webp": "webp"}
+
+// epubLanguagePattern matches the language tags that can be declared in an EPUB.
+var epubLanguagePattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+const epubStylesheet = `body { font-family: serif; line-height: 1.5; }
+header { margin-bottom: 2em; }
+h1 { font-size: 1.6em; line-height: 1.2; }
+.byline, .source { font-size: 0.9em; margin: 0.3em 0; }
+.source a { word-break: break-all; }
+.summary { font-style: italic; margin-top: 1em; }
+figure { margin: 1em 0; }
+img { max-width: 100%; height: auto; }
+pre { white-space: pre-wrap; font-size: 0.85em; }
+blockquote { margin-left: 1.5em; }
+`
+
+// epubWriter writes an EPUB 3 book with a chapter per article. Chapters are
+// written to the archive as they are added; the table of contents and package
+// document, which list them, follow the last one.
+type epubWriter struct {
+	zw       *zip.Writer
+	modified time.Time
+	language string // Of the first article that declares one
+	chapters []epubChapter
+	images   []string // Paths of the embedded images, relative to OEBPS/
+}
+
+type epubChapter struct {
+	path  string // Relative to OEBPS/
+	title string
+}
+
+// newEPUBWriter starts a book written to w.
+func newEPUBWriter(w io.Writer) (*epubWriter, error) {
+	e := &epubWriter{zw: zip.NewWriter(w), modified: time.Now().UTC().Truncate(time.Second)}
+
+	// The mimetype file must come first, uncompressed and without a data
+	// descriptor, so readers can identify the file from its first bytes
+	mimetype := []byte("application/epub+zip")
+	fw, err := e.zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		Modified:           e.modified,
+		CRC32:              crc32.ChecksumIEEE(mimetype),
+		CompressedSize64:   uint64(len(mimetype)),
+		UncompressedSize64: uint64(len(mimetype)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(mimetype); err != nil {
+		return nil, err
+	}
+
+	if err := e.writeFile("META-INF/container.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`); err != nil {
+		return nil, err
+	}
+	if err := e.writeFile("OEBPS/style.css", epubStylesheet); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *epubWriter) writeFile(name, content string) error {
+	return e.writeBytes(name, []byte(content))
+}
+
+func (e *epubWriter) writeBytes(name string, content []byte) error {
+	fw, err := e.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: e.modified})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	return err
+}
+
+// AddArticle writes a chapter with the article's front matter and content.
+// The lead image is embedded if image, its cached copy, is not nil.
+func (e *epubWriter) AddArticle(a *models.Article, image *models.ArticleImage) error {
+	n := len(e.chapters) + 1
+	chapter := epubChapter{path: fmt.Sprintf("articles/%04d.xhtml", n), title: articleTitle(a)}
+
+	var imagePath string
+	if image != nil {
+		imagePath = fmt.Sprintf("images/%04d.%s", n, epubImageExtensions[image.ContentType])
+		if err := e.writeBytes("OEBPS/"+imagePath, image.Data); err != nil {
+			return err
+		}
+		e.images = append(e.images, imagePath)
+	}
+
+	language := ""
+	if epubLanguagePattern.MatchString(a.Language) {
+		language = a.Language
+		if e.language == "" {
+			e.language = language
+		}
+	}
+
+	// Render the content first, to know whether it already shows the lead image
+	var content strings.Builder
+	leadShown := false
+	if a.ContentHTML != "" {
+		nodes, err := html.ParseFragment(strings.NewReader(a.ContentHTML), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+		if err != nil {
+			return fmt.Errorf("failed to parse content of article %s: %w", a.ID, err)
+		}
+		r := &xhtmlRenderer{w: &content, leadURL: a.ImageURL, leadPath: "../" + imagePath}
+		if imagePath == "" {
+			r.leadURL = ""
+		}
+		for _, node := range nodes {
+			r.render(node)
+		}
+		leadShown = r.leadShown
+	} else {
+		fmt.Fprintf(&content, "<p>The content of this article is not available offline.</p>\n")
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"`)
+	if language != "" {
+		fmt.Fprintf(&b, ` lang="%s" xml:lang="%s"`, language, language)
+	}
+	fmt.Fprintf(&b, `>
+<head>
+<meta charset="UTF-8"/>
+<title>%s</title>
+<link rel="stylesheet" type="text/css" href="../style.css"/>
+</head>
+<body>
+<section epub:type="chapter">
+<header>
+<h1>%s</h1>
+`, xmlEscape(chapter.title), xmlEscape(chapter.title))
+
+	var byline []string
+	if a.Author != "" {
+		byline = append(byline, "By "+xmlEscape(a.Author))
+	}
+	if a.SiteName != "" {
+		byline = append(byline, xmlEscape(a.SiteName))
+	}
+	if a.PublishedAt != nil {
+		byline = append(byline, a.PublishedAt.UTC().Format("January 2, 2006"))
+	}
+	if len(byline) > 0 {
+		fmt.Fprintf(&b, "<p class=\"byline\">%s</p>\n", strings.Join(byline, " · "))
+	}
+	fmt.Fprintf(&b, "<p class=\"source\"><a href=\"%s\">%s</a></p>\n", xmlEscape(a.URL), xmlEscape(a.URL))
+	if a.Summary != "" {
+		fmt.Fprintf(&b, "<p class=\"summary\">%s</p>\n", xmlEscape(a.Summary))
+	}
+	if imagePath != "" && !leadShown {
+		fmt.Fprintf(&b, "<figure><img src=\"../%s\" alt=\"\"/></figure>\n", imagePath)
+	}
+	b.WriteString("</header>\n")
+	b.WriteString(content.String())
+	b.WriteString("</section>\n</body>\n</html>\n")
+
+	if err := e.writeFile("OEBPS/"+chapter.path, b.String()); err != nil {
+		return err
+	}
+	e.chapters = append(e.chapters, chapter)
+	return nil
+}
+
+// Close writes the table of contents and the package document, which make
+// the chapters a book, and finishes the archive.
+func (e *epubWriter) Close(title string) error {
+	identifier := "urn:uuid:" + models.GenerateUUID()
+	language := e.language
+	if language == "" {
+		language = "en"
+	}
+
+	// The EPUB 3 navigation document
+	var nav strings.Builder
+	fmt.Fprintf(&nav, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%s" xml:lang="%s">
+<head>
+<meta charset="UTF-8"/>
+<title>%s</title>
+<link rel="stylesheet" type="text/css" href="style.css"/>
+</head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>Contents</h1>
+<ol>
+`, language, language, xmlEscape(title))
+	for _, chapter := range e.chapters {
+		fmt.Fprintf(&nav, "<li><a href=\"%s\">%s</a></li>\n", chapter.path, xmlEscape(chapter.title))
+	}
+	nav.WriteString("</ol>\n</nav>\n</body>\n</html>\n")
+	if err := e.writeFile("OEBPS/nav.xhtml", nav.String()); err != nil {
+		return err
+	}
+
+	// The EPUB 2 table of contents, for older e-readers
+	var ncx strings.Builder
+	fmt.Fprintf(&ncx, `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head>
+<meta name="dtb:uid" content="%s"/>
+<meta name="dtb:depth" content="1"/>
+<meta name="dtb:totalPageCount" content="0"/>
+<meta name="dtb:maxPageNumber" content="0"/>
+</head>
+<docTitle><text>%s</text></docTitle>
+<navMap>
+`, identifier, xmlEscape(title))
+	for i, chapter := range e.chapters {
+		fmt.Fprintf(&ncx, "<navPoint id=\"nav-%d\" playOrder=\"%d\"><navLabel><text>%s</text></navLabel><content src=\"%s\"/></navPoint>\n",
+			i+1, i+1, xmlEscape(chapter.title), chapter.path)
+	}
+	ncx.WriteString("</navMap>\n</ncx>\n")
+	if err := e.writeFile("OEBPS/toc.ncx", ncx.String()); err != nil {
+		return err
+	}
+
+	// The package document, listing every file and the reading order
+	var opf strings.Builder
+	fmt.Fprintf(&opf, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="%s">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="style" href="style.css" media-type="text/css"/>
+`, language, identifier, xmlEscape(title), language, e.modified.Format("2006-01-02T15:04:05Z"))
+	for i, chapter := range e.chapters {
+		fmt.Fprintf(&opf, "<item id=\"article-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapter.path)
+	}
+	for i, imagePath := range e.images {
+		fmt.Fprintf(&opf, "<item id=\"image-%d\" href=\"%s\" media-type=\"%s\"/>\n", i+1, imagePath, epubImageMediaType(imagePath))
+	}
+	opf.WriteString("</manifest>\n<spine toc=\"ncx\">\n<itemref idref=\"nav\"/>\n")
+	for i := range e.chapters {
+		fmt.Fprintf(&opf, "<itemref idref=\"article-%d\"/>\n", i+1)
+	}
+	opf.WriteString("</spine>\n</package>\n")
+	if err := e.writeFile("OEBPS/content.opf", opf.String()); err != nil {
+		return err
+	}
+
+	return e.zw.Close()
+}
+
+func epubImageMediaType(imagePath string) string {
+	for mediaType, extension := range epubImageExtensions {
+		if strings.HasSuffix(imagePath, "."+extension) {
+			return mediaType
+		}
+	}
+	return ""
+}
+
+// xmlEscaper escapes text for element content and quoted attribute values.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// xmlEscape escapes s for XML, dropping the characters XML doesn't allow,
+// such as most control characters.
+func xmlEscape(s string) string {
+	return xmlEscaper.Replace(strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) || r >= 0x10000 {
+			return r
+		}
+		return -1
+	}, s))
+}
+
+// xhtmlRenderer writes sanitized article HTML as XHTML. Images other than the
+// lead image are left out, since e-readers can't load them from the web; the
+// lead image is pointed at its embedded copy.
+type xhtmlRenderer struct {
+	w         *strings.Builder
+	leadURL   string // Empty if the lead image isn't embedded
+	leadPath  string
+	leadShown bool
+}
+
+func (r *xhtmlRenderer) render(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.w.WriteString(xmlEscape(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	attrs := n.Attr
+	if n.DataAtom == atom.Img {
+		if r.leadURL == "" || attrValue(n, "src") != r.leadURL {
+			return
+		}
+		attrs = []html.Attribute{{Key: "src", Val: r.leadPath}, {Key: "alt", Val: attrValue(n, "alt")}}
+		r.leadShown = true
+	}
+
+	r.w.WriteString("<" + n.Data)
+	for _, a := range attrs {
+		fmt.Fprintf(r.w, ` %s="%s"`, a.Key, xmlEscape(a.Val))
+	}
+	switch n.DataAtom {
+	case atom.Br, atom.Hr, atom.Img:
+		r.w.WriteString("/>")
+		return
+	}
+	r.w.WriteString(">")
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.render(c)
+	}
+	r.w.WriteString("</" + n.Data + ">")
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// The parts of the EPUB's XML documents the tests check.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Items []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+type epubNav struct {
+	Navs []struct {
+		Type  string `xml:"http://www.idpf.org/2007/ops type,attr"`
+		Links []struct {
+			Href  string `xml:"href,attr"`
+			Title string `xml:",chardata"`
+		} `xml:"ol>li>a"`
+	} `xml:"body>nav"`
+}
+
+// pngImage is a 1x1 PNG.
+var pngImage = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89" +
+	"\x00\x00\x00\rIDATx\x9cc\xf8\x0f\x00\x00\x01\x01\x00\x05\x18\xd8N\x00\x00\x00\x00IEND\xaeB`\x82")
+
+func TestExportEPUB(t *testing.T) {
+	s := newTestServer(t)
+	token, userID := s.login("reader@example.com")
+	ctx := context.Background()
+
+	withImage := s.saveArticle(&models.Article{
+		UserID: userID, URL: "https://example.com/pictures", Title: "Pictures & Words", Status: "unread",
+		Author: "Ada", Summary: "A summary.", ImageURL: "https://example.com/lead.png", Language: "en",
+		ContentHTML: `<p>First <b>bold</b> paragraph.<br>Next line</p><img src="https://example.com/inline.jpg">`,
+	})
+	if err := s.store.SaveArticleImage(ctx, &models.ArticleImage{ArticleID: withImage.ID, SourceURL: withImage.ImageURL, ContentType: "image/png", Data: pngImage}); err != nil {
+		t.Fatal(err)
+	}
+	// The cached image is of an earlier lead image, so it is left out
+	staleImage := s.saveArticle(&models.Article{
+		UserID: userID, URL: "https://example.com/stale", Title: "Stale image", Status: "read",
+		ImageURL: "https://example.com/new.png", ContentHTML: "<p>Text</p>",
+	})
+	if err := s.store.SaveArticleImage(ctx, &models.ArticleImage{ArticleID: staleImage.ID, SourceURL: "https://example.com/old.png", ContentType: "image/png", Data: pngImage}); err != nil {
+		t.Fatal(err)
+	}
+	noContent := s.saveArticle(&models.Article{UserID: userID, URL: "https://example.com/untitled", Status: "unread"})
+
+	rec := s.do(http.MethodGet, "/api/v1/export/epub?ids="+withImage.ID+","+staleImage.ID+","+noContent.ID, token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/epub+zip" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("reading the book as a zip: %v", err)
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	read := func(name string) []byte {
+		t.Helper()
+		f := files[name]
+		if f == nil {
+			t.Fatalf("the book has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// The mimetype file comes first and is stored uncompressed, so readers can sniff it
+	first := zr.File[0]
+	if first.Name != "mimetype" || first.Method != zip.Store {
+		t.Errorf("first entry is %q with method %d, want mimetype stored (method 0)", first.Name, first.Method)
+	}
+	if data := read("mimetype"); string(data) != "application/epub+zip" {
+		t.Errorf("mimetype = %q", data)
+	}
+	if off, err := first.DataOffset(); err != nil || off != int64(30+len("mimetype")) {
+		t.Errorf("mimetype data offset = %d, %v; want no extra field", off, err)
+	}
+
+	var container epubContainer
+	if err := xml.Unmarshal(read("META-INF/container.xml"), &container); err != nil {
+		t.Fatalf("parsing container.xml: %v", err)
+	}
+	if len(container.Rootfiles) != 1 || container.Rootfiles[0].FullPath != "OEBPS/content.opf" ||
+		container.Rootfiles[0].MediaType != "application/oebps-package+xml" {
+		t.Fatalf("container rootfiles = %+v, want OEBPS/content.opf", container.Rootfiles)
+	}
+
+	var pkg epubPackage
+	if err := xml.Unmarshal(read("OEBPS/content.opf"), &pkg); err != nil {
+		t.Fatalf("parsing content.opf: %v", err)
+	}
+	manifest := make(map[string]string) // href by ID
+	for _, item := range pkg.Items {
+		manifest[item.ID] = item.Href
+		if files["OEBPS/"+item.Href] == nil {
+			t.Errorf("manifest item %s refers to missing file %s", item.ID, item.Href)
+		}
+	}
+	var spine []string
+	for _, ref := range pkg.Spine {
+		href, ok := manifest[ref.IDRef]
+		if !ok {
+			t.Errorf("spine refers to %q, which isn't in the manifest", ref.IDRef)
+		}
+		spine = append(spine, href)
+	}
+	wantChapters := []string{"articles/0001.xhtml", "articles/0002.xhtml", "articles/0003.xhtml"}
+	if want := append([]string{"nav.xhtml"}, wantChapters...); strings.Join(spine, " ") != strings.Join(want, " ") {
+		t.Errorf("spine = %v, want %v", spine, want)
+	}
+	// Every chapter in the archive is in the manifest
+	for name := range files {
+		if strings.HasPrefix(name, "OEBPS/articles/") {
+			found := false
+			for _, href := range manifest {
+				found = found || "OEBPS/"+href == name
+			}
+			if !found {
+				t.Errorf("%s isn't in the manifest", name)
+			}
+		}
+	}
+
+	var nav epubNav
+	if err := xml.Unmarshal(read("OEBPS/nav.xhtml"), &nav); err != nil {
+		t.Fatalf("parsing nav.xhtml: %v", err)
+	}
+	if len(nav.Navs) != 1 || nav.Navs[0].Type != "toc" {
+		t.Fatalf("nav.xhtml navs = %+v, want one table of contents", nav.Navs)
+	}
+	wantTitles := []string{"Pictures & Words", "Stale image", "https://example.com/untitled"}
+	if links := nav.Navs[0].Links; len(links) != len(wantChapters) {
+		t.Errorf("table of contents = %+v, want %d entries", links, len(wantChapters))
+	} else {
+		for i, link := range links {
+			if link.Href != wantChapters[i] || link.Title != wantTitles[i] {
+				t.Errorf("table of contents entry %d = %q -> %s, want %q -> %s", i, link.Title, link.Href, wantTitles[i], wantChapters[i])
+			}
+		}
+	}
+
+	// Only the up to date lead image is embedded, and the chapter shows it
+	var images []string
+	for name := range files {
+		if strings.HasPrefix(name, "OEBPS/images/") {
+			images = append(images, name)
+		}
+	}
+	if len(images) != 1 || images[0] != "OEBPS/images/0001.png" {
+		t.Fatalf("embedded images = %v, want OEBPS/images/0001.png", images)
+	}
+	if !bytes.Equal(read("OEBPS/images/0001.png"), pngImage) {
+		t.Error("the embedded image differs from the cached copy")
+	}
+	imageListed := false
+	for _, item := range pkg.Items {
+		imageListed = imageListed || (item.Href == "images/0001.png" && item.MediaType == "image/png")
+	}
+	if !imageListed {
+		t.Error("the embedded image isn't in the manifest as image/png")
+	}
+	chapter := string(read("OEBPS/articles/0001.xhtml"))
+	if !strings.Contains(chapter, `src="../images/0001.png"`) {
+		t.Errorf("chapter 1 doesn't show the lead image:\n%s", chapter)
+	}
+	if strings.Contains(chapter, "https://example.com/inline.jpg") {
+		t.Errorf("chapter 1 links to a remote image:\n%s", chapter)
+	}
+	if strings.Contains(string(read("OEBPS/articles/0002.xhtml")), "<img") {
+		t.Error("chapter 2 shows an image, but its cached copy is stale")
+	}
+
+	// Every XHTML document is well-formed XML
+	for name := range files {
+		if path.Ext(name) == ".xhtml" {
+			decoder := xml.NewDecoder(bytes.NewReader(read(name)))
+			for {
+				if _, err := decoder.Token(); err != nil {
+					if !errors.Is(err, io.EOF) {
+						t.Errorf("%s isn't well-formed: %v", name, err)
+					}
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestExportEPUBNotFound(t *testing.T) {
+	s := newTestServer(t)
+	token, _ := s.login("reader@example.com")
+	_, otherID := s.login("other@example.com")
+	other := s.saveArticle(&models.Article{UserID: otherID, URL: "https://example.com/theirs", Status: "unread"})
+
+	if rec := s.do(http.MethodGet, "/api/v1/export/epub", token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("empty reading list: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/export/epub?ids="+other.ID, token, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("another user's article: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
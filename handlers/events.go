@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// eventKeepAliveInterval is how often an idle event stream sends a comment, so
+// proxies don't close the connection.
+const eventKeepAliveInterval = 30 * time.Second
+
+// @Summary Stream article events
+// @Description Streams the progress of the user's articles through background processing as Server-Sent Events.
+// @Description Every event has the event type as its name (queued, fetched, extracted, summarized, tagged, processed or failed) and a models.ArticleEvent as its data.
+// @Description Reconnect with the Last-Event-ID header to receive the events missed in between, as long as the server still has them.
+// @ID stream-events
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "ID of the last event received"
+// @Success 200 {object} models.ArticleEvent "Stream of article events"
+// @Failure 400 {object} ErrorResponse "Invalid Last-Event-ID"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /events [get]
+func (app *App) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var lastEventID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		parsed, err := strconv.ParseInt(header, 10, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Last-Event-ID must be an event ID", http.StatusBadRequest)
+			return
+		}
+		lastEventID = parsed
+	}
+
+	missed, events, unsubscribe := app.Events.Subscribe(userID, lastEventID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// The response controller finds the Flusher behind middleware wrappers
+	rc := http.NewResponseController(w)
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Error flushing event stream: %v", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return // Fell behind or shutting down; the client reconnects and resumes
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format.
+func writeEvent(w http.ResponseWriter, event models.ArticleEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// exportPageSize is how many articles an export reads from the database at a
+// time, so memory use doesn't grow with the size of the library.
+const exportPageSize = models.MaxArticleListLimit
+
+// exportWriter writes articles in one export format.
+type exportWriter interface {
+	Write(a *models.Article) error
+	// Close writes anything that follows the last article.
+	Close() error
+}
+
+// exportFormat is a format articles can be exported in.
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) (exportWriter, error) // Writes anything that precedes the first article
+}
+
+// exportFormats maps the format query parameter to export formats.
+var exportFormats = map[string]exportFormat{
+	"jsonl":     {contentType: "application/x-ndjson", extension: "jsonl", newWriter: newJSONLinesExportWriter},
+	"csv":       {contentType: "text/csv; charset=utf-8", extension: "csv", newWriter: newCSVExportWriter},
+	"markdown":  {contentType: "text/markdown; charset=utf-8", extension: "md", newWriter: newMarkdownExportWriter},
+	"bookmarks": {contentType: "text/html; charset=utf-8", extension: "html", newWriter: newBookmarksExportWriter},
+}
+
+// @Summary Export articles
+// @Description Downloads the user's articles, newest first, with their summaries, tags, status and timestamps.
+// @Description Formats: JSON Lines (one models.Article per line), CSV, a Markdown digest, or Netscape bookmark HTML that browsers can import.
+// @Description The export is streamed, so a failure part way through ends it early rather than returning an error status.
+// @ID export-articles
+// @Produce application/x-ndjson,text/csv,text/markdown,text/html
+// @Param format query string false "jsonl (default), csv, markdown or bookmarks"
+// @Param status query string false "Only export articles with this status (e.g., read, unread)"
+// @Param tag query string false "Only export articles with this tag"
+// @Success 200 {string} string "Exported articles"
+// @Failure 400 {object} ErrorResponse "Unsupported format"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /export [get]
+func (app *App) ExportArticles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = "jsonl"
+	}
+	format, ok := exportFormats[formatName]
+	if !ok {
+		http.Error(w, "Format must be 'jsonl', 'csv', 'markdown' or 'bookmarks'", http.StatusBadRequest)
+		return
+	}
+	pager := &articlePager{store: app.Articles, userID: userID, opts: models.ArticleListOptions{
+		Status: query.Get("status"),
+		Tag:    query.Get("tag"),
+		Limit:  exportPageSize,
+	}}
+
+	// Read the first page before answering, so a database error can still be reported
+	articles, err := pager.next(r.Context())
+	if err != nil {
+		log.Printf("Error fetching articles to export for user %s: %v", userID, err)
+		http.Error(w, "Failed to export articles", http.StatusInternalServerError)
+		return
+	}
+
+	setExportHeaders(w, format.contentType, format.extension)
+	ew, err := format.newWriter(w)
+	if err != nil {
+		return // The client went away
+	}
+
+	rc := http.NewResponseController(w)
+	for len(articles) > 0 {
+		for i := range articles {
+			if err := ew.Write(&articles[i]); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		articles, err = pager.next(r.Context())
+		if err != nil {
+			if !errors.Is(err, r.Context().Err()) {
+				log.Printf("Error fetching articles to export for user %s, ending the export early: %v", userID, err)
+			}
+			return
+		}
+	}
+	ew.Close()
+}
+
+// @Summary Export articles as an EPUB book
+// @Description Builds an EPUB 3 book for e-readers with a chapter per article, newest first, from the content kept when the article was processed.
+// @Description Each chapter starts with the article's title, author, source URL, summary and, if a copy was kept, its lead image; the book has a table of contents.
+// @Description Select the articles by ID, or by tag and status; without any of these the book has every article.
+// @Description The book is streamed, so a failure part way through ends it early rather than returning an error status.
+// @ID export-epub
+// @Produce application/epub+zip
+// @Param ids query string false "Comma separated IDs of the articles, in the order of the chapters"
+// @Param status query string false "Only include articles with this status (e.g., read, unread)"
+// @Param tag query string false "Only include articles with this tag"
+// @Success 200 {file} file "EPUB book"
+// @Failure 400 {object} ErrorResponse "Too many article IDs"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "An article was not found, or no article matches"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /export/epub [get]
+func (app *App) ExportEPUB(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	// Chosen articles are read up front, since they must all exist; the others
+	// are read a page at a time
+	query := r.URL.Query()
+	var articles []models.Article
+	var pager *articlePager
+	if ids := splitIDs(query.Get("ids")); len(ids) > 0 {
+		if len(ids) > models.MaxArticleListLimit {
+			http.Error(w, fmt.Sprintf("At most %d article IDs can be exported at once", models.MaxArticleListLimit), http.StatusBadRequest)
+			return
+		}
+		for _, id := range ids {
+			article, err := app.Articles.GetArticleByID(r.Context(), id, userID)
+			if err != nil {
+				log.Printf("Error fetching article with ID %s: %v", id, err)
+				http.Error(w, "Failed to export articles", http.StatusInternalServerError)
+				return
+			}
+			if article == nil {
+				http.Error(w, "Article not found: "+id, http.StatusNotFound)
+				return
+			}
+			articles = append(articles, *article)
+		}
+	} else {
+		pager = &articlePager{store: app.Articles, userID: userID, opts: models.ArticleListOptions{
+			Status: query.Get("status"),
+			Tag:    query.Get("tag"),
+			Limit:  epubPageSize,
+		}}
+		var err error
+		articles, err = pager.next(r.Context())
+		if err != nil {
+			log.Printf("Error fetching articles to export for user %s: %v", userID, err)
+			http.Error(w, "Failed to export articles", http.StatusInternalServerError)
+			return
+		}
+		if len(articles) == 0 {
+			http.Error(w, "No articles match", http.StatusNotFound)
+			return
+		}
+	}
+
+	setExportHeaders(w, "application/epub+zip", "epub")
+	book, err := newEPUBWriter(w)
+	if err != nil {
+		return // The client went away
+	}
+
+	rc := http.NewResponseController(w)
+	for len(articles) > 0 {
+		for i := range articles {
+			if err := book.AddArticle(&articles[i], app.exportImage(r.Context(), &articles[i])); err != nil {
+				log.Printf("Error adding article %s to EPUB export, ending it early: %v", articles[i].ID, err)
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+		if pager == nil {
+			break
+		}
+
+		articles, err = pager.next(r.Context())
+		if err != nil {
+			if !errors.Is(err, r.Context().Err()) {
+				log.Printf("Error fetching articles to export for user %s, ending the export early: %v", userID, err)
+			}
+			return
+		}
+	}
+	book.Close("Reading list, " + time.Now().UTC().Format("January 2, 2006"))
+}
+
+// epubPageSize is how many articles an EPUB export reads at a time. It is
+// smaller than exportPageSize since the articles are read with their content.
+const epubPageSize = 20
+
+// exportImage returns the cached lead image of an article, or nil if there is
+// none that can be embedded. Images are optional, so errors are only logged.
+func (app *App) exportImage(ctx context.Context, a *models.Article) *models.ArticleImage {
+	if a.ImageURL == "" || app.Images == nil {
+		return nil
+	}
+	img, err := app.Images.GetArticleImage(ctx, a.ID, a.UserID)
+	if err != nil {
+		log.Printf("Error fetching lead image of article %s: %v", a.ID, err)
+		return nil
+	}
+	// A copy of an earlier lead image is out of date
+	if img == nil || img.SourceURL != a.ImageURL || epubImageExtensions[img.ContentType] == "" {
+		return nil
+	}
+	return img
+}
+
+// articlePager reads the articles matching a list query one page at a time.
+type articlePager struct {
+	store  models.ArticleStore
+	userID string
+	opts   models.ArticleListOptions
+	done   bool
+}
+
+// next returns the next page of articles, which is empty after the last one.
+func (p *articlePager) next(ctx context.Context) ([]models.Article, error) {
+	if p.done {
+		return nil, nil
+	}
+	articles, nextCursor, err := p.store.GetArticlesByUserID(ctx, p.userID, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	p.opts.Cursor = nextCursor
+	p.done = nextCursor == ""
+	return articles, nil
+}
+
+// setExportHeaders makes the response a download named after the current date.
+func setExportHeaders(w http.ResponseWriter, contentType, extension string) {
+	filename := fmt.Sprintf("reading-list-%s.%s", time.Now().UTC().Format("2006-01-02"), extension)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+}
+
+// splitIDs splits a comma separated list of IDs, dropping empty entries.
+func splitIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// articleTitle returns the article's title, or its URL before it has one.
+func articleTitle(a *models.Article) string {
+	if a.Title != "" {
+		return a.Title
+	}
+	return a.URL
+}
+
+// jsonLinesExportWriter writes one JSON article per line.
+type jsonLinesExportWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONLinesExportWriter(w io.Writer) (exportWriter, error) {
+	return &jsonLinesExportWriter{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *jsonLinesExportWriter) Write(a *models.Article) error { return e.enc.Encode(a) }
+func (e *jsonLinesExportWriter) Close() error                  { return nil }
+
+// csvExportWriter writes a header row and one row per article. Tags are
+// separated by "|" and timestamps are RFC 3339.
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+var csvExportColumns = []string{"id", "url", "title", "status", "tags", "summary", "author", "site_name",
+	"published_at", "word_count", "reading_minutes", "created_at", "updated_at"}
+
+func newCSVExportWriter(w io.Writer) (exportWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvExportColumns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{w: cw}, nil
+}
+
+func (e *csvExportWriter) Write(a *models.Article) error {
+	var publishedAt string
+	if a.PublishedAt != nil {
+		publishedAt = a.PublishedAt.UTC().Format(time.RFC3339)
+	}
+	// The csv.Writer buffers, so rows only reach the client as the buffer fills
+	return e.w.Write([]string{
+		a.ID, a.URL, a.Title, a.Status, strings.Join(a.Tags, "|"), a.Summary, a.Author, a.SiteName,
+		publishedAt, strconv.Itoa(a.WordCount), strconv.Itoa(a.ReadingMinutes),
+		a.CreatedAt.UTC().Format(time.RFC3339), a.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (e *csvExportWriter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// markdownExportWriter writes a digest with a section per article.
+type markdownExportWriter struct {
+	w io.Writer
+}
+
+// markdownEscaper escapes the characters that would format text in Markdown.
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`)
+
+func newMarkdownExportWriter(w io.Writer) (exportWriter, error) {
+	_, err := fmt.Fprintf(w, "# Reading list\n\nExported on %s.\n", time.Now().UTC().Format("January 2, 2006"))
+	return &markdownExportWriter{w: w}, err
+}
+
+func (e *markdownExportWriter) Write(a *models.Article) error {
+	destination := a.URL
+	if strings.ContainsAny(destination, " ()<>") {
+		destination = "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(destination) + ">"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## [%s](%s)\n\n", markdownEscaper.Replace(articleTitle(a)), destination)
+
+	details := []string{a.Status}
+	for _, detail := range []string{a.SiteName, a.Author} {
+		if detail != "" {
+			details = append(details, markdownEscaper.Replace(detail))
+		}
+	}
+	details = append(details, "saved "+a.CreatedAt.UTC().Format("2006-01-02"))
+	if a.ReadingMinutes > 0 {
+		details = append(details, fmt.Sprintf("%d min read", a.ReadingMinutes))
+	}
+	fmt.Fprintf(&b, "*%s*\n", strings.Join(details, " · "))
+
+	if len(a.Tags) > 0 {
+		b.WriteString("\nTags: ")
+		for i, tag := range a.Tags {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("`" + strings.ReplaceAll(tag, "`", "'") + "`")
+		}
+		b.WriteString("\n")
+	}
+	if a.Summary != "" {
+		b.WriteString("\n" + markdownEscaper.Replace(a.Summary) + "\n")
+	}
+
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *markdownExportWriter) Close() error { return nil }
+
+// bookmarksExportWriter writes a Netscape bookmark file, the format browsers
+// import and export bookmarks in. Summaries become bookmark descriptions.
+type bookmarksExportWriter struct {
+	w io.Writer
+}
+
+func newBookmarksExportWriter(w io.Writer) (exportWriter, error) {
+	_, err := io.WriteString(w, `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Reading list</H1>
+<DL><p>
+`)
+	return &bookmarksExportWriter{w: w}, err
+}
+
+func (e *bookmarksExportWriter) Write(a *models.Article) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `    <DT><A HREF="%s" ADD_DATE="%d" LAST_MODIFIED="%d"`,
+		html.EscapeString(a.URL), a.CreatedAt.Unix(), a.UpdatedAt.Unix())
+	if len(a.Tags) > 0 {
+		fmt.Fprintf(&b, ` TAGS="%s"`, html.EscapeString(strings.Join(a.Tags, ",")))
+	}
+	fmt.Fprintf(&b, ">%s</A>\n", html.EscapeString(articleTitle(a)))
+	if a.Summary != "" {
+		fmt.Fprintf(&b, "    <DD>%s\n", html.EscapeString(a.Summary))
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *bookmarksExportWriter) Close() error {
+	_, err := io.WriteString(e.w, "</DL><p>\n")
+	return err
+}
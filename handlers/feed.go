@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// feedEntryLimit is how many of the newest articles a feed lists.
+const feedEntryLimit = 50
+
+// Atom 1.0 documents (RFC 4287).
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomPerson  `xml:"author"` // Of the entries that don't name one
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+// RSS 2.0 documents, with the Atom self link and Dublin Core creator
+// extensions feed validators recommend.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	SelfLink      rssLink   `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// @Summary Get the Atom feed of a user's articles
+// @Description Lists the user's 50 newest processed articles as an Atom 1.0 feed, with their summaries as content.
+// @Description Feed readers can't send a JWT, so the feed is authorized by a feed token in the path; see POST /feed-tokens.
+// @Description Supports conditional requests with If-None-Match, using the ETag of the previous response.
+// @ID get-atom-feed
+// @Produce application/atom+xml
+// @Param token path string true "Feed token"
+// @Param tag query string false "Only list articles with this tag"
+// @Param status query string false "Only list articles with this status (e.g., read, unread)"
+// @Success 200 {string} string "Atom feed"
+// @Failure 404 {object} ErrorResponse "Feed not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /feeds/{token}/atom [get]
+func (app *App) GetAtomFeed(w http.ResponseWriter, r *http.Request) {
+	title, articles, ok := app.feedArticles(w, r)
+	if !ok {
+		return
+	}
+
+	selfURL := requestBaseURL(r) + r.URL.RequestURI()
+	feed := atomFeed{
+		ID:      selfURL,
+		Title:   title,
+		Updated: feedUpdated(articles).Format(time.RFC3339),
+		Links:   []atomLink{{Rel: "self", Type: "application/atom+xml", Href: selfURL}},
+		Author:  atomPerson{Name: title},
+		Entries: make([]atomEntry, 0, len(articles)),
+	}
+	for i := range articles {
+		a := &articles[i]
+		entry := atomEntry{
+			ID:        "urn:uuid:" + a.ID,
+			Title:     articleTitle(a),
+			Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: a.URL}},
+			Published: a.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   a.UpdatedAt.UTC().Format(time.RFC3339),
+		}
+		if a.Author != "" {
+			entry.Author = &atomPerson{Name: a.Author}
+		}
+		for _, tag := range a.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if content := feedContent(a); content != "" {
+			entry.Content = &atomText{Type: "text", Text: content}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	serveFeed(w, r, "application/atom+xml; charset=utf-8", feed)
+}
+
+// @Summary Get the RSS feed of a user's articles
+// @Description Lists the user's 50 newest processed articles as an RSS 2.0 feed, with their summaries as descriptions.
+// @Description Feed readers can't send a JWT, so the feed is authorized by a feed token in the path; see POST /feed-tokens.
+// @Description Supports conditional requests with If-None-Match, using the ETag of the previous response.
+// @ID get-rss-feed
+// @Produce application/rss+xml
+// @Param token path string true "Feed token"
+// @Param tag query string false "Only list articles with this tag"
+// @Param status query string false "Only list articles with this status (e.g., read, unread)"
+// @Success 200 {string} string "RSS feed"
+// @Failure 404 {object} ErrorResponse "Feed not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /feeds/{token}/rss [get]
+func (app *App) GetRSSFeed(w http.ResponseWriter, r *http.Request) {
+	title, articles, ok := app.feedArticles(w, r)
+	if !ok {
+		return
+	}
+
+	selfURL := requestBaseURL(r) + r.URL.RequestURI()
+	feed := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:       title,
+			Link:        selfURL,
+			Description: "Articles saved to " + title,
+			SelfLink:    rssLink{Rel: "self", Type: "application/rss+xml", Href: selfURL},
+			Items:       make([]rssItem, 0, len(articles)),
+		},
+	}
+	if len(articles) > 0 {
+		feed.Channel.LastBuildDate = feedUpdated(articles).Format(time.RFC1123Z)
+	}
+	for i := range articles {
+		a := &articles[i]
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       articleTitle(a),
+			Link:        a.URL,
+			GUID:        rssGUID{ID: a.ID},
+			PubDate:     a.CreatedAt.UTC().Format(time.RFC1123Z),
+			Creator:     a.Author,
+			Categories:  a.Tags,
+			Description: feedContent(a),
+		})
+	}
+
+	serveFeed(w, r, "application/rss+xml; charset=utf-8", feed)
+}
+
+// feedArticles authorizes a feed request by its token and returns the feed's
+// title and articles. If it returns false, it has already written the error.
+func (app *App) feedArticles(w http.ResponseWriter, r *http.Request) (string, []models.Article, bool) {
+	feedToken, err := app.FeedTokens.UseFeedToken(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		log.Printf("Error looking up feed token: %v", err)
+		http.Error(w, "Failed to load feed", http.StatusInternalServerError)
+		return "", nil, false
+	}
+	if feedToken == nil {
+		http.Error(w, "Feed not found", http.StatusNotFound)
+		return "", nil, false
+	}
+
+	query := r.URL.Query()
+	pager := &articlePager{store: app.Articles, userID: feedToken.UserID, opts: models.ArticleListOptions{
+		Status: query.Get("status"),
+		Tag:    query.Get("tag"),
+		Limit:  feedEntryLimit,
+	}}
+
+	// Articles still being processed have no title or summary yet; they are
+	// listed once they are processed
+	articles := []models.Article{}
+	for len(articles) < feedEntryLimit {
+		page, err := pager.next(r.Context())
+		if err != nil {
+			log.Printf("Error fetching feed articles for user %s: %v", feedToken.UserID, err)
+			http.Error(w, "Failed to load feed", http.StatusInternalServerError)
+			return "", nil, false
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, a := range page {
+			if a.Status != "processing" && a.Status != "failed" && len(articles) < feedEntryLimit {
+				articles = append(articles, a)
+			}
+		}
+	}
+
+	var filters []string
+	if tag := query.Get("tag"); tag != "" {
+		filters = append(filters, "tagged "+models.NormalizeTag(tag))
+	}
+	if status := query.Get("status"); status != "" {
+		filters = append(filters, status)
+	}
+	title := "Reading list"
+	if len(filters) > 0 {
+		title += ": " + strings.Join(filters, ", ")
+	}
+	return title, articles, true
+}
+
+// feedContent is the text of a feed entry: the article's summary, or the page's
+// description if it has none.
+func feedContent(a *models.Article) string {
+	if a.Summary != "" {
+		return a.Summary
+	}
+	return a.Description
+}
+
+// feedUpdated returns when the newest change to the feed's articles was made.
+func feedUpdated(articles []models.Article) time.Time {
+	var updated time.Time
+	for _, a := range articles {
+		if a.UpdatedAt.After(updated) {
+			updated = a.UpdatedAt
+		}
+	}
+	if updated.IsZero() {
+		return time.Now().UTC()
+	}
+	return updated.UTC()
+}
+
+// serveFeed writes a feed document, answering conditional requests from feed
+// readers that already have it. The ETag is a hash of the document, so it
+// changes with any change to the feed, including deleted articles, which the
+// articles' modification times can't show.
+func serveFeed(w http.ResponseWriter, r *http.Request, contentType string, feed interface{}) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(feed); err != nil {
+		log.Printf("Error encoding feed: %v", err)
+		http.Error(w, "Failed to load feed", http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(buf.Bytes())
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
+
+// requestBaseURL returns the scheme and host the request was made to, taking
+// a TLS-terminating proxy into account.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
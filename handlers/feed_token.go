@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// maxFeedTokenNameLength bounds the names users give their feed tokens.
+const maxFeedTokenNameLength = 100
+
+// FeedTokenRequest is the payload for creating a feed token.
+type FeedTokenRequest struct {
+	Name string `json:"name,omitempty" example:"Feed reader"`
+}
+
+// FeedTokenCreatedResponse is a new feed token along with the token itself and
+// the feed URLs containing it. This is the only response that includes them.
+type FeedTokenCreatedResponse struct {
+	models.FeedToken
+	Token   string `json:"token" example:"q3V0bW9rZW4tZXhhbXBsZS12YWx1ZQ"`
+	AtomURL string `json:"atom_url" example:"http://localhost:8080/api/v1/feeds/q3V0bW9rZW4tZXhhbXBsZS12YWx1ZQ/atom"`
+	RSSURL  string `json:"rss_url" example:"http://localhost:8080/api/v1/feeds/q3V0bW9rZW4tZXhhbXBsZS12YWx1ZQ/rss"`
+}
+
+// @Summary Create a feed token
+// @Description Creates a token for the user's Atom and RSS feeds, which feed readers load without a JWT.
+// @Description Anyone with the token can read the feeds, so create one per feed reader and delete it to revoke its access.
+// @ID create-feed-token
+// @Accept json
+// @Produce json
+// @Param feed_token body FeedTokenRequest false "Feed token details"
+// @Success 201 {object} FeedTokenCreatedResponse "Feed token created successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /feed-tokens [post]
+func (app *App) CreateFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req FeedTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("Error decoding request body: %v", err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > maxFeedTokenNameLength {
+		http.Error(w, "Name must be at most "+strconv.Itoa(maxFeedTokenNameLength)+" characters", http.StatusBadRequest)
+		return
+	}
+
+	token, feedToken, err := app.FeedTokens.CreateFeedToken(r.Context(), userID, req.Name)
+	if err != nil {
+		log.Printf("Error creating feed token for user %s: %v", userID, err)
+		http.Error(w, "Failed to create feed token", http.StatusInternalServerError)
+		return
+	}
+
+	feedURL := requestBaseURL(r) + "/api/v1/feeds/" + token
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(FeedTokenCreatedResponse{
+		FeedToken: *feedToken,
+		Token:     token,
+		AtomURL:   feedURL + "/atom",
+		RSSURL:    feedURL + "/rss",
+	})
+}
+
+// @Summary Get all feed tokens for a user
+// @Description Retrieves the user's feed tokens, oldest first, without the tokens themselves.
+// @ID get-feed-tokens
+// @Produce json
+// @Success 200 {array} models.FeedToken "Feed tokens retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /feed-tokens [get]
+func (app *App) GetFeedTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := app.FeedTokens.GetFeedTokensByUserID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching feed tokens for user %s: %v", userID, err)
+		http.Error(w, "Failed to fetch feed tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// @Summary Revoke a feed token
+// @Description Deletes a feed token; feed readers using it can no longer load the feeds.
+// @ID delete-feed-token
+// @Param id path string true "Feed token ID"
+// @Success 204 "Feed token revoked successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Feed token not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /feed-tokens/{id} [delete]
+func (app *App) DeleteFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID := chi.URLParam(r, "id")
+	err := app.FeedTokens.DeleteFeedToken(r.Context(), tokenID, userID)
+	if err != nil {
+		log.Printf("Error deleting feed token %s for user %s: %v", tokenID, userID, err)
+		if errors.Is(err, models.ErrFeedTokenNotFound) {
+			http.Error(w, "Feed token not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to delete feed token", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
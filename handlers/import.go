@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+)
+
+// importFormMemory is how much of an upload is held in memory; the rest is
+// buffered in a temporary file.
+const importFormMemory = 8 << 20
+
+// @Summary Import articles
+// @Description Imports a reading list exported from Pocket (HTML or CSV), Instapaper (CSV), Omnivore (JSON, or the export ZIP) or a browser (Netscape bookmark HTML).
+// @Description The articles keep the date they were saved, whether they were read, and their tags. URLs already in the reading list are skipped.
+// @Description The import runs in the background, queueing the articles for processing at a limited rate; follow its progress with GET /import/{id}.
+// @ID import-articles
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Export file"
+// @Param format formData string false "pocket, instapaper, omnivore or bookmarks; detected from the file if omitted"
+// @Success 202 {object} models.Import "Import started"
+// @Failure 400 {object} ErrorResponse "Missing or unreadable export file"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 413 {object} ErrorResponse "Export file too large"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /import [post]
+func (app *App) ImportArticles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(config.Current.Import.MaxUploadBytes))
+	if err := r.ParseMultipartForm(importFormMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Export file too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		log.Printf("Error parsing import upload: %v", err)
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "An export file is required in the 'file' field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.Printf("Error reading import upload: %v", err)
+		http.Error(w, "Failed to read export file", http.StatusInternalServerError)
+		return
+	}
+
+	format, items, err := app.Importer.ParseImport(r.FormValue("format"), data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp, err := app.Importer.StartImport(r.Context(), userID, format, items)
+	if err != nil {
+		log.Printf("Error starting import for user %s: %v", userID, err)
+		http.Error(w, "Failed to start import", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(imp)
+}
+
+// @Summary Get all imports for a user
+// @Description Retrieves the user's imports, newest first.
+// @ID get-imports
+// @Produce json
+// @Success 200 {array} models.Import "Imports retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /import [get]
+func (app *App) GetImports(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	imports, err := app.Imports.GetImportsByUserID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching imports for user %s: %v", userID, err)
+		http.Error(w, "Failed to fetch imports", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imports)
+}
+
+// @Summary Get an import by ID
+// @Description Retrieves the progress of an import, or its report once completed: how many entries were imported, skipped as duplicates or failed, and why.
+// @ID get-import-by-id
+// @Produce json
+// @Param id path string true "Import ID"
+// @Success 200 {object} models.Import "Import retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Import not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /import/{id} [get]
+func (app *App) GetImport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	importID := chi.URLParam(r, "id")
+	imp, err := app.Imports.GetImportByID(r.Context(), importID, userID)
+	if err != nil {
+		log.Printf("Error fetching import %s for user %s: %v", importID, userID, err)
+		http.Error(w, "Failed to fetch import", http.StatusInternalServerError)
+		return
+	}
+	if imp == nil {
+		http.Error(w, "Import not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imp)
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
@@ -15,8 +17,31 @@ type ContextKey string
 
 const UserIDKey ContextKey = "userID"
 
+// parseToken validates a signed JWT and returns its claims.
+// Tokens without a jti claim predate revocation support and are rejected.
+func parseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// Make sure the signing method is what we expect
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return config.JwtSecret(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil, errors.New("token is missing jti or exp claim")
+	}
+	return claims, nil
+}
+
 // AuthMiddleware is a Chi middleware that validates JWTs and stores user ID in context
-func AuthMiddleware(next http.Handler) http.Handler {
+func (app *App) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get the Authorization header from the request
 		authHeader := r.Header.Get("Authorization")
@@ -35,20 +60,24 @@ func AuthMiddleware(next http.Handler) http.Handler {
 		tokenString := parts[1]
 
 		// Parse and validate the token
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			// Make sure the signing method is what we expect
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return config.JwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := parseToken(tokenString)
+		if err != nil {
 			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
+		// Reject tokens that were revoked by logging out
+		revoked, err := app.revocations.isRevoked(r.Context(), app.Tokens, claims.ID, claims.ExpiresAt.Time)
+		if err != nil {
+			log.Printf("Error checking token revocation: %v", err)
+			http.Error(w, "Failed to verify token", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+
 		// If the token is valid, get the user ID from the claims
 		userID := claims.UserID
 
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// revocationCacheTTL bounds how long a "not revoked" answer is trusted before the
+// database is asked again, so revocations made by other server instances are
+// picked up within this window. Revocations made by this instance apply immediately.
+const revocationCacheTTL = 30 * time.Second
+
+// revocationCacheMaxEntries triggers a sweep of stale entries when the cache grows.
+const revocationCacheMaxEntries = 10000
+
+type revocationEntry struct {
+	revoked   bool
+	checkedAt time.Time
+	expiresAt time.Time // Token expiry; revoked entries are kept until then
+}
+
+// revocationCache remembers revocation lookups so authenticated requests don't
+// hit the database every time.
+type revocationCache struct {
+	mu      sync.Mutex
+	entries map[string]revocationEntry
+}
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{entries: make(map[string]revocationEntry)}
+}
+
+// isRevoked reports whether the token with the given jti has been revoked,
+// asking tokens on a cache miss.
+func (c *revocationCache) isRevoked(ctx context.Context, tokens models.TokenStore, jti string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[jti]
+	c.mu.Unlock()
+	if ok && (entry.revoked || now.Sub(entry.checkedAt) < revocationCacheTTL) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := tokens.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+	c.set(jti, revocationEntry{revoked: revoked, checkedAt: now, expiresAt: expiresAt})
+	return revoked, nil
+}
+
+// markRevoked records a revocation made by this instance.
+func (c *revocationCache) markRevoked(jti string, expiresAt time.Time) {
+	c.set(jti, revocationEntry{revoked: true, checkedAt: time.Now(), expiresAt: expiresAt})
+}
+
+func (c *revocationCache) set(jti string, entry revocationEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= revocationCacheMaxEntries {
+		c.pruneLocked(time.Now())
+	}
+	c.entries[jti] = entry
+}
+
+// pruneLocked drops entries for expired tokens and stale "not revoked" answers.
+func (c *revocationCache) pruneLocked(now time.Time) {
+	for jti, entry := range c.entries {
+		if now.After(entry.expiresAt) || (!entry.revoked && now.Sub(entry.checkedAt) >= revocationCacheTTL) {
+			delete(c.entries, jti)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+const (
+	// maxSubscriptionTitleLength bounds the titles users give their subscriptions.
+	maxSubscriptionTitleLength = 200
+	// maxSubscriptionKeywords bounds a subscription's keyword filter.
+	maxSubscriptionKeywords = 50
+)
+
+// SubscriptionRequest is the payload for creating or updating a subscription.
+// When updating, fields left out keep their current value.
+type SubscriptionRequest struct {
+	URL      string   `json:"url" example:"https://example.com/feed.xml"` // Can't be changed once subscribed
+	Title    *string  `json:"title,omitempty" example:"Example Blog"`     // Empty for the feed's title
+	Tags     []string `json:"tags,omitempty" example:"go,blogs"`          // Given to the articles added
+	Keywords []string `json:"keywords,omitempty" example:"generics"`      // Empty to add every entry
+	Active   *bool    `json:"active,omitempty" example:"true"`            // Defaults to true
+}
+
+// validateSubscriptionRequest checks the fields that are set in a
+// subscription request and tidies the keywords. It returns a message for the
+// client, or "" if the request is valid.
+func validateSubscriptionRequest(req *SubscriptionRequest) string {
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL != "" && !validWebURL(req.URL) {
+		return "URL must be an absolute http or https URL"
+	}
+	if req.Title != nil {
+		title := strings.TrimSpace(*req.Title)
+		if len(title) > maxSubscriptionTitleLength {
+			return "Title must be at most " + strconv.Itoa(maxSubscriptionTitleLength) + " characters"
+		}
+		req.Title = &title
+	}
+	if req.Keywords != nil {
+		keywords := []string{}
+		seen := make(map[string]bool)
+		for _, keyword := range req.Keywords {
+			keyword = strings.Join(strings.Fields(keyword), " ")
+			if keyword != "" && !seen[strings.ToLower(keyword)] {
+				seen[strings.ToLower(keyword)] = true
+				keywords = append(keywords, keyword)
+			}
+		}
+		if len(keywords) > maxSubscriptionKeywords {
+			return "At most " + strconv.Itoa(maxSubscriptionKeywords) + " keywords are allowed"
+		}
+		req.Keywords = keywords
+	}
+	return ""
+}
+
+// @Summary Subscribe to a feed
+// @Description Subscribes to an RSS, Atom or JSON feed. The feed is checked right away and then periodically,
+// @Description and every entry the subscription hasn't seen yet is added to the reading list with the subscription's tags
+// @Description and processed like a submitted article. A check adds at most a configured number of entries, the newest.
+// @Description With keywords, only entries whose title or text mentions one of them (ignoring case) are added;
+// @Description entries skipped by the filter are not added later.
+// @Description Failed checks are retried with exponential backoff; last_error and error_count report them.
+// @ID create-subscription
+// @Accept json
+// @Produce json
+// @Param subscription body SubscriptionRequest true "Subscription details"
+// @Success 201 {object} models.Subscription "Subscribed successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or missing fields"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 409 {object} ErrorResponse "Already subscribed to this feed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /subscriptions [post]
+func (app *App) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if msg := validateSubscriptionRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "URL is required", http.StatusBadRequest)
+		return
+	}
+
+	sub := &models.Subscription{
+		UserID:   userID,
+		URL:      req.URL,
+		Tags:     req.Tags,
+		Keywords: req.Keywords,
+		Active:   req.Active == nil || *req.Active,
+	}
+	if req.Title != nil {
+		sub.Title = *req.Title
+	}
+	if err := app.Subscriptions.CreateSubscription(r.Context(), sub); err != nil {
+		log.Printf("Error creating subscription for user %s: %v", userID, err)
+		if errors.Is(err, models.ErrSubscriptionExists) {
+			http.Error(w, "Already subscribed to this feed", http.StatusConflict)
+		} else {
+			http.Error(w, "Failed to subscribe", http.StatusInternalServerError)
+		}
+		return
+	}
+	app.wakePoller()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Get all subscriptions for a user
+// @Description Retrieves the user's feed subscriptions, oldest first, with the outcome of their last check.
+// @ID get-subscriptions
+// @Produce json
+// @Success 200 {array} models.Subscription "Subscriptions retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /subscriptions [get]
+func (app *App) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	subs, err := app.Subscriptions.GetSubscriptionsByUserID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching subscriptions for user %s: %v", userID, err)
+		http.Error(w, "Failed to fetch subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// @Summary Get a subscription by ID
+// @Description Retrieves one of the user's feed subscriptions.
+// @ID get-subscription-by-id
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} models.Subscription "Subscription retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Subscription not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /subscriptions/{id} [get]
+func (app *App) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := app.requestSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Update a subscription
+// @Description Updates a subscription's title, tags, keywords or active flag. Fields left out are unchanged.
+// @Description The new tags and keywords apply to entries added from now on.
+// @ID update-subscription
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param subscription body SubscriptionRequest true "Fields to update"
+// @Success 200 {object} models.Subscription "Subscription updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Subscription not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /subscriptions/{id} [put]
+func (app *App) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, ok := app.requestSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if msg := validateSubscriptionRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if req.URL != "" && req.URL != sub.URL {
+		http.Error(w, "The URL of a subscription can't be changed; subscribe to the new URL instead", http.StatusBadRequest)
+		return
+	}
+
+	if req.Title != nil {
+		sub.Title = *req.Title
+	}
+	if req.Tags != nil {
+		sub.Tags = req.Tags
+	}
+	if req.Keywords != nil {
+		sub.Keywords = req.Keywords
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	err := app.Subscriptions.UpdateSubscription(r.Context(), sub)
+	if err != nil {
+		log.Printf("Error updating subscription %s: %v", sub.ID, err)
+		if errors.Is(err, models.ErrSubscriptionNotFound) {
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to update subscription", http.StatusInternalServerError)
+		}
+		return
+	}
+	if sub.Active {
+		app.wakePoller() // A reactivated subscription may be overdue
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Unsubscribe from a feed
+// @Description Deletes a subscription. The articles it added stay in the reading list.
+// @ID delete-subscription
+// @Param id path string true "Subscription ID"
+// @Success 204 "Unsubscribed successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Subscription not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /subscriptions/{id} [delete]
+func (app *App) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	subID := chi.URLParam(r, "id")
+	err := app.Subscriptions.DeleteSubscription(r.Context(), subID, userID)
+	if err != nil {
+		log.Printf("Error deleting subscription %s for user %s: %v", subID, userID, err)
+		if errors.Is(err, models.ErrSubscriptionNotFound) {
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestSubscription loads the user's subscription named by the {id} path
+// parameter. It writes an error response and returns false if there is none.
+func (app *App) requestSubscription(w http.ResponseWriter, r *http.Request) (*models.Subscription, bool) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	subID := chi.URLParam(r, "id")
+	sub, err := app.Subscriptions.GetSubscriptionByID(r.Context(), subID, userID)
+	if err != nil {
+		log.Printf("Error fetching subscription %s for user %s: %v", subID, userID, err)
+		http.Error(w, "Failed to fetch subscription", http.StatusInternalServerError)
+		return nil, false
+	}
+	if sub == nil {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return nil, false
+	}
+	return sub, true
+}
+
+// wakePoller has due subscriptions checked now rather than at the poller's next round.
+func (app *App) wakePoller() {
+	if app.Poller != nil {
+		app.Poller.Wake()
+	}
+}
@@ -1,8 +1,8 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,19 +17,22 @@ import (
 
 // Define a struct for JWT claims
 type Claims struct {
-	UserID string `json:"user_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid,omitempty"` // Refresh token family the token was issued for
 	jwt.RegisteredClaims
 }
 
-// generateJWT creates a new JWT for a given user ID
-func generateJWT(userID string) (string, error) {
-	// Set the token expiration time to, for example, 24 hours
-	expirationTime := time.Now().Add(24 * time.Hour)
+// generateJWT creates a new JWT for a given user ID and refresh token family
+func generateJWT(userID, sessionID string) (string, error) {
+	// Access tokens are short-lived; clients use the refresh token to get a new one
+	expirationTime := time.Now().Add(config.Current.Auth.AccessTokenTTL)
 
 	// Create the JWT claims, which includes the user ID and expiration time
 	claims := &Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        models.GenerateUUID(), // jti, used to revoke the token on logout
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -39,7 +42,7 @@ func generateJWT(userID string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	// Sign the token with our secret key
-	tokenString, err := token.SignedString(config.JwtSecret)
+	tokenString, err := token.SignedString(config.JwtSecret())
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -68,7 +71,7 @@ var emailRegex = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-
 // @Failure 409 {object} ErrorResponse "Username already exists"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/register [post]
-func RegisterUser(w http.ResponseWriter, r *http.Request) {
+func (app *App) RegisterUser(w http.ResponseWriter, r *http.Request) {
 	var req RegisterUserRequest
 	// Decode the JSON request body into our struct
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -91,13 +94,13 @@ func RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Check if the username already exists
-	_, err = models.GetUserByUsername(req.Username)
+	_, err = app.Users.GetUserByUsername(r.Context(), req.Username)
 	if err == nil {
 		http.Error(w, fmt.Sprintf("Username '%s' already exists", req.Username), http.StatusConflict) // 409 Conflict
 		return
 	}
 
-	if err != sql.ErrNoRows {
+	if !errors.Is(err, models.ErrUserNotFound) {
 		log.Printf("Error checking username existence: %v", err)
 		http.Error(w, "Failed to check username", http.StatusInternalServerError)
 		return
@@ -116,11 +119,11 @@ func RegisterUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Save the user to the database
-	err = models.CreateUser(user)
+	err = app.Users.CreateUser(r.Context(), user)
 	if err != nil {
 		// Check if the error indicates a duplicate username
-		if err.Error() == fmt.Sprintf("username '%s' already exists", req.Username) {
-			http.Error(w, err.Error(), http.StatusConflict) // 409 Conflict
+		if errors.Is(err, models.ErrUsernameTaken) {
+			http.Error(w, fmt.Sprintf("Username '%s' already exists", req.Username), http.StatusConflict) // 409 Conflict
 			return
 		}
 		log.Printf("Error creating user %s in database: %v", req.Username, err)
@@ -140,12 +143,12 @@ func RegisterUser(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param user body LoginUserRequest true "User login details"
-// @Success 200 {object} object{token:string} "User logged in successfully"
+// @Success 200 {object} TokenResponse "User logged in successfully"
 // @Failure 400 {object} ErrorResponse "Invalid request payload or missing fields"
 // @Failure 401 {object} ErrorResponse "Invalid username or password"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/login [post]
-func LoginUser(w http.ResponseWriter, r *http.Request) {
+func (app *App) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var req LoginUserRequest
 	// Decode the JSON request body into our struct
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -169,35 +172,90 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Authenticate the user
-	user, err := models.AuthenticateUser(req.Username, req.Password)
+	user, err := models.AuthenticateUser(r.Context(), app.Users, req.Username, req.Password)
 	if err != nil {
 		log.Printf("Authentication failed for user %s: %v", req.Username, err)
 		http.Error(w, "Invalid username or password", http.StatusUnauthorized) // 401 Unauthorized
 		return
 	}
 
-	// 1. Generate a JWT
-	tokenString, err := generateJWT(user.ID)
+	// 1. Start a new refresh token family for this login
+	refreshToken, rt, err := app.Tokens.CreateRefreshToken(r.Context(), user.ID, config.Current.Auth.RefreshTokenTTL)
+	if err != nil {
+		log.Printf("Error creating refresh token for user %s: %v", user.Username, err)
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	// 2. Generate a JWT
+	tokenString, err := generateJWT(user.ID, rt.FamilyID)
 	if err != nil {
 		log.Printf("Error generating JWT for user %s: %v", user.Username, err)
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
 
-	// 2. Send the token back to the client
-	response := struct {
-		Token string `json:"token"`
-	}{
-		Token: tokenString,
+	// 3. Send the tokens back to the client
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.Current.Auth.AccessTokenTTL.Seconds()),
+	})
+}
+
+// @Summary Refresh an access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token.
+// @Description Each refresh token can be used once; presenting a used one again revokes every token from the same login.
+// @ID refresh-token
+// @Accept json
+// @Produce json
+// @Param token body RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} TokenResponse "Tokens refreshed successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or missing fields"
+// @Failure 401 {object} ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /auth/refresh [post]
+func (app *App) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshTokenRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	refreshToken, rt, err := app.Tokens.RotateRefreshToken(r.Context(), req.RefreshToken, config.Current.Auth.RefreshTokenTTL)
+	if err != nil {
+		if errors.Is(err, models.ErrRefreshTokenInvalid) || errors.Is(err, models.ErrRefreshTokenReused) {
+			http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Error rotating refresh token: %v", err)
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	tokenString, err := generateJWT(rt.UserID, rt.FamilyID)
+	if err != nil {
+		log.Printf("Error generating JWT for user %s: %v", rt.UserID, err)
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
 	}
 
-	// Respond with success (200 OK) and the authenticated user object (excluding password hash)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response) // Encode the user struct directly to JSON
+	json.NewEncoder(w).Encode(TokenResponse{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(config.Current.Auth.AccessTokenTTL.Seconds()),
+	})
 }
 
 // @Summary Logout a user
-// @Description Logs out a user by invalidating their JWT.
+// @Description Logs out a user by invalidating their JWT and the refresh tokens issued with it.
 // @ID logout-user
 // @Accept json
 // @Produce json
@@ -205,7 +263,7 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid token format or claims"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /auth/logout [post]
-func LogoutUser(w http.ResponseWriter, r *http.Request) {
+func (app *App) LogoutUser(w http.ResponseWriter, r *http.Request) {
 	// Invalidate the JWT token
 	// Get the Authorization header from the request
 	authHeader := r.Header.Get("Authorization")
@@ -223,34 +281,117 @@ func LogoutUser(w http.ResponseWriter, r *http.Request) {
 	// Extract the token string
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	// Only tokens we signed can be revoked; this also yields the jti and expiry
+	claims, err := parseToken(tokenString)
 	if err != nil {
-		http.Error(w, "Invalid token format", http.StatusUnauthorized)
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 		return
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+
+	expirationTime := claims.ExpiresAt.Time
+
+	err = app.Tokens.RevokeToken(r.Context(), claims.ID, expirationTime)
+	if err != nil {
+		log.Printf("Error revoking token for user %s: %v", claims.UserID, err)
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
 		return
 	}
+	app.revocations.markRevoked(claims.ID, expirationTime)
 
-	expiresAt, ok := claims["exp"].(float64)
-	if !ok {
-		http.Error(w, "Token expiration time not found", http.StatusUnauthorized)
-		return
+	// End the session: the refresh token issued at login can no longer be used
+	if claims.SessionID != "" {
+		if err := app.Tokens.RevokeRefreshTokenFamily(r.Context(), claims.SessionID, claims.UserID); err != nil {
+			log.Printf("Error revoking refresh tokens for user %s: %v", claims.UserID, err)
+			http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+			return
+		}
 	}
 
-	expirationTime := time.Unix(int64(expiresAt), 0)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Logged out successfully"))
+}
+
+// @Summary Get the current user
+// @Description Returns the authenticated user's account and settings.
+// @ID get-current-user
+// @Produce json
+// @Success 200 {object} models.User "Current user"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me [get]
+func (app *App) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
 
-	// Call the function with the models package prefix
-	err = models.RevokeToken(tokenString, expirationTime)
+	user, err := app.Users.GetUserByID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		if errors.Is(err, models.ErrUserNotFound) {
+			http.Error(w, "Unauthorized: User not found", http.StatusUnauthorized)
+			return
+		}
+		log.Printf("Error fetching user %s: %v", userID, err)
+		http.Error(w, "Failed to fetch user", http.StatusInternalServerError)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Logged out successfully"))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(user)
+}
+
+// @Summary Set the reading speed
+// @Description Sets the words per minute used to estimate reading times, and recalculates the reading time of every saved article.
+// @ID update-reading-speed
+// @Accept json
+// @Produce json
+// @Param speed body ReadingSpeedRequest true "Reading speed"
+// @Success 200 {object} models.User "Updated user"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or reading speed"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /users/me/reading-speed [put]
+func (app *App) UpdateReadingSpeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req ReadingSpeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.WordsPerMinute < models.MinWordsPerMinute || req.WordsPerMinute > models.MaxWordsPerMinute {
+		http.Error(w, fmt.Sprintf("words_per_minute must be between %d and %d", models.MinWordsPerMinute, models.MaxWordsPerMinute), http.StatusBadRequest)
+		return
+	}
+
+	err := app.Users.SetUserWordsPerMinute(r.Context(), userID, req.WordsPerMinute)
+	if err == nil {
+		var user *models.User
+		user, err = app.Users.GetUserByID(r.Context(), userID)
+		if err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(user)
+			return
+		}
+	}
+	if errors.Is(err, models.ErrUserNotFound) {
+		http.Error(w, "Unauthorized: User not found", http.StatusUnauthorized)
+		return
+	}
+	log.Printf("Error updating reading speed for user %s: %v", userID, err)
+	http.Error(w, "Failed to update reading speed", http.StatusInternalServerError)
+}
+
+// ReadingSpeedRequest represents the request body for setting the reading speed
+type ReadingSpeedRequest struct {
+	WordsPerMinute int `json:"words_per_minute" example:"300"`
 }
 
 // LoginUserRequest represents the request body for user login
@@ -259,6 +400,18 @@ type LoginUserRequest struct {
 	Password string `json:"password" example:"verysecurepassword"`
 }
 
+// RefreshTokenRequest represents the request body for refreshing an access token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" example:"b1Zq...x9Q"`
+}
+
+// TokenResponse is returned by login and refresh
+type TokenResponse struct {
+	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIs..."`
+	RefreshToken string `json:"refresh_token" example:"b1Zq...x9Q"`
+	ExpiresIn    int    `json:"expires_in" example:"900"` // Access token lifetime in seconds
+}
+
 // ErrorResponse is a generic error response structure for Swagger documentation
 type ErrorResponse struct {
 	Message string `json:"message" example:"An error occurred"`
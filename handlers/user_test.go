@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRefreshToken(t *testing.T) {
+	s := newTestServer(t)
+	credentials := map[string]string{"username": "reader@example.com", "password": "correct-horse-battery"}
+	if rec := s.do(http.MethodPost, "/api/v1/auth/register", "", credentials); rec.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", rec.Code, rec.Body)
+	}
+	rec := s.do(http.MethodPost, "/api/v1/auth/login", "", credentials)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status %d: %s", rec.Code, rec.Body)
+	}
+	var login TokenResponse
+	decodeJSON(t, rec, &login)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/refresh", "", RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var refreshed TokenResponse
+	decodeJSON(t, rec, &refreshed)
+	if refreshed.Token == "" || refreshed.RefreshToken == "" || refreshed.RefreshToken == login.RefreshToken {
+		t.Fatalf("refresh returned %+v, want a new access token and refresh token", refreshed)
+	}
+	if rec := s.do(http.MethodGet, "/api/v1/articles", refreshed.Token, nil); rec.Code != http.StatusOK {
+		t.Errorf("listing articles with the refreshed token: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	tests := []struct {
+		name string
+		body interface{}
+		want int
+	}{
+		{"not an object", "refresh", http.StatusBadRequest},
+		{"missing token", RefreshTokenRequest{}, http.StatusBadRequest},
+		{"unknown token", RefreshTokenRequest{RefreshToken: "unknown"}, http.StatusUnauthorized},
+		{"reused token", RefreshTokenRequest{RefreshToken: login.RefreshToken}, http.StatusUnauthorized},
+		{"token of a family revoked by reuse", RefreshTokenRequest{RefreshToken: refreshed.RefreshToken}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := s.do(http.MethodPost, "/api/v1/auth/refresh", "", tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
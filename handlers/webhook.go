@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+const (
+	// minWebhookSecretLength keeps client-chosen secrets from being guessable.
+	minWebhookSecretLength = 16
+	// defaultDeliveryLimit and maxDeliveryLimit bound the delivery log returned at once.
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// WebhookRequest is the payload for creating or updating a webhook. When
+// updating, fields left out keep their current value.
+type WebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/hooks/reading-list"`
+	Secret string   `json:"secret,omitempty" example:"7f3c9a1e5b2d4f6a8c0e"`             // Generated when creating without one
+	Events []string `json:"events,omitempty" example:"article.processed,article.failed"` // Empty for every event
+	Active *bool    `json:"active,omitempty" example:"true"`                             // Defaults to true
+}
+
+// WebhookCreatedResponse is a new webhook along with the secret that signs
+// its deliveries. This is the only response that includes the secret.
+type WebhookCreatedResponse struct {
+	models.Webhook
+	Secret string `json:"secret" example:"7f3c9a1e5b2d4f6a8c0e"`
+}
+
+// validateWebhookRequest checks the fields that are set in a webhook request.
+// It returns a message for the client, or "" if the request is valid.
+func validateWebhookRequest(req *WebhookRequest) string {
+	if req.URL != "" && !validWebURL(req.URL) {
+		return "URL must be an absolute http or https URL"
+	}
+	if req.Secret != "" && len(req.Secret) < minWebhookSecretLength {
+		return "Secret must be at least " + strconv.Itoa(minWebhookSecretLength) + " characters"
+	}
+	for _, event := range req.Events {
+		if !containsEvent(event) {
+			return "Unknown webhook event '" + event + "'"
+		}
+	}
+	return ""
+}
+
+func containsEvent(event string) bool {
+	for _, e := range models.WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret returns a new random signing secret.
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// @Summary Register a webhook
+// @Description Registers an endpoint that receives the user's article events as signed JSON POSTs.
+// @Description Every delivery carries X-Webhook-Event, X-Webhook-Delivery, X-Webhook-Timestamp and X-Webhook-Signature headers.
+// @Description The signature is "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed with the secret.
+// @Description Failed deliveries are retried with exponential backoff.
+// @ID create-webhook
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook details"
+// @Success 201 {object} WebhookCreatedResponse "Webhook registered successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or missing fields"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks [post]
+func (app *App) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "URL is required", http.StatusBadRequest)
+		return
+	}
+	if msg := validateWebhookRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if req.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			log.Printf("Error generating webhook secret: %v", err)
+			http.Error(w, "Failed to register webhook", http.StatusInternalServerError)
+			return
+		}
+		req.Secret = secret
+	}
+	if req.Events == nil {
+		req.Events = []string{}
+	}
+	webhook := &models.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Active: req.Active == nil || *req.Active,
+	}
+	if err := app.Webhooks.CreateWebhook(r.Context(), webhook); err != nil {
+		log.Printf("Error creating webhook for user %s: %v", userID, err)
+		http.Error(w, "Failed to register webhook", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(WebhookCreatedResponse{Webhook: *webhook, Secret: webhook.Secret})
+}
+
+// @Summary Get all webhooks for a user
+// @Description Retrieves the user's webhooks. Secrets are not included.
+// @ID get-webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook "Webhooks retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks [get]
+func (app *App) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	webhooks, err := app.Webhooks.GetWebhooksByUserID(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching webhooks for user %s: %v", userID, err)
+		http.Error(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// @Summary Get a webhook by ID
+// @Description Retrieves one of the user's webhooks. The secret is not included.
+// @ID get-webhook-by-id
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.Webhook "Webhook retrieved successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [get]
+func (app *App) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.requestWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// @Summary Update a webhook
+// @Description Updates a webhook's URL, secret, events or active flag. Fields left out are unchanged.
+// @ID update-webhook
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param webhook body WebhookRequest true "Fields to update"
+// @Success 200 {object} models.Webhook "Webhook updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request payload or missing fields"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [put]
+func (app *App) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.requestWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if msg := validateWebhookRequest(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	if req.URL != "" {
+		webhook.URL = req.URL
+	}
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	err := app.Webhooks.UpdateWebhook(r.Context(), webhook)
+	if err != nil {
+		log.Printf("Error updating webhook %s: %v", webhook.ID, err)
+		if errors.Is(err, models.ErrWebhookNotFound) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// @Summary Delete a webhook
+// @Description Deletes a webhook along with its delivery log. Pending deliveries are not sent.
+// @ID delete-webhook
+// @Param id path string true "Webhook ID"
+// @Success 204 "Webhook deleted successfully"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id} [delete]
+func (app *App) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return
+	}
+
+	webhookID := chi.URLParam(r, "id")
+	err := app.Webhooks.DeleteWebhook(r.Context(), webhookID, userID)
+	if err != nil {
+		log.Printf("Error deleting webhook %s for user %s: %v", webhookID, userID, err)
+		if errors.Is(err, models.ErrWebhookNotFound) {
+			http.Error(w, "Webhook not found", http.StatusNotFound)
+		} else {
+			http.Error(w, "Failed to delete webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Get a webhook's deliveries
+// @Description Retrieves the most recent deliveries to a webhook, newest first, with the outcome of their last attempt.
+// @ID get-webhook-deliveries
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Maximum number of deliveries (default 50, max 200)"
+// @Success 200 {array} models.WebhookDelivery "Deliveries retrieved successfully"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/deliveries [get]
+func (app *App) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.requestWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(parsed, maxDeliveryLimit)
+	}
+
+	deliveries, err := app.Webhooks.GetWebhookDeliveries(r.Context(), webhook.ID, webhook.UserID, limit)
+	if err != nil {
+		log.Printf("Error fetching deliveries for webhook %s: %v", webhook.ID, err)
+		http.Error(w, "Failed to fetch webhook deliveries", http.StatusInternalServerError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// @Summary Send a test delivery
+// @Description Sends a "webhook.test" event to the webhook right away, even if it is inactive, and returns the logged delivery.
+// @Description The delivery is attempted once; check its status and response_status for the outcome.
+// @ID test-webhook
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} models.WebhookDelivery "Test delivery attempted"
+// @Failure 401 {object} ErrorResponse "Unauthorized: User ID not found"
+// @Failure 404 {object} ErrorResponse "Webhook not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /webhooks/{id}/test [post]
+func (app *App) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := app.requestWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := app.Notifier.SendTest(r.Context(), webhook)
+	if err != nil {
+		log.Printf("Error sending test delivery to webhook %s: %v", webhook.ID, err)
+		http.Error(w, "Failed to send test delivery", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// requestWebhook loads the user's webhook named by the {id} path parameter.
+// It writes an error response and returns false if there is none.
+func (app *App) requestWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	userID, ok := r.Context().Value(UserIDKey).(string)
+	if !ok || userID == "" {
+		log.Println("Unauthorized: User ID not found in context")
+		http.Error(w, "Unauthorized: User ID not found", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	webhookID := chi.URLParam(r, "id")
+	webhook, err := app.Webhooks.GetWebhookByID(r.Context(), webhookID, userID)
+	if err != nil {
+		log.Printf("Error fetching webhook %s for user %s: %v", webhookID, userID, err)
+		http.Error(w, "Failed to fetch webhook", http.StatusInternalServerError)
+		return nil, false
+	}
+	if webhook == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return nil, false
+	}
+	return webhook, true
+}
@@ -9,7 +9,6 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,9 +16,11 @@ import (
 	// Import for Swagger UI
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 
+	"github.com/jeana-hines/personal-reading-list-api/config"
 	_ "github.com/jeana-hines/personal-reading-list-api/docs" // This will be generated by `swag init`
 	"github.com/jeana-hines/personal-reading-list-api/handlers"
 	"github.com/jeana-hines/personal-reading-list-api/models"
+	"github.com/jeana-hines/personal-reading-list-api/services"
 )
 
 // @title           Personal Reading List API
@@ -28,9 +29,74 @@ import (
 // @host            localhost:8080
 // @BasePath        /api/v1
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		}
+	}
+
+	// Load and validate the configuration: defaults < config file < environment < flags
+	cfg, err := loadConfig("reading-list-api", os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.IsDev() && cfg.Auth.JwtSecret == config.DefaultJwtSecret {
+		log.Println("WARNING: using the default JWT secret; set JWT_SECRET before running in production.")
+	}
+	config.Current = cfg.Config
+
 	// Initialize Database
-	models.InitDB("./reading_list.db") // This will create/open 'reading_list.db' in project root
-	defer models.CloseDB()
+	store := models.InitDB(databaseDSN(cfg.Config))
+	defer store.Close()
+
+	// Select the summarizer/tagger backend; falls back to the offline provider if misconfigured
+	services.SetProvider(services.ProviderFromConfig())
+	services.SetFetcher(services.NewFetcher(cfg.Fetcher))
+
+	// Processing progress is published to the event bus and streamed to clients
+	events := services.NewEventBus()
+	services.SetEventBus(events)
+
+	// Article changes are also delivered to the users' webhooks, retrying failed deliveries
+	webhooks := services.NewWebhookDispatcher(store)
+	services.SetWebhookDispatcher(webhooks)
+	if err := webhooks.Recover(context.Background()); err != nil {
+		log.Printf("Error recovering webhook deliveries: %v", err)
+	}
+	webhooks.Start()
+
+	// Start the article processing workers, re-enqueueing anything a previous run left unfinished
+	workers := services.NewWorkerPool(store, cfg.Workers.Count)
+	if err := workers.Recover(context.Background()); err != nil {
+		log.Printf("Error recovering processing jobs: %v", err)
+	}
+	workers.Start()
+
+	// Imports queue their articles on the workers at a limited rate; interrupted ones resume
+	importer := services.NewImporter(store, workers, cfg.Import.EnqueueInterval)
+	if err := importer.Recover(context.Background()); err != nil {
+		log.Printf("Error resuming imports: %v", err)
+	}
+
+	// Subscribed feeds are checked periodically; their new entries are queued on the workers
+	poller := services.NewSubscriptionPoller(store, workers, cfg.Subscriptions)
+	poller.Start()
+
+	// Periodically delete expired token revocations and refresh tokens
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	go services.RunTokenSweeper(sweeperCtx, store, services.TokenSweepInterval)
+
+	// The HTTP handlers, backed by the database, the processing queue, its events, the webhooks, the importer
+	// and the subscription poller
+	app := handlers.NewApp(store, workers, events, webhooks, importer, poller)
 
 	// Initialize Chi Router
 	// Chi is a lightweight router for Go HTTP services
@@ -43,38 +109,94 @@ func main() {
 	r.Get("/api/v1", healthCheckHandler)
 
 	// User Authentication: Registration
-	r.Post("/api/v1/auth/register", handlers.RegisterUser)
+	r.Post("/api/v1/auth/register", app.RegisterUser)
 
 	// User Authentication: Login
 	// This route allows users to log in and receive a JWT token
-	r.Post("/api/v1/auth/login", handlers.LoginUser)
+	r.Post("/api/v1/auth/login", app.LoginUser)
+
+	// User Authentication: Refresh
+	// This route exchanges a refresh token for a new access token and refresh token
+	r.Post("/api/v1/auth/refresh", app.RefreshToken)
 
 	// User Authentication: Logout
 	// This route allows users to log out by invalidating their JWT token
-	r.Post("/api/v1/auth/logout", handlers.LogoutUser)
+	r.Post("/api/v1/auth/logout", app.LogoutUser)
+
+	// Article Feeds
+	// These routes serve a user's articles to feed readers, authorized by a feed token in the path instead of a JWT
+	r.Get("/api/v1/feeds/{token}/atom", app.GetAtomFeed)
+	r.Get("/api/v1/feeds/{token}/rss", app.GetRSSFeed)
 
 	// Routes that require authentication
 	// This group of routes will require the user to be authenticated
 	r.Group(func(r chi.Router) {
-		r.Use(handlers.AuthMiddleware) // Apply the authentication middleware to all routes in this group
+		r.Use(app.AuthMiddleware) // Apply the authentication middleware to all routes in this group
 
-		// Article Submission endpoint
-		// This route allows authenticated users to submit articles
-		r.Post("/api/v1/articles", handlers.SubmitArticle)
+		// Article Submission endpoints
+		// These routes allow authenticated users to submit articles, and to have them processed again
+		r.Post("/api/v1/articles", app.SubmitArticle)
+		r.Post("/api/v1/articles/{id}/reprocess", app.ReprocessArticle)
 
 		// Article Management Endpoints
 		// These routes allow users to manage their articles, including viewing, updating, and deleting
-		r.Get("/api/v1/articles/{id}", handlers.ReturnArticle)              // Return article by ID
-		r.Get("/api/v1/articles", handlers.GetArticlesByUserID)             // Get all articles for a user
-		r.Get("/api/v1/articles/tags", handlers.GetTagsByUserID)            // Get all tags for a user
-		r.Put("/api/v1/articles/{id}/status", handlers.UpdateArticleStatus) // Update an existing article status
-		r.Put("/api/v1/articles/{id}/tags", handlers.UpdateArticleTags)     // Update an existing article tags
-		r.Delete("/api/v1/articles/{id}", handlers.DeleteArticle)           // Delete an article by ID
-		r.Get("/api/v1/tags", handlers.GetTagsByUserID)                     // Get all tags across all articles
+		r.Get("/api/v1/articles/search", app.SearchArticles)           // Full-text search across articles
+		r.Get("/api/v1/articles/{id}", app.ReturnArticle)              // Return article by ID
+		r.Get("/api/v1/articles/{id}/job", app.GetArticleJob)          // Get the article's processing job state
+		r.Get("/api/v1/articles", app.GetArticlesByUserID)             // Get all articles for a user
+		r.Get("/api/v1/articles/tags", app.GetTagsByUserID)            // Get all tags for a user
+		r.Put("/api/v1/articles/{id}/status", app.UpdateArticleStatus) // Update an existing article status
+		r.Put("/api/v1/articles/{id}/tags", app.UpdateArticleTags)     // Update an existing article tags
+		r.Delete("/api/v1/articles/{id}", app.DeleteArticle)           // Delete an article by ID
+		r.Get("/api/v1/tags", app.GetTagsByUserID)                     // Get all tags across all articles
+
+		// Article Events Endpoint
+		// This route streams the progress of the user's articles as Server-Sent Events
+		r.Get("/api/v1/events", app.StreamEvents)
+
+		// Webhook Endpoints
+		// These routes allow users to register endpoints that receive signed article events, and to inspect their deliveries
+		r.Post("/api/v1/webhooks", app.CreateWebhook)
+		r.Get("/api/v1/webhooks", app.GetWebhooks)
+		r.Get("/api/v1/webhooks/{id}", app.GetWebhook)
+		r.Put("/api/v1/webhooks/{id}", app.UpdateWebhook)
+		r.Delete("/api/v1/webhooks/{id}", app.DeleteWebhook)
+		r.Get("/api/v1/webhooks/{id}/deliveries", app.GetWebhookDeliveries)
+		r.Post("/api/v1/webhooks/{id}/test", app.TestWebhook)
+
+		// Import Endpoints
+		// These routes allow users to import reading lists exported from other services, and to follow the imports
+		r.Post("/api/v1/import", app.ImportArticles)
+		r.Get("/api/v1/import", app.GetImports)
+		r.Get("/api/v1/import/{id}", app.GetImport)
+
+		// Export Endpoints
+		// These routes allow users to download their reading list as JSON Lines, CSV, Markdown or browser bookmarks,
+		// or their articles as an EPUB book for e-readers
+		r.Get("/api/v1/export", app.ExportArticles)
+		r.Get("/api/v1/export/epub", app.ExportEPUB)
+
+		// Feed Token Endpoints
+		// These routes allow users to create and revoke the tokens that give feed readers access to their feeds
+		r.Post("/api/v1/feed-tokens", app.CreateFeedToken)
+		r.Get("/api/v1/feed-tokens", app.GetFeedTokens)
+		r.Delete("/api/v1/feed-tokens/{id}", app.DeleteFeedToken)
+
+		// Subscription Endpoints
+		// These routes allow users to subscribe to RSS, Atom and JSON feeds whose new entries are added to their reading list
+		r.Post("/api/v1/subscriptions", app.CreateSubscription)
+		r.Get("/api/v1/subscriptions", app.GetSubscriptions)
+		r.Get("/api/v1/subscriptions/{id}", app.GetSubscription)
+		r.Put("/api/v1/subscriptions/{id}", app.UpdateSubscription)
+		r.Delete("/api/v1/subscriptions/{id}", app.DeleteSubscription)
+
+		// User Settings Endpoints
+		r.Get("/api/v1/users/me", app.GetCurrentUser)                   // Get the current user's account and settings
+		r.Put("/api/v1/users/me/reading-speed", app.UpdateReadingSpeed) // Set the words per minute used for reading times
 	})
 
 	// Serve Swagger UI
-	// The URL for Swagger UI will be http://localhost:8080/swagger/index.html
+	// The URL for Swagger UI will be http://<server.addr>/swagger/index.html
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"), // This is the default path generated by swag
 		httpSwagger.DeepLinking(true),
@@ -82,7 +204,8 @@ func main() {
 		httpSwagger.DomID("swagger-ui"),
 	))
 	// Graceful Shutdown Setup
-	server := &http.Server{Addr: ":8080", Handler: r}
+	server := &http.Server{Addr: cfg.Server.Addr, Handler: r}
+	server.RegisterOnShutdown(events.Close) // End open event streams so shutdown doesn't wait for them
 
 	// Channel to listen for OS signals (e.g., Ctrl+C)
 	stop := make(chan os.Signal, 1)
@@ -90,8 +213,8 @@ func main() {
 
 	// Start server in a goroutine so it doesn't block
 	go func() {
-		fmt.Println("Server starting on :8080")
-		fmt.Println("Access API docs at http://localhost:8080/swagger/index.html")
+		fmt.Printf("Server starting on %s\n", server.Addr)
+		fmt.Printf("Access API docs at http://%s/swagger/index.html\n", docsHost(server.Addr))
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Could not listen on %s: %v\n", server.Addr, err)
 		}
@@ -102,13 +225,30 @@ func main() {
 
 	// Shutdown the server gracefully
 	log.Println("Shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // 5 second timeout for graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Imports save their progress and resume on the next start
+	if err := importer.Stop(ctx); err != nil {
+		log.Printf("Imports did not stop in time: %v", err)
+	}
+	// The poller queues articles, so it stops before the workers; an interrupted check is made again on the next start
+	if err := poller.Stop(ctx); err != nil {
+		log.Printf("Subscription poller did not stop in time: %v", err)
+	}
+	// Let in-flight jobs finish; anything interrupted is recovered on the next start
+	if err := workers.Stop(ctx); err != nil {
+		log.Printf("Processing workers did not stop in time: %v", err)
+	}
+	// Stopped after the processing workers, which queue deliveries; unsent ones go out on the next start
+	if err := webhooks.Stop(ctx); err != nil {
+		log.Printf("Webhook workers did not stop in time: %v", err)
+	}
+
 	log.Println("Server exited gracefully.")
 
 }
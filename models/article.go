@@ -1,73 +1,179 @@
-// models/article.go
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"strings"
 	"time"
 )
 
 // Article represents a saved article in the reading list.
 type Article struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	URL       string    `json:"url"`
-	Title     string    `json:"title"`
-	Summary   string    `json:"summary,omitempty"` // omitempty will hide if empty
-	Tags      []string  `json:"tags"`
-	Status    string    `json:"status"` // "processing", "failed", "read", or "unread"
+	ID          string   `json:"id"`
+	UserID      string   `json:"user_id"`
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary,omitempty"` // omitempty will hide if empty
+	Tags        []string `json:"tags"`
+	AutoTags    []string `json:"-"`      // The tags processing added, a subset of Tags replaced when the article is reprocessed
+	Status      string   `json:"status"` // "processing", "failed", "read", or "unread"
+	BodyText    string   `json:"-"`      // Extracted article text, used for search but not returned by the API
+	ContentHTML string   `json:"-"`      // Sanitized HTML of the main content, kept for offline reading
+
+	// Page metadata, empty when the page doesn't provide it
+	Author       string     `json:"author,omitempty"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	SiteName     string     `json:"site_name,omitempty"`
+	ImageURL     string     `json:"image_url,omitempty"` // Lead image
+	Description  string     `json:"description,omitempty"`
+	Language     string     `json:"language,omitempty"` // e.g. "en" or "en-US"
+	CanonicalURL string     `json:"canonical_url,omitempty"`
+
+	WordCount      int `json:"word_count"`
+	ReadingMinutes int `json:"reading_minutes"`      // Derived from WordCount and the user's reading speed
+	PageCount      int `json:"page_count,omitempty"` // Only set for PDFs
+
+	// Why processing failed, set while the status is "failed"
+	FailureReason string `json:"failure_reason,omitempty"`
+	FailureStage  string `json:"failure_stage,omitempty"` // "fetch", "parse", "summarize", "tag" or "save"
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// Save inserts a new article or updates an existing one if ID exists.
-func (a *Article) Save() error {
-	// Convert []string tags to a comma-separated string for DB storage
-	tagsStr := strings.Join(a.Tags, ",")
+// articleColumns lists the articles columns read by scanArticle, in order.
+const articleColumns = "id, user_id, url, title, COALESCE(summary, ''), status, body_text, content_html, " +
+	"author, published_at, site_name, image_url, description, language, canonical_url, " +
+	"word_count, reading_minutes, page_count, failure_reason, failure_stage, created_at, updated_at"
+
+// scanArticle reads a row selected with articleColumns, followed by any extra columns.
+func scanArticle(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*Article, error) {
+	a := &Article{}
+	var publishedAt sql.NullTime
+	dest := []interface{}{
+		&a.ID, &a.UserID, &a.URL, &a.Title, &a.Summary,
+		&a.Status, &a.BodyText, &a.ContentHTML,
+		&a.Author, &publishedAt, &a.SiteName, &a.ImageURL, &a.Description, &a.Language, &a.CanonicalURL,
+		&a.WordCount, &a.ReadingMinutes, &a.PageCount, &a.FailureReason, &a.FailureStage, &a.CreatedAt, &a.UpdatedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	if publishedAt.Valid {
+		a.PublishedAt = &publishedAt.Time
+	}
+	return a, nil
+}
+
+// SaveArticle inserts a new article or updates an existing one if ID exists.
+// The article's tags are normalized and stored in the article_tags table, and
+// its reading time is derived from the word count and the user's reading speed.
+func (s *SQLStore) SaveArticle(ctx context.Context, a *Article) error {
+	a.Tags = NormalizeTags(a.Tags)
+	a.AutoTags = intersectTags(a.AutoTags, a.Tags)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin article transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	wpm := DefaultWordsPerMinute
+	err = tx.QueryRowContext(ctx, "SELECT words_per_minute FROM users WHERE id = ?", a.UserID).Scan(&wpm)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get reading speed: %w", err)
+	}
+	a.ReadingMinutes = ReadingMinutes(a.WordCount, wpm)
+
+	// Timestamps are stored in UTC: lists sort and page by their text in SQLite
+	if a.PublishedAt != nil {
+		publishedAt := a.PublishedAt.UTC()
+		a.PublishedAt = &publishedAt
+	}
 
-	var stmt *sql.Stmt
-	var err error
 	if a.ID == "" { // Insert new article
 		a.ID = GenerateUUID()
-		a.CreatedAt = time.Now() // Set creation timestamp for new articles
-		// For new articles, UpdatedAt is same as CreatedAt initially
-		a.UpdatedAt = a.CreatedAt
-
-		stmt, err = DB.Prepare("INSERT INTO articles(id, user_id, url, title, summary, tags, status, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)")
-		if err != nil {
-			return fmt.Errorf("failed to prepare article insert statement: %w", err)
+		a.UpdatedAt = time.Now().UTC()
+		// Imported articles keep the date they were originally saved; for
+		// other new articles, CreatedAt is the same as UpdatedAt initially
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = a.UpdatedAt
 		}
-		defer stmt.Close()
-		_, err = stmt.Exec(a.ID, a.UserID, a.URL, a.Title, a.Summary, tagsStr, a.Status, a.CreatedAt, a.UpdatedAt)
+		a.CreatedAt = a.CreatedAt.UTC()
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO articles(id, user_id, url, title, summary, status, body_text, content_html,
+			author, published_at, site_name, image_url, description, language, canonical_url,
+			word_count, reading_minutes, page_count, failure_reason, failure_stage, created_at, updated_at)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.ID, a.UserID, a.URL, a.Title, a.Summary, a.Status, a.BodyText, a.ContentHTML,
+			a.Author, a.PublishedAt, a.SiteName, a.ImageURL, a.Description, a.Language, a.CanonicalURL,
+			a.WordCount, a.ReadingMinutes, a.PageCount, a.FailureReason, a.FailureStage, a.CreatedAt, a.UpdatedAt)
 		if err != nil {
 			return fmt.Errorf("failed to insert article: %w", err)
 		}
 	} else { // Update existing article
 		// For updates, only update UpdatedAt
-		a.UpdatedAt = time.Now()
-		stmt, err = DB.Prepare("UPDATE articles SET url=?, title=?, summary=?, tags=?, status=?, updated_at=? WHERE id=? AND user_id=?")
+		a.UpdatedAt = time.Now().UTC()
+		result, err := tx.ExecContext(ctx, `UPDATE articles SET url=?, title=?, summary=?, status=?, body_text=?, content_html=?,
+			author=?, published_at=?, site_name=?, image_url=?, description=?, language=?, canonical_url=?,
+			word_count=?, reading_minutes=?, page_count=?, failure_reason=?, failure_stage=?, updated_at=?
+			WHERE id=? AND user_id=?`,
+			a.URL, a.Title, a.Summary, a.Status, a.BodyText, a.ContentHTML,
+			a.Author, a.PublishedAt, a.SiteName, a.ImageURL, a.Description, a.Language, a.CanonicalURL,
+			a.WordCount, a.ReadingMinutes, a.PageCount, a.FailureReason, a.FailureStage, a.UpdatedAt,
+			a.ID, a.UserID)
 		if err != nil {
-			return fmt.Errorf("failed to prepare article update statement: %w", err)
+			return fmt.Errorf("failed to update article: %w", err)
 		}
-		defer stmt.Close()
-		_, err = stmt.Exec(a.URL, a.Title, a.Summary, tagsStr, a.Status, a.UpdatedAt, a.ID, a.UserID)
+		// The article may have been deleted while it was being processed
+		rowsAffected, err := result.RowsAffected()
 		if err != nil {
-			return fmt.Errorf("failed to update article: %w", err)
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("article with ID '%s': %w", a.ID, ErrArticleNotFound)
 		}
 	}
+
+	if err = replaceArticleTags(ctx, tx, a.ID, a.Tags, a.AutoTags); err != nil {
+		return err
+	}
+	if err = syncArticleSearchIndex(ctx, tx, a.ID); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit article: %w", err)
+	}
 	return nil
 }
 
+// ArticleURLExists reports whether the user has already saved an article with the URL.
+func (s *SQLStore) ArticleURLExists(ctx context.Context, userID, url string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM articles WHERE user_id = ? AND url = ?)", userID, url).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check article URL: %w", err)
+	}
+	return exists, nil
+}
+
 // DeleteArticle deletes an article by ID and user ID.
-func DeleteArticle(id, userID string) error {
-	stmt, err := DB.Prepare("DELETE FROM articles WHERE id=? AND user_id=?")
+func (s *SQLStore) DeleteArticle(ctx context.Context, id, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to prepare article delete statement: %w", err)
+		return fmt.Errorf("failed to begin article delete transaction: %w", err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	result, err := stmt.Exec(id, userID)
+	// Unlink the article's tags before PostgreSQL's cascade does, to learn which
+	// ones to prune once the article is gone. Another user's article is rolled back.
+	unlinked, err := unlinkArticleTags(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM articles WHERE id=? AND user_id=?", id, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete article: %w", err)
 	}
@@ -77,52 +183,74 @@ func DeleteArticle(id, userID string) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("article with ID '%s' not found or not owned by user '%s'", id, userID)
+		return fmt.Errorf("article with ID '%s': %w", id, ErrArticleNotFound)
+	}
+
+	// Remove the article from the search index
+	if err = syncArticleSearchIndex(ctx, tx, id); err != nil {
+		return err
 	}
 
+	// Delete the tags only the article was using
+	if err = pruneUnusedTags(ctx, tx, unlinked); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM article_images WHERE article_id=?", id); err != nil {
+		return fmt.Errorf("failed to delete article image: %w", err)
+	}
+
+	// Drop the article's processing job so workers don't pick it up again
+	if _, err = tx.ExecContext(ctx, "DELETE FROM processing_jobs WHERE article_id=?", id); err != nil {
+		return fmt.Errorf("failed to delete processing job for article: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit article deletion: %w", err)
+	}
 	return nil
 }
 
-// UpdateArticleStatus updates the status of an existing article.
-func UpdateArticleStatus(id, userID, newStatus string) error {
-	// Prepare the statement with a WHERE clause that includes both ID and UserID for security
-	stmt, err := DB.Prepare("UPDATE articles SET status=?, updated_at=CURRENT_TIMESTAMP WHERE id=? AND user_id=?")
+// UpdateArticleStatus updates the status of an existing article and reports
+// whether it changed.
+func (s *SQLStore) UpdateArticleStatus(ctx context.Context, id, userID, newStatus string) (bool, error) {
+	// The WHERE clause includes both ID and UserID for security
+	result, err := s.db.ExecContext(ctx, "UPDATE articles SET status=?, updated_at=? WHERE id=? AND user_id=? AND status <> ?",
+		newStatus, time.Now().UTC(), id, userID, newStatus)
 	if err != nil {
-		return fmt.Errorf("failed to prepare article status update statement: %w", err)
+		return false, fmt.Errorf("failed to update article status: %w", err)
 	}
-	defer stmt.Close()
 
-	result, err := stmt.Exec(newStatus, id, userID)
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to update article status: %w", err)
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return true, nil
 	}
 
-	// Check if a row was actually affected to ensure the article existed and belonged to the user
-	rowsAffected, err := result.RowsAffected()
+	// Either the article already has the status, or it doesn't exist or belongs to another user
+	var exists bool
+	err = s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM articles WHERE id = ? AND user_id = ?)", id, userID).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return false, fmt.Errorf("failed to check article: %w", err)
 	}
-	if rowsAffected == 0 {
-		// Handling not-found or unauthorized updates
-		return fmt.Errorf("article with ID '%s' not found or not owned by user '%s'", id, userID)
+	if !exists {
+		return false, fmt.Errorf("article with ID '%s': %w", id, ErrArticleNotFound)
 	}
-
-	return nil
+	return false, nil
 }
 
-// UpdateArticleTags updates the tags of an existing article.
-func UpdateArticleTags(id, userID string, newTags []string) error {
-	// Convert []string tags to a comma-separated string for DB storage
-	tagsStr := strings.Join(newTags, ",")
-
-	// Prepare the statement with a WHERE clause that includes both ID and UserID for security
-	stmt, err := DB.Prepare("UPDATE articles SET tags=?, updated_at=CURRENT_TIMESTAMP WHERE id=? AND user_id=?")
+// UpdateArticleTags replaces the tags of an existing article.
+func (s *SQLStore) UpdateArticleTags(ctx context.Context, id, userID string, newTags []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to prepare article tags update statement: %w", err)
+		return fmt.Errorf("failed to begin article tags transaction: %w", err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	result, err := stmt.Exec(tagsStr, id, userID)
+	// The WHERE clause includes both ID and UserID for security
+	result, err := tx.ExecContext(ctx, "UPDATE articles SET updated_at=? WHERE id=? AND user_id=?", time.Now().UTC(), id, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update article tags: %w", err)
 	}
@@ -133,101 +261,35 @@ func UpdateArticleTags(id, userID string, newTags []string) error {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("article with ID '%s' not found or not owned by user '%s'", id, userID)
+		return fmt.Errorf("article with ID '%s': %w", id, ErrArticleNotFound)
 	}
 
-	return nil
-}
-
-// GetArticlesByUserID retrieves all articles for a given user, with optional filters.
-func GetArticlesByUserID(userID, statusFilter, tagFilter string) ([]Article, error) {
-	query := "SELECT id, user_id, url, title, summary, tags, status, created_at, updated_at FROM articles WHERE user_id = ?"
-	args := []interface{}{userID}
-
-	if statusFilter != "" {
-		query += " AND status = ?"
-		args = append(args, statusFilter)
+	if err = replaceArticleTags(ctx, tx, id, NormalizeTags(newTags), nil); err != nil {
+		return err
 	}
-	if tagFilter != "" {
-		// Use LIKE for tag filtering, assuming comma-separated tags
-		query += " AND tags LIKE ?"
-		args = append(args, "%"+tagFilter+"%") // Matches if tagFilter is anywhere in the string
+	if err = syncArticleSearchIndex(ctx, tx, id); err != nil {
+		return err
 	}
-
-	rows, err := DB.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query articles: %w", err)
-	}
-	defer rows.Close()
-
-	var articles []Article
-	for rows.Next() {
-		var a Article
-		var tagsStr string // Temporary variable for scanning tags
-		err := rows.Scan(
-			&a.ID, &a.UserID, &a.URL, &a.Title, &a.Summary,
-			&tagsStr, &a.Status, &a.CreatedAt, &a.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan article row: %w", err)
-		}
-		a.Tags = strings.Split(tagsStr, ",") // Convert back to []string
-		articles = append(articles, a)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit article tags: %w", err)
 	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating article rows: %w", err)
-	}
-
-	return articles, nil
+	return nil
 }
 
 // GetArticleByID retrieves a single article by its ID and user ID.
-func GetArticleByID(id, userID string) (*Article, error) {
-	article := &Article{}
-	var tagsStr string
-	row := DB.QueryRow("SELECT id, user_id, url, title, summary, tags, status, created_at, updated_at FROM articles WHERE id = ? AND user_id = ?", id, userID)
-	err := row.Scan(
-		&article.ID, &article.UserID, &article.URL, &article.Title, &article.Summary,
-		&tagsStr, &article.Status, &article.CreatedAt, &article.UpdatedAt,
-	)
+func (s *SQLStore) GetArticleByID(ctx context.Context, id, userID string) (*Article, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+articleColumns+" FROM articles WHERE id = ? AND user_id = ?", id, userID)
+	article, err := scanArticle(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // Article not found
 		}
 		return nil, fmt.Errorf("failed to get article by ID: %w", err)
 	}
-	article.Tags = strings.Split(tagsStr, ",")
-	return article, nil
-}
-func GetTagsByUserID(userID string) ([]string, error) {
-	query := "SELECT DISTINCT tags FROM articles WHERE user_id = ?"
-	rows, err := DB.Query(query, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query tags: %w", err)
-	}
-	defer rows.Close()
 
-	tagSet := make(map[string]struct{}) // Use a map to avoid duplicates
-	for rows.Next() {
-		var tagsStr string
-		if err := rows.Scan(&tagsStr); err != nil {
-			return nil, fmt.Errorf("failed to scan tag row: %w", err)
-		}
-		tags := strings.Split(tagsStr, ",")
-		for _, tag := range tags {
-			tagSet[tag] = struct{}{} // Add to set
-		}
+	articles := []Article{*article}
+	if err = s.loadArticleTags(ctx, articles); err != nil {
+		return nil, err
 	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating tag rows: %w", err)
-	}
-
-	var tags []string
-	for tag := range tagSet {
-		tags = append(tags, tag)
-	}
-
-	return tags, nil
+	return &articles[0], nil
 }
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ArticleImage is a copy of an article's lead image, kept so exports can embed
+// it without going back to the network.
+type ArticleImage struct {
+	ArticleID   string
+	SourceURL   string // The article's ImageURL when the copy was made
+	ContentType string
+	Data        []byte
+	CreatedAt   time.Time
+}
+
+// SaveArticleImage stores the copy of an article's lead image, replacing any previous one.
+func (s *SQLStore) SaveArticleImage(ctx context.Context, img *ArticleImage) error {
+	img.CreatedAt = time.Now().UTC()
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO article_images(article_id, source_url, content_type, data, created_at) VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(article_id) DO UPDATE SET
+			source_url=excluded.source_url, content_type=excluded.content_type, data=excluded.data, created_at=excluded.created_at`,
+		img.ArticleID, img.SourceURL, img.ContentType, img.Data, img.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save article image: %w", err)
+	}
+	return nil
+}
+
+// GetArticleImage retrieves the lead image of an article owned by the given user.
+func (s *SQLStore) GetArticleImage(ctx context.Context, articleID, userID string) (*ArticleImage, error) {
+	img := &ArticleImage{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT i.article_id, i.source_url, i.content_type, i.data, i.created_at
+		FROM article_images i JOIN articles a ON a.id = i.article_id
+		WHERE i.article_id = ? AND a.user_id = ?`, articleID, userID).
+		Scan(&img.ArticleID, &img.SourceURL, &img.ContentType, &img.Data, &img.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No image cached
+		}
+		return nil, fmt.Errorf("failed to get article image: %w", err)
+	}
+	return img, nil
+}
+
+// DeleteArticleImage removes the copy of an article's lead image, if there is one.
+func (s *SQLStore) DeleteArticleImage(ctx context.Context, articleID string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM article_images WHERE article_id=?", articleID); err != nil {
+		return fmt.Errorf("failed to delete article image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Article list paging limits.
+const (
+	DefaultArticleListLimit = 50
+	MaxArticleListLimit     = 200
+)
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded or
+// doesn't belong to the requested sort order.
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// articleSortColumn is a column articles can be sorted by.
+type articleSortColumn struct {
+	name    string
+	sqlType string // Type the cursor's text value is cast back to, where the dialect needs it
+}
+
+// articleSortColumns maps the sort options accepted by the API to columns.
+// Values are read back as text so the cursor can reproduce them exactly.
+var articleSortColumns = map[string]articleSortColumn{
+	"created_at":   {name: "created_at", sqlType: "TIMESTAMPTZ"},
+	"updated_at":   {name: "updated_at", sqlType: "TIMESTAMPTZ"},
+	"title":        {name: "title", sqlType: "TEXT"},
+	"reading_time": {name: "reading_minutes", sqlType: "INTEGER"},
+}
+
+// ArticleListOptions filters, sorts and pages a user's articles.
+type ArticleListOptions struct {
+	Status string // Optional status filter
+	Tag    string // Optional tag filter, matched exactly after normalization
+	Site   string // Optional site name filter, case-insensitive
+	Author string // Optional author filter, case-insensitive
+
+	MinReadingMinutes int // Optional lower bound on reading time, inclusive; 0 for none
+	MaxReadingMinutes int // Optional upper bound on reading time, inclusive; 0 for none
+
+	Sort   string // One of the keys of articleSortColumns; defaults to "created_at"
+	Order  string // "asc" or "desc"; defaults to "desc"
+	Limit  int    // Page size; defaults to DefaultArticleListLimit
+	Cursor string // Opaque cursor from a previous page
+}
+
+// ValidArticleSort reports whether sort is a supported sort option.
+func ValidArticleSort(sort string) bool {
+	_, ok := articleSortColumns[sort]
+	return ok
+}
+
+// articleCursor is the position after the last article of a page.
+type articleCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`  // Sort column value of the last article
+	ID    string `json:"id"` // Tie-breaker for equal sort values
+}
+
+func encodeArticleCursor(c articleCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// withDefaults fills in the default sort, order and limit and validates the options.
+func (opts ArticleListOptions) withDefaults() (ArticleListOptions, error) {
+	if opts.Sort == "" {
+		opts.Sort = "created_at"
+	}
+	if opts.Order == "" {
+		opts.Order = "desc"
+	}
+	if opts.Limit <= 0 || opts.Limit > MaxArticleListLimit {
+		opts.Limit = DefaultArticleListLimit
+	}
+	if _, ok := articleSortColumns[opts.Sort]; !ok {
+		return opts, fmt.Errorf("unsupported sort option %q", opts.Sort)
+	}
+	if opts.Order != "asc" && opts.Order != "desc" {
+		return opts, fmt.Errorf("unsupported sort order %q", opts.Order)
+	}
+	return opts, nil
+}
+
+// decodeCursor decodes opts.Cursor and checks that it belongs to the same sort order.
+func (opts ArticleListOptions) decodeCursor() (articleCursor, error) {
+	cursor, err := decodeArticleCursor(opts.Cursor)
+	if err != nil {
+		return cursor, err
+	}
+	if cursor.Sort != opts.Sort || cursor.Order != opts.Order {
+		return cursor, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func decodeArticleCursor(s string) (articleCursor, error) {
+	var c articleCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// GetArticlesByUserID retrieves one page of a user's articles and the cursor of
+// the next page, which is empty on the last page.
+func (s *SQLStore) GetArticlesByUserID(ctx context.Context, userID string, opts ArticleListOptions) ([]Article, string, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, "", err
+	}
+	sortColumn := articleSortColumns[opts.Sort]
+
+	query := "SELECT " + articleColumns + ", CAST(" + sortColumn.name + " AS TEXT) FROM articles WHERE user_id = ?"
+	args := []interface{}{userID}
+
+	if opts.Status != "" {
+		query += " AND status = ?"
+		args = append(args, opts.Status)
+	}
+	if opts.Tag != "" {
+		query += " AND id IN (SELECT at.article_id FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE t.name = ?)"
+		args = append(args, NormalizeTag(opts.Tag))
+	}
+	if opts.Site != "" {
+		query += " AND LOWER(site_name) = LOWER(?)"
+		args = append(args, opts.Site)
+	}
+	if opts.Author != "" {
+		query += " AND LOWER(author) = LOWER(?)"
+		args = append(args, opts.Author)
+	}
+	if opts.MinReadingMinutes > 0 {
+		query += " AND reading_minutes >= ?"
+		args = append(args, opts.MinReadingMinutes)
+	}
+	if opts.MaxReadingMinutes > 0 {
+		query += " AND reading_minutes <= ?"
+		args = append(args, opts.MaxReadingMinutes)
+	}
+
+	// Keyset pagination: continue strictly after the last article of the previous page
+	comparison := "<"
+	if opts.Order == "asc" {
+		comparison = ">"
+	}
+	if opts.Cursor != "" {
+		cursor, err := opts.decodeCursor()
+		if err != nil {
+			return nil, "", err
+		}
+		query += fmt.Sprintf(" AND (%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s ?))", sortColumn.name, comparison, s.db.dialect.param(sortColumn.sqlType))
+		args = append(args, cursor.Value, cursor.Value, cursor.ID)
+	}
+
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT ?", sortColumn.name, opts.Order)
+	args = append(args, opts.Limit+1) // One extra row tells us whether there is a next page
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query articles: %w", err)
+	}
+	defer rows.Close()
+
+	articles := []Article{}
+	var sortValues []string
+	for rows.Next() {
+		var sortValue sql.NullString
+		a, err := scanArticle(rows, &sortValue)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan article row: %w", err)
+		}
+		articles = append(articles, *a)
+		sortValues = append(sortValues, sortValue.String)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("error iterating article rows: %w", err)
+	}
+
+	var nextCursor string
+	if len(articles) > opts.Limit {
+		articles = articles[:opts.Limit]
+		last := len(articles) - 1
+		nextCursor = encodeArticleCursor(articleCursor{
+			Sort:  opts.Sort,
+			Order: opts.Order,
+			Value: sortValues[last],
+			ID:    articles[last].ID,
+		})
+	}
+
+	if err = s.loadArticleTags(ctx, articles); err != nil {
+		return nil, "", err
+	}
+
+	return articles, nextCursor, nil
+}
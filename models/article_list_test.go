@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestListArticlesPages pages through articles in every sort order and checks
+// that the pages add up to the whole list in order. The articles include ties,
+// which the cursor breaks by ID, and timestamps with sub-second precision, which
+// the cursor must carry through exactly.
+func TestListArticlesPages(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		other := createUser(t, s, "other")
+		saveArticle(t, s, &Article{UserID: other.ID, URL: "https://example.com/other", Title: "bravo", Status: "processed"})
+
+		base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		specs := []struct {
+			title   string
+			created time.Duration
+			words   int
+		}{
+			{"delta", 0, 100},
+			{"bravo", 1500 * time.Microsecond, 300},
+			{"alpha", 1500 * time.Microsecond, 300},
+			{"charlie", 2 * time.Second, 900},
+			{"bravo", time.Hour + 123456*time.Microsecond, 100},
+			{"echo", 24 * time.Hour, 5000},
+			{"alpha", 24*time.Hour + 999999*time.Microsecond, 900},
+		}
+		var articles []*Article
+		for i, spec := range specs {
+			a := saveArticle(t, s, &Article{
+				UserID:    user.ID,
+				URL:       fmt.Sprintf("https://example.com/%d", i),
+				Title:     spec.title,
+				Status:    "processed",
+				WordCount: spec.words,
+				CreatedAt: base.Add(spec.created),
+			})
+			stored, err := s.GetArticleByID(ctx, a.ID, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			articles = append(articles, stored)
+		}
+
+		compareBy := map[string]func(a, b *Article) int{
+			"created_at":   func(a, b *Article) int { return a.CreatedAt.Compare(b.CreatedAt) },
+			"updated_at":   func(a, b *Article) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+			"title":        func(a, b *Article) int { return strings.Compare(a.Title, b.Title) },
+			"reading_time": func(a, b *Article) int { return a.ReadingMinutes - b.ReadingMinutes },
+		}
+		for sortKey, compare := range compareBy {
+			for _, order := range []string{"asc", "desc"} {
+				t.Run(sortKey+" "+order, func(t *testing.T) {
+					want := append([]*Article(nil), articles...)
+					sort.Slice(want, func(i, j int) bool {
+						c := compare(want[i], want[j])
+						if c == 0 {
+							c = strings.Compare(want[i].ID, want[j].ID)
+						}
+						return (c < 0) == (order == "asc")
+					})
+
+					var got []string
+					opts := ArticleListOptions{Sort: sortKey, Order: order, Limit: 2}
+					for page := 0; ; page++ {
+						if page > len(articles) {
+							t.Fatalf("still paging after %d pages", page)
+						}
+						list, next, err := s.GetArticlesByUserID(ctx, user.ID, opts)
+						if err != nil {
+							t.Fatalf("page %d: %v", page, err)
+						}
+						for _, a := range list {
+							got = append(got, a.ID)
+						}
+						if next == "" {
+							break
+						}
+						opts.Cursor = next
+					}
+
+					var wantIDs []string
+					for _, a := range want {
+						wantIDs = append(wantIDs, a.ID)
+					}
+					if strings.Join(got, " ") != strings.Join(wantIDs, " ") {
+						t.Errorf("paged through %v, want %v", got, wantIDs)
+					}
+				})
+			}
+		}
+	})
+}
+
+func TestListArticlesInvalidCursor(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		for i := 0; i < 3; i++ {
+			saveArticle(t, s, &Article{UserID: user.ID, URL: fmt.Sprintf("https://example.com/%d", i), Status: "processed"})
+		}
+		_, next, err := s.GetArticlesByUserID(ctx, user.ID, ArticleListOptions{Sort: "title", Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name string
+			opts ArticleListOptions
+		}{
+			{"not base64", ArticleListOptions{Cursor: "not a cursor!"}},
+			{"not JSON", ArticleListOptions{Cursor: "bm90IEpTT04"}},
+			{"other sort", ArticleListOptions{Sort: "created_at", Cursor: next}},
+			{"other order", ArticleListOptions{Sort: "title", Order: "asc", Cursor: next}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, _, err := s.GetArticlesByUserID(ctx, user.ID, tt.opts); !errors.Is(err, ErrInvalidCursor) {
+					t.Errorf("got %v, want ErrInvalidCursor", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUpdateArticleStatus(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		other := createUser(t, s, "other")
+		article := saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/a", Status: "unread"})
+
+		tests := []struct {
+			status      string
+			wantChanged bool
+		}{
+			{"read", true},
+			{"read", false},
+			{"unread", true},
+		}
+		for _, tt := range tests {
+			changed, err := s.UpdateArticleStatus(ctx, article.ID, user.ID, tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("setting status %q: changed = %v, want %v", tt.status, changed, tt.wantChanged)
+			}
+		}
+		got, err := s.GetArticleByID(ctx, article.ID, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != "unread" {
+			t.Errorf("status = %q, want unread", got.Status)
+		}
+
+		if _, err := s.UpdateArticleStatus(ctx, article.ID, other.ID, "read"); !errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("updating another user's article: got %v, want ErrArticleNotFound", err)
+		}
+		if _, err := s.UpdateArticleStatus(ctx, GenerateUUID(), user.ID, "read"); !errors.Is(err, ErrArticleNotFound) {
+			t.Errorf("updating a missing article: got %v, want ErrArticleNotFound", err)
+		}
+	})
+}
@@ -1,95 +1,108 @@
-// models/db.go
 package models
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
+	"strings"
 
-	_ "github.com/mattn/go-sqlite3" // Import the SQLite driver
+	_ "github.com/jackc/pgx/v5/stdlib" // Import the PostgreSQL driver
+	_ "github.com/mattn/go-sqlite3"    // Import the SQLite driver
 )
 
-// DB holds the database connection pool
-var DB *sql.DB
+// SQLStore implements Store on top of a SQLite or PostgreSQL connection pool.
+type SQLStore struct {
+	db *dbConn
+}
+
+var _ Store = (*SQLStore)(nil)
 
-// InitDB initializes the database connection
-func InitDB(dataSourceName string) {
-	var err error
-	DB, err = sql.Open("sqlite3", dataSourceName) // "sqlite3" is the driver name
+// InitDB opens the database and applies pending migrations
+func InitDB(dataSourceName string) *SQLStore {
+	store, err := Open(dataSourceName)
 	if err != nil {
-		log.Fatalf("Error opening database: %v", err)
+		log.Fatalf("Error connecting to database: %v", err)
 	}
 
-	// Ping the database to verify the connection
-	if err = DB.Ping(); err != nil {
-		log.Fatalf("Error connecting to database: %v", err)
+	log.Printf("Database connection established successfully (%s).", store.db.dialect)
+
+	// Bring the schema up to date
+	applied, err := store.Migrate(context.Background())
+	if err != nil {
+		log.Fatalf("Error migrating database: %v", err)
+	}
+	if len(applied) == 0 {
+		log.Println("Database schema is up to date.")
 	}
+	return store
+}
 
-	log.Println("Database connection established successfully.")
+// IsPostgresDSN reports whether a data source name refers to a PostgreSQL
+// database rather than a SQLite file.
+func IsPostgresDSN(dataSourceName string) bool {
+	return strings.HasPrefix(dataSourceName, "postgres://") || strings.HasPrefix(dataSourceName, "postgresql://")
+}
 
-	// You can call a function here to set up your tables
-	createTables()
+// Open opens the database a data source name refers to without touching the
+// schema: PostgreSQL for postgres:// URLs and SQLite otherwise.
+func Open(dataSourceName string) (*SQLStore, error) {
+	if IsPostgresDSN(dataSourceName) {
+		return OpenPostgres(dataSourceName)
+	}
+	return OpenSQLite(dataSourceName)
 }
 
-// createTables sets up the necessary tables in the database
-func createTables() {
-	// SQL to create Users table
-	usersTableSQL := `
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		username TEXT UNIQUE NOT NULL,
-		password_hash TEXT NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
-
-	// SQL to create Articles table
-	articlesTableSQL := `
-	CREATE TABLE IF NOT EXISTS articles (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		url TEXT NOT NULL,
-		title TEXT NOT NULL,
-		summary TEXT,
-		tags TEXT, -- Storing as comma-separated string for simplicity initially
-		status TEXT NOT NULL DEFAULT 'unread', -- 'read' or 'unread'
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);`
-
-	// SQL to create Revoked Tokens table
-	revokeTokensTableSQL := `
-    CREATE TABLE IF NOT EXISTS revoked_tokens (
-        token TEXT PRIMARY KEY,
-        expires_at TIMESTAMP
-    );
-    `
-	// Execute table creation queries
-	_, err := DB.Exec(usersTableSQL)
+// ErrNoFTS5 is returned when opening a SQLite database with a driver built
+// without the FTS5 extension, which article search needs.
+var ErrNoFTS5 = errors.New("SQLite was built without FTS5, which article search needs (build with -tags sqlite_fts5)")
+
+// OpenSQLite opens a SQLite database without touching the schema
+func OpenSQLite(dataSourceName string) (*SQLStore, error) {
+	store, err := openSQLStore("sqlite3", dataSourceName, dialectSQLite) // "sqlite3" is the driver name
 	if err != nil {
-		log.Fatalf("Error creating users table: %v", err)
+		return nil, err
 	}
 
-	_, err = DB.Exec(articlesTableSQL)
-	if err != nil {
-		log.Fatalf("Error creating articles table: %v", err)
+	// Fail now rather than at the first migration or search that needs FTS5
+	var hasFTS5 bool
+	if err := store.db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&hasFTS5); err != nil {
+		store.db.DB.Close()
+		return nil, err
 	}
+	if !hasFTS5 {
+		store.db.DB.Close()
+		return nil, ErrNoFTS5
+	}
+	return store, nil
+}
 
-	_, err = DB.Exec(revokeTokensTableSQL)
+// OpenPostgres opens a PostgreSQL database without touching the schema
+func OpenPostgres(dataSourceName string) (*SQLStore, error) {
+	return openSQLStore("pgx", dataSourceName, dialectPostgres)
+}
+
+func openSQLStore(driverName, dataSourceName string, d dialect) (*SQLStore, error) {
+	db, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
-		log.Fatalf("Error creating revoked_tokens table: %v", err)
+		return nil, err
 	}
 
-	log.Println("Tables created or already exist.")
+	// Ping the database to verify the connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLStore{db: &dbConn{DB: db, dialect: d}}, nil
 }
 
-// CloseDB closes the database connection
-func CloseDB() {
-	if DB != nil {
-		err := DB.Close()
-		if err != nil {
-			log.Printf("Error closing database: %v", err)
-		} else {
-			log.Println("Database connection closed.")
-		}
+// Close closes the database connection
+func (s *SQLStore) Close() error {
+	err := s.db.Close()
+	if err != nil {
+		log.Printf("Error closing database: %v", err)
+	} else {
+		log.Println("Database connection closed.")
 	}
+	return err
 }
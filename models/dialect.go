@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dialect identifies the SQL database behind a SQLStore. Queries are written
+// once, in the subset of SQL both databases share, with '?' placeholders; the
+// few places where the databases differ ask the dialect.
+type dialect int
+
+const (
+	dialectSQLite dialect = iota
+	dialectPostgres
+)
+
+func (d dialect) String() string {
+	if d == dialectPostgres {
+		return "postgres"
+	}
+	return "sqlite"
+}
+
+// rebind rewrites '?' placeholders into the dialect's placeholder syntax.
+// Question marks inside string literals are left alone.
+func (d dialect) rebind(query string) string {
+	if d != dialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// greatest returns the function that picks the largest of its arguments.
+func (d dialect) greatest() string {
+	if d == dialectPostgres {
+		return "GREATEST"
+	}
+	return "MAX"
+}
+
+// skipLocked is appended to a SELECT so concurrent claimers skip rows another
+// transaction has locked. SQLite serializes writers, so it needs nothing.
+func (d dialect) skipLocked() string {
+	if d == dialectPostgres {
+		return " FOR UPDATE SKIP LOCKED"
+	}
+	return ""
+}
+
+// migrationLockID is the key of the PostgreSQL advisory lock that serializes
+// migrations. Any constant works as long as nothing else uses it.
+const migrationLockID = 0x72656164 // "read"
+
+// lockMigrationsQuery returns a statement that holds the migration lock until
+// the transaction ends, so replicas starting at once don't apply the same
+// migration twice. SQLite serializes writers, so it needs none.
+func (d dialect) lockMigrationsQuery() string {
+	if d == dialectPostgres {
+		return "SELECT pg_advisory_xact_lock(" + strconv.Itoa(migrationLockID) + ")"
+	}
+	return ""
+}
+
+// param returns a placeholder for a value compared against a column of the
+// given type. Cursor values arrive as text; Postgres needs them cast back.
+func (d dialect) param(sqlType string) string {
+	if d == dialectPostgres {
+		return "CAST(? AS " + sqlType + ")"
+	}
+	return "?"
+}
+
+// timestampType is the column type used for timestamps.
+func (d dialect) timestampType() string {
+	if d == dialectPostgres {
+		return "TIMESTAMPTZ"
+	}
+	return "DATETIME"
+}
+
+// tableExistsQuery returns a query that takes a table name and returns whether it exists.
+func (d dialect) tableExistsQuery() string {
+	if d == dialectPostgres {
+		return "SELECT EXISTS(SELECT 1 FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?)"
+	}
+	return "SELECT EXISTS(SELECT 1 FROM sqlite_master WHERE type='table' AND name = ?)"
+}
+
+// isUniqueViolation reports whether err was caused by a UNIQUE constraint.
+func (d dialect) isUniqueViolation(err error) bool {
+	if d == dialectPostgres {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	}
+	// For sqlite3, the error message contains "UNIQUE constraint failed"
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// dbConn is a connection pool that rebinds queries for its dialect.
+type dbConn struct {
+	*sql.DB
+	dialect dialect
+}
+
+func (c *dbConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.ExecContext(ctx, c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return c.DB.QueryContext(ctx, c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return c.DB.QueryRowContext(ctx, c.dialect.rebind(query), args...)
+}
+
+func (c *dbConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return c.DB.PrepareContext(ctx, c.dialect.rebind(query))
+}
+
+func (c *dbConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (*dbTx, error) {
+	tx, err := c.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &dbTx{Tx: tx, dialect: c.dialect}, nil
+}
+
+// dbTx is a transaction that rebinds queries for its dialect.
+type dbTx struct {
+	*sql.Tx
+	dialect dialect
+}
+
+func (t *dbTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.ExecContext(ctx, t.dialect.rebind(query), args...)
+}
+
+func (t *dbTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.QueryContext(ctx, t.dialect.rebind(query), args...)
+}
+
+func (t *dbTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRowContext(ctx, t.dialect.rebind(query), args...)
+}
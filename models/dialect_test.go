@@ -0,0 +1,45 @@
+package models
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect
+		query   string
+		want    string
+	}{
+		{"sqlite keeps question marks", dialectSQLite, "SELECT id FROM articles WHERE id = ? AND user_id = ?", "SELECT id FROM articles WHERE id = ? AND user_id = ?"},
+		{"postgres numbers placeholders", dialectPostgres, "SELECT id FROM articles WHERE id = ? AND user_id = ?", "SELECT id FROM articles WHERE id = $1 AND user_id = $2"},
+		{"no placeholders", dialectPostgres, "SELECT COUNT(*) FROM articles", "SELECT COUNT(*) FROM articles"},
+		{"question mark in a string", dialectPostgres, "SELECT ? WHERE title = 'why?' AND id = ?", "SELECT $1 WHERE title = 'why?' AND id = $2"},
+		{"escaped quote in a string", dialectPostgres, "SELECT 'it''s ?', ?", "SELECT 'it''s ?', $1"},
+		{"more than nine", dialectPostgres, "VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", "VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"},
+		{"cast parameter", dialectPostgres, "WHERE created_at < " + dialectPostgres.param("TIMESTAMPTZ"), "WHERE created_at < CAST($1 AS TIMESTAMPTZ)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dialect.rebind(tt.query); got != tt.want {
+				t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipLocked(t *testing.T) {
+	if got := dialectSQLite.skipLocked(); got != "" {
+		t.Errorf("sqlite skipLocked() = %q, want none", got)
+	}
+	if got, want := dialectPostgres.skipLocked(), " FOR UPDATE SKIP LOCKED"; got != want {
+		t.Errorf("postgres skipLocked() = %q, want %q", got, want)
+	}
+}
+
+func TestLockMigrationsQuery(t *testing.T) {
+	if got := dialectSQLite.lockMigrationsQuery(); got != "" {
+		t.Errorf("sqlite lockMigrationsQuery() = %q, want none", got)
+	}
+	if got, want := dialectPostgres.lockMigrationsQuery(), "SELECT pg_advisory_xact_lock(1919246692)"; got != want {
+		t.Errorf("postgres lockMigrationsQuery() = %q, want %q", got, want)
+	}
+}
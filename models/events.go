@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Article event types, in the order processing emits them. An article that
+// fails emits "failed" instead of the remaining events.
+const (
+	EventArticleQueued     = "queued"
+	EventArticleFetched    = "fetched"
+	EventArticleExtracted  = "extracted"
+	EventArticleSummarized = "summarized"
+	EventArticleTagged     = "tagged"
+	EventArticleProcessed  = "processed"
+	EventArticleFailed     = "failed"
+)
+
+// ArticleEvent reports progress in the background processing of an article.
+type ArticleEvent struct {
+	ID            int64     `json:"id"` // Increases with every event, across all users
+	Type          string    `json:"type" example:"summarized"`
+	ArticleID     string    `json:"article_id"`
+	UserID        string    `json:"-"`
+	Status        string    `json:"status"`          // The article's status after the event
+	Title         string    `json:"title,omitempty"` // Known once the article has been fetched
+	FailureReason string    `json:"failure_reason,omitempty"`
+	FailureStage  string    `json:"failure_stage,omitempty"`
+	Time          time.Time `json:"time"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FeedToken gives feed readers access to a user's Atom and RSS feeds. The plain
+// token is only returned when it is created; the database stores its hash.
+type FeedToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name" example:"Feed reader"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+const feedTokenColumns = "id, user_id, name, created_at, last_used_at"
+
+func scanFeedToken(row interface{ Scan(...interface{}) error }) (*FeedToken, error) {
+	ft := &FeedToken{}
+	var lastUsedAt sql.NullTime
+	if err := row.Scan(&ft.ID, &ft.UserID, &ft.Name, &ft.CreatedAt, &lastUsedAt); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		ft.LastUsedAt = &lastUsedAt.Time
+	}
+	return ft, nil
+}
+
+// CreateFeedToken creates a feed token for a user and returns the plain token.
+func (s *SQLStore) CreateFeedToken(ctx context.Context, userID, name string) (string, *FeedToken, error) {
+	plain, err := generateRefreshTokenValue()
+	if err != nil {
+		return "", nil, err
+	}
+	ft := &FeedToken{ID: GenerateUUID(), UserID: userID, Name: name, CreatedAt: time.Now().UTC()}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO feed_tokens(id, user_id, name, token_hash, created_at) VALUES(?, ?, ?, ?, ?)",
+		ft.ID, ft.UserID, ft.Name, hashRefreshToken(plain), ft.CreatedAt)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to insert feed token: %w", err)
+	}
+	return plain, ft, nil
+}
+
+// GetFeedTokensByUserID retrieves a user's feed tokens, oldest first.
+func (s *SQLStore) GetFeedTokensByUserID(ctx context.Context, userID string) ([]FeedToken, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+feedTokenColumns+" FROM feed_tokens WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feed tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []FeedToken{}
+	for rows.Next() {
+		ft, err := scanFeedToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feed token row: %w", err)
+		}
+		tokens = append(tokens, *ft)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feed token rows: %w", err)
+	}
+	return tokens, nil
+}
+
+// UseFeedToken looks up a plain feed token and records that it was used. It
+// returns nil without an error if the token doesn't exist or was revoked.
+func (s *SQLStore) UseFeedToken(ctx context.Context, token string) (*FeedToken, error) {
+	hash := hashRefreshToken(token)
+	row := s.db.QueryRowContext(ctx, "SELECT "+feedTokenColumns+" FROM feed_tokens WHERE token_hash = ?", hash)
+	ft, err := scanFeedToken(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Unknown or revoked token
+		}
+		return nil, fmt.Errorf("failed to get feed token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx, "UPDATE feed_tokens SET last_used_at=? WHERE id=?", now, ft.ID); err != nil {
+		return nil, fmt.Errorf("failed to record feed token use: %w", err)
+	}
+	ft.LastUsedAt = &now
+	return ft, nil
+}
+
+// DeleteFeedToken revokes a feed token by deleting it.
+func (s *SQLStore) DeleteFeedToken(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM feed_tokens WHERE id=? AND user_id=?", id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete feed token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("feed token with ID '%s': %w", id, ErrFeedTokenNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Import statuses.
+const (
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// MaxImportErrors caps how many failed entries an import reports individually.
+const MaxImportErrors = 100
+
+// Import tracks the import of a reading list exported from another service.
+type Import struct {
+	ID          string        `json:"id"`
+	UserID      string        `json:"user_id"`
+	Format      string        `json:"format" example:"pocket"` // "pocket", "instapaper", "omnivore" or "bookmarks"
+	Status      string        `json:"status"`                  // "running", "completed" or "failed"
+	Total       int           `json:"total"`                   // Entries found in the file
+	Processed   int           `json:"processed"`               // Entries handled so far
+	Imported    int           `json:"imported"`
+	Duplicates  int           `json:"duplicates"` // Skipped because the URL was already saved
+	Failed      int           `json:"failed"`
+	Errors      []ImportError `json:"errors"` // The first MaxImportErrors failed entries
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+}
+
+// ImportError reports an entry that could not be imported.
+type ImportError struct {
+	Entry int    `json:"entry"` // Position of the entry in the file, from 1
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportItem is one entry of an export file.
+type ImportItem struct {
+	URL     string    `json:"url"`
+	Title   string    `json:"title,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Read    bool      `json:"read,omitempty"`
+	SavedAt time.Time `json:"saved_at"`        // Zero if the export doesn't have it
+	Error   string    `json:"error,omitempty"` // Why the entry can't be imported, e.g. a missing URL
+}
+
+const importColumns = "id, user_id, format, status, total, processed, imported, duplicates, failed, errors, created_at, updated_at, completed_at"
+
+func scanImport(row interface{ Scan(...interface{}) error }) (*Import, error) {
+	imp := &Import{}
+	var errs string
+	var completedAt sql.NullTime
+	err := row.Scan(&imp.ID, &imp.UserID, &imp.Format, &imp.Status, &imp.Total, &imp.Processed,
+		&imp.Imported, &imp.Duplicates, &imp.Failed, &errs, &imp.CreatedAt, &imp.UpdatedAt, &completedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(errs), &imp.Errors); err != nil {
+		return nil, fmt.Errorf("failed to decode import errors: %w", err)
+	}
+	if imp.Errors == nil {
+		imp.Errors = []ImportError{}
+	}
+	if completedAt.Valid {
+		imp.CompletedAt = &completedAt.Time
+	}
+	return imp, nil
+}
+
+// CreateImport stores a new import together with the entries it imports.
+func (s *SQLStore) CreateImport(ctx context.Context, imp *Import, items []ImportItem) error {
+	itemsJSON, err := json.Marshal(items)
+	if err != nil {
+		return fmt.Errorf("failed to encode import items: %w", err)
+	}
+	if imp.Errors == nil {
+		imp.Errors = []ImportError{}
+	}
+	errs, err := json.Marshal(imp.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode import errors: %w", err)
+	}
+
+	imp.ID = GenerateUUID()
+	imp.Total = len(items)
+	imp.CreatedAt = time.Now().UTC()
+	imp.UpdatedAt = imp.CreatedAt
+	_, err = s.db.ExecContext(ctx, `INSERT INTO imports(id, user_id, format, status, total, processed, imported, duplicates, failed,
+		errors, items, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		imp.ID, imp.UserID, imp.Format, imp.Status, imp.Total, imp.Processed, imp.Imported, imp.Duplicates, imp.Failed,
+		string(errs), string(itemsJSON), imp.CreatedAt, imp.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert import: %w", err)
+	}
+	return nil
+}
+
+// GetImportByID retrieves an import owned by the given user.
+func (s *SQLStore) GetImportByID(ctx context.Context, id, userID string) (*Import, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+importColumns+" FROM imports WHERE id = ? AND user_id = ?", id, userID)
+	imp, err := scanImport(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Import not found
+		}
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+	return imp, nil
+}
+
+// GetImportsByUserID retrieves a user's imports, newest first.
+func (s *SQLStore) GetImportsByUserID(ctx context.Context, userID string) ([]Import, error) {
+	return s.queryImports(ctx, "SELECT "+importColumns+" FROM imports WHERE user_id = ? ORDER BY created_at DESC, id DESC", userID)
+}
+
+// GetRunningImports retrieves the imports that have not finished, e.g. because the process stopped.
+func (s *SQLStore) GetRunningImports(ctx context.Context) ([]Import, error) {
+	return s.queryImports(ctx, "SELECT "+importColumns+" FROM imports WHERE status = ? ORDER BY created_at, id", ImportStatusRunning)
+}
+
+func (s *SQLStore) queryImports(ctx context.Context, query string, args ...interface{}) ([]Import, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query imports: %w", err)
+	}
+	defer rows.Close()
+
+	imports := []Import{}
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan import row: %w", err)
+		}
+		imports = append(imports, *imp)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating import rows: %w", err)
+	}
+	return imports, nil
+}
+
+// GetImportItems retrieves the entries of an import, in file order.
+func (s *SQLStore) GetImportItems(ctx context.Context, id string) ([]ImportItem, error) {
+	var itemsJSON string
+	err := s.db.QueryRowContext(ctx, "SELECT items FROM imports WHERE id = ?", id).Scan(&itemsJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import items: %w", err)
+	}
+	var items []ImportItem
+	if err := json.Unmarshal([]byte(itemsJSON), &items); err != nil {
+		return nil, fmt.Errorf("failed to decode import items: %w", err)
+	}
+	return items, nil
+}
+
+// UpdateImport saves an import's status, progress and errors.
+func (s *SQLStore) UpdateImport(ctx context.Context, imp *Import) error {
+	errs, err := json.Marshal(imp.Errors)
+	if err != nil {
+		return fmt.Errorf("failed to encode import errors: %w", err)
+	}
+	imp.UpdatedAt = time.Now().UTC()
+	_, err = s.db.ExecContext(ctx, `UPDATE imports SET status=?, processed=?, imported=?, duplicates=?, failed=?, errors=?,
+		updated_at=?, completed_at=? WHERE id=?`,
+		imp.Status, imp.Processed, imp.Imported, imp.Duplicates, imp.Failed, string(errs),
+		imp.UpdatedAt, imp.CompletedAt, imp.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update import: %w", err)
+	}
+	return nil
+}
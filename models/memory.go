@@ -0,0 +1,1358 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// MemoryStore is a Store that keeps everything in memory. It is meant for
+// handler tests and for trying the API without a database; data is lost when
+// the process exits.
+type MemoryStore struct {
+	mu            sync.Mutex
+	users         map[string]User                // By username
+	articles      map[string]Article             // By article ID
+	jobs          map[string]ProcessingJob       // By article ID
+	revoked       map[string]time.Time           // jti to token expiry
+	refreshTokens map[string]*memoryRefreshToken // By token hash
+	webhooks      map[string]Webhook             // By webhook ID
+	deliveries    map[string]WebhookDelivery     // By delivery ID
+	imports       map[string]memoryImport        // By import ID
+	images        map[string]ArticleImage        // By article ID
+	feedTokens    map[string]FeedToken           // By token hash
+	subscriptions map[string]Subscription        // By subscription ID
+	seenEntries   map[string]map[string]bool     // Entry keys by subscription ID
+}
+
+type memoryImport struct {
+	Import
+	Items []ImportItem
+}
+
+type memoryRefreshToken struct {
+	RefreshToken
+	UsedAt    time.Time
+	RevokedAt time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		users:         make(map[string]User),
+		articles:      make(map[string]Article),
+		jobs:          make(map[string]ProcessingJob),
+		revoked:       make(map[string]time.Time),
+		refreshTokens: make(map[string]*memoryRefreshToken),
+		webhooks:      make(map[string]Webhook),
+		deliveries:    make(map[string]WebhookDelivery),
+		imports:       make(map[string]memoryImport),
+		images:        make(map[string]ArticleImage),
+		feedTokens:    make(map[string]FeedToken),
+		subscriptions: make(map[string]Subscription),
+		seenEntries:   make(map[string]map[string]bool),
+	}
+}
+
+// Close is a no-op; it exists to satisfy Store.
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// copyArticle returns a with its own copy of the tags, sorted like the SQL store returns them.
+func copyArticle(a Article) Article {
+	tags := append([]string{}, a.Tags...)
+	sort.Strings(tags)
+	a.Tags = tags
+	autoTags := append([]string{}, a.AutoTags...)
+	sort.Strings(autoTags)
+	a.AutoTags = autoTags
+	if a.PublishedAt != nil {
+		publishedAt := *a.PublishedAt
+		a.PublishedAt = &publishedAt
+	}
+	return a
+}
+
+// SaveArticle inserts a new article or updates an existing one if ID exists.
+func (m *MemoryStore) SaveArticle(ctx context.Context, a *Article) error {
+	a.Tags = NormalizeTags(a.Tags)
+	a.AutoTags = intersectTags(a.AutoTags, a.Tags)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wpm := DefaultWordsPerMinute
+	if user, ok := m.userByID(a.UserID); ok {
+		wpm = user.WordsPerMinute
+	}
+	a.ReadingMinutes = ReadingMinutes(a.WordCount, wpm)
+
+	if a.PublishedAt != nil {
+		publishedAt := a.PublishedAt.UTC()
+		a.PublishedAt = &publishedAt
+	}
+	if a.ID == "" {
+		a.ID = GenerateUUID()
+		a.UpdatedAt = time.Now().UTC()
+		if a.CreatedAt.IsZero() {
+			a.CreatedAt = a.UpdatedAt
+		}
+		a.CreatedAt = a.CreatedAt.UTC()
+	} else {
+		existing, ok := m.articles[a.ID]
+		if !ok || existing.UserID != a.UserID {
+			return fmt.Errorf("article with ID '%s': %w", a.ID, ErrArticleNotFound)
+		}
+		a.CreatedAt = existing.CreatedAt
+		a.UpdatedAt = time.Now().UTC()
+	}
+	m.articles[a.ID] = copyArticle(*a)
+	return nil
+}
+
+// GetArticleByID retrieves a single article by its ID and user ID.
+func (m *MemoryStore) GetArticleByID(ctx context.Context, id, userID string) (*Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.UserID != userID {
+		return nil, nil // Article not found
+	}
+	a = copyArticle(a)
+	return &a, nil
+}
+
+// memorySortValue returns the value an article is sorted by. Times and numbers
+// use a fixed width format so they order correctly as strings.
+func memorySortValue(a Article, sortKey string) string {
+	switch sortKey {
+	case "updated_at":
+		return a.UpdatedAt.UTC().Format("2006-01-02T15:04:05.000000000")
+	case "title":
+		return a.Title
+	case "reading_time":
+		return fmt.Sprintf("%010d", a.ReadingMinutes)
+	default:
+		return a.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000")
+	}
+}
+
+// GetArticlesByUserID retrieves one page of a user's articles and the cursor of the next page.
+func (m *MemoryStore) GetArticlesByUserID(ctx context.Context, userID string, opts ArticleListOptions) ([]Article, string, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, "", err
+	}
+	var cursor *articleCursor
+	if opts.Cursor != "" {
+		c, err := opts.decodeCursor()
+		if err != nil {
+			return nil, "", err
+		}
+		cursor = &c
+	}
+	tag := NormalizeTag(opts.Tag)
+
+	// before reports whether (v1, id1) comes before (v2, id2) in the requested order
+	before := func(v1, id1, v2, id2 string) bool {
+		if v1 != v2 {
+			return (v1 < v2) == (opts.Order == "asc")
+		}
+		return id1 != id2 && (id1 < id2) == (opts.Order == "asc")
+	}
+
+	m.mu.Lock()
+	var articles []Article
+	for _, a := range m.articles {
+		if a.UserID != userID || (opts.Status != "" && a.Status != opts.Status) {
+			continue
+		}
+		if opts.Tag != "" && !containsString(a.Tags, tag) {
+			continue
+		}
+		if (opts.Site != "" && !strings.EqualFold(a.SiteName, opts.Site)) || (opts.Author != "" && !strings.EqualFold(a.Author, opts.Author)) {
+			continue
+		}
+		if (opts.MinReadingMinutes > 0 && a.ReadingMinutes < opts.MinReadingMinutes) || (opts.MaxReadingMinutes > 0 && a.ReadingMinutes > opts.MaxReadingMinutes) {
+			continue
+		}
+		if cursor != nil && !before(cursor.Value, cursor.ID, memorySortValue(a, opts.Sort), a.ID) {
+			continue
+		}
+		articles = append(articles, copyArticle(a))
+	}
+	m.mu.Unlock()
+
+	sort.Slice(articles, func(i, j int) bool {
+		return before(memorySortValue(articles[i], opts.Sort), articles[i].ID, memorySortValue(articles[j], opts.Sort), articles[j].ID)
+	})
+
+	var nextCursor string
+	if len(articles) > opts.Limit {
+		articles = articles[:opts.Limit]
+		last := articles[len(articles)-1]
+		nextCursor = encodeArticleCursor(articleCursor{
+			Sort:  opts.Sort,
+			Order: opts.Order,
+			Value: memorySortValue(last, opts.Sort),
+			ID:    last.ID,
+		})
+	}
+	if articles == nil {
+		articles = []Article{}
+	}
+	return articles, nextCursor, nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// searchTerm is a word of a search query; prefix terms match any word they start.
+type searchTerm struct {
+	text   string
+	prefix bool
+}
+
+func (t searchTerm) matches(word string) bool {
+	if t.prefix {
+		return strings.HasPrefix(word, t.text)
+	}
+	return word == t.text
+}
+
+func isSearchSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// SearchArticles runs a simplified full-text search: every term of the query
+// must appear in the article, phrases are matched word by word and the AND,
+// OR and NOT operators are ignored. There is no stemming.
+func (m *MemoryStore) SearchArticles(ctx context.Context, userID, query string, limit int) ([]SearchResult, error) {
+	var terms []searchTerm
+	for _, word := range strings.Fields(query) {
+		if word == "AND" || word == "OR" || word == "NOT" {
+			continue
+		}
+		prefix := strings.HasSuffix(word, "*")
+		for _, part := range strings.FieldsFunc(strings.ToLower(word), isSearchSeparator) {
+			terms = append(terms, searchTerm{text: part, prefix: prefix})
+		}
+	}
+	if len(terms) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := []SearchResult{}
+	for _, a := range m.articles {
+		if a.UserID != userID {
+			continue
+		}
+		// Weights follow the SQL store's bm25 weights
+		fields := []struct {
+			text   string
+			weight float64
+		}{{a.Title, 10}, {a.Summary, 4}, {a.BodyText, 1}, {strings.Join(a.Tags, " "), 6}}
+
+		matched := make([]bool, len(terms))
+		var score float64
+		snippet := ""
+		for _, field := range fields {
+			words := strings.FieldsFunc(field.text, isSearchSeparator)
+			first := -1
+			for i, word := range words {
+				word = strings.ToLower(word)
+				for j, term := range terms {
+					if term.matches(word) {
+						matched[j] = true
+						score += field.weight
+						if first < 0 {
+							first = i
+						}
+					}
+				}
+			}
+			if first >= 0 && snippet == "" {
+				snippet = memorySnippet(words, first, terms)
+			}
+		}
+		if !containsFalse(matched) {
+			results = append(results, SearchResult{Article: copyArticle(a), Snippet: snippet, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func containsFalse(values []bool) bool {
+	for _, v := range values {
+		if !v {
+			return true
+		}
+	}
+	return false
+}
+
+// memorySnippet returns up to 24 words around words[first] with matching words highlighted.
+func memorySnippet(words []string, first int, terms []searchTerm) string {
+	start := max(0, first-8)
+	end := min(len(words), start+24)
+	parts := make([]string, 0, end-start+2)
+	if start > 0 {
+		parts = append(parts, "…")
+	}
+	for _, word := range words[start:end] {
+		for _, term := range terms {
+			if term.matches(strings.ToLower(word)) {
+				word = snippetMatchStart + word + snippetMatchEnd
+				break
+			}
+		}
+		parts = append(parts, word)
+	}
+	if end < len(words) {
+		parts = append(parts, "…")
+	}
+	return highlightSnippet(strings.Join(parts, " "))
+}
+
+// GetTagsByUserID returns every tag used by a user's articles with its article count, most used first.
+func (m *MemoryStore) GetTagsByUserID(ctx context.Context, userID string) ([]TagCount, error) {
+	m.mu.Lock()
+	counts := make(map[string]int)
+	for _, a := range m.articles {
+		if a.UserID != userID {
+			continue
+		}
+		for _, tag := range a.Tags {
+			counts[tag]++
+		}
+	}
+	m.mu.Unlock()
+
+	tags := []TagCount{}
+	for name, count := range counts {
+		tags = append(tags, TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// updateArticle applies fn to an article owned by userID and bumps its updated_at.
+func (m *MemoryStore) updateArticle(id, userID string, fn func(a *Article)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.UserID != userID {
+		return fmt.Errorf("article with ID '%s': %w", id, ErrArticleNotFound)
+	}
+	fn(&a)
+	a.UpdatedAt = time.Now().UTC()
+	m.articles[id] = a
+	return nil
+}
+
+// UpdateArticleStatus updates the status of an existing article and reports
+// whether it changed.
+func (m *MemoryStore) UpdateArticleStatus(ctx context.Context, id, userID, newStatus string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.UserID != userID {
+		return false, fmt.Errorf("article with ID '%s': %w", id, ErrArticleNotFound)
+	}
+	if a.Status == newStatus {
+		return false, nil
+	}
+	a.Status = newStatus
+	a.UpdatedAt = time.Now().UTC()
+	m.articles[id] = a
+	return true, nil
+}
+
+// UpdateArticleTags replaces the tags of an existing article.
+func (m *MemoryStore) UpdateArticleTags(ctx context.Context, id, userID string, newTags []string) error {
+	return m.updateArticle(id, userID, func(a *Article) {
+		a.Tags = NormalizeTags(newTags)
+		a.AutoTags = []string{}
+	})
+}
+
+// ArticleURLExists reports whether the user has already saved an article with the URL.
+func (m *MemoryStore) ArticleURLExists(ctx context.Context, userID, url string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, a := range m.articles {
+		if a.UserID == userID && a.URL == url {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DeleteArticle deletes an article and its processing job.
+func (m *MemoryStore) DeleteArticle(ctx context.Context, id, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.articles[id]
+	if !ok || a.UserID != userID {
+		return fmt.Errorf("article with ID '%s': %w", id, ErrArticleNotFound)
+	}
+	delete(m.articles, id)
+	delete(m.jobs, id)
+	delete(m.images, id)
+	return nil
+}
+
+// EnqueueProcessingJob creates a pending job for an article, or resets the existing one.
+// It returns ErrProcessingJobActive if the existing job is still pending or running.
+func (m *MemoryStore) EnqueueProcessingJob(ctx context.Context, articleID, userID string, maxAttempts int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	job, ok := m.jobs[articleID]
+	if !ok {
+		job = ProcessingJob{ArticleID: articleID, UserID: userID, CreatedAt: now}
+	} else if job.Status == JobStatusPending || job.Status == JobStatusRunning {
+		return fmt.Errorf("processing job for article '%s': %w", articleID, ErrProcessingJobActive)
+	}
+	job.Status = JobStatusPending
+	job.Attempts = 0
+	job.MaxAttempts = maxAttempts
+	job.LastError = ""
+	job.NextRunAt = now
+	job.UpdatedAt = now
+	m.jobs[articleID] = job
+	return nil
+}
+
+// ClaimNextProcessingJob marks the next due pending job as running and returns it.
+func (m *MemoryStore) ClaimNextProcessingJob(ctx context.Context) (*ProcessingJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var next *ProcessingJob
+	for _, job := range m.jobs {
+		if job.Status != JobStatusPending || job.NextRunAt.After(now) {
+			continue
+		}
+		if next == nil || job.NextRunAt.Before(next.NextRunAt) {
+			job := job
+			next = &job
+		}
+	}
+	if next == nil {
+		return nil, nil // Nothing to do
+	}
+	next.Status = JobStatusRunning
+	next.Attempts++
+	next.UpdatedAt = now
+	m.jobs[next.ArticleID] = *next
+	return next, nil
+}
+
+// updateJob applies fn to an article's job, if it has one.
+func (m *MemoryStore) updateJob(articleID string, fn func(job *ProcessingJob)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[articleID]
+	if !ok {
+		return
+	}
+	fn(&job)
+	job.UpdatedAt = time.Now().UTC()
+	m.jobs[articleID] = job
+}
+
+// CompleteProcessingJob marks a job as succeeded.
+func (m *MemoryStore) CompleteProcessingJob(ctx context.Context, articleID string) error {
+	m.updateJob(articleID, func(job *ProcessingJob) {
+		job.Status = JobStatusSucceeded
+		job.LastError = ""
+	})
+	return nil
+}
+
+// RetryProcessingJob records a failed attempt and schedules the job to run again at nextRunAt.
+func (m *MemoryStore) RetryProcessingJob(ctx context.Context, articleID, lastError string, nextRunAt time.Time) error {
+	m.updateJob(articleID, func(job *ProcessingJob) {
+		job.Status = JobStatusPending
+		job.LastError = lastError
+		job.NextRunAt = nextRunAt.UTC()
+	})
+	return nil
+}
+
+// FailProcessingJob marks a job as permanently failed.
+func (m *MemoryStore) FailProcessingJob(ctx context.Context, articleID, lastError string) error {
+	m.updateJob(articleID, func(job *ProcessingJob) {
+		job.Status = JobStatusFailed
+		job.LastError = lastError
+	})
+	return nil
+}
+
+// ReleaseProcessingJob returns a running job to the queue without counting the attempt.
+func (m *MemoryStore) ReleaseProcessingJob(ctx context.Context, articleID string) error {
+	m.updateJob(articleID, func(job *ProcessingJob) {
+		if job.Status == JobStatusRunning {
+			job.Status = JobStatusPending
+			job.Attempts = max(job.Attempts-1, 0)
+		}
+	})
+	return nil
+}
+
+// RequeueRunningProcessingJobs puts running jobs back in the queue.
+func (m *MemoryStore) RequeueRunningProcessingJobs(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var requeued int64
+	for id, job := range m.jobs {
+		if job.Status == JobStatusRunning {
+			job.Status = JobStatusPending
+			job.NextRunAt = now
+			job.UpdatedAt = now
+			m.jobs[id] = job
+			requeued++
+		}
+	}
+	return requeued, nil
+}
+
+// GetOrphanedProcessingArticles returns articles stuck in "processing" that have no pending or running job.
+func (m *MemoryStore) GetOrphanedProcessingArticles(ctx context.Context) ([]Article, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var articles []Article
+	for _, a := range m.articles {
+		if a.Status != "processing" {
+			continue
+		}
+		if job, ok := m.jobs[a.ID]; ok && (job.Status == JobStatusPending || job.Status == JobStatusRunning) {
+			continue
+		}
+		articles = append(articles, Article{ID: a.ID, UserID: a.UserID})
+	}
+	return articles, nil
+}
+
+// GetProcessingJobByArticleID retrieves the processing job for an article owned by the given user.
+func (m *MemoryStore) GetProcessingJobByArticleID(ctx context.Context, articleID, userID string) (*ProcessingJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[articleID]
+	if !ok || job.UserID != userID {
+		return nil, nil // Job not found
+	}
+	return &job, nil
+}
+
+// CreateUser adds a new user.
+func (m *MemoryStore) CreateUser(ctx context.Context, user *User) error {
+	if user.ID == "" {
+		user.ID = GenerateUUID()
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.users[user.Username]; exists {
+		return fmt.Errorf("username '%s': %w", user.Username, ErrUsernameTaken)
+	}
+	if user.WordsPerMinute == 0 {
+		user.WordsPerMinute = DefaultWordsPerMinute
+	}
+	m.users[user.Username] = *user
+	return nil
+}
+
+// userByID finds a user by ID. The caller must hold m.mu.
+func (m *MemoryStore) userByID(id string) (User, bool) {
+	for _, user := range m.users {
+		if user.ID == id {
+			return user, true
+		}
+	}
+	return User{}, false
+}
+
+// GetUserByID retrieves a user by their ID.
+func (m *MemoryStore) GetUserByID(ctx context.Context, id string) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.userByID(id)
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// SetUserWordsPerMinute changes a user's reading speed and recalculates the
+// reading time of their articles.
+func (m *MemoryStore) SetUserWordsPerMinute(ctx context.Context, userID string, wpm int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.userByID(userID)
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.WordsPerMinute = wpm
+	m.users[user.Username] = user
+	for id, a := range m.articles {
+		if a.UserID == userID {
+			a.ReadingMinutes = ReadingMinutes(a.WordCount, wpm)
+			m.articles[id] = a
+		}
+	}
+	return nil
+}
+
+// GetUserByUsername retrieves a user by their username.
+func (m *MemoryStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[username]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// RevokeToken records a token's jti as revoked until the token expires.
+func (m *MemoryStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.revoked[jti]; !ok {
+		m.revoked[jti] = expiresAt.UTC()
+	}
+	return nil
+}
+
+// IsTokenRevoked checks if a token's jti has been revoked.
+func (m *MemoryStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.revoked[jti]
+	return ok, nil
+}
+
+// DeleteExpiredRevokedTokens removes revocations for tokens that have expired anyway.
+func (m *MemoryStore) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var deleted int64
+	for jti, expiresAt := range m.revoked {
+		if expiresAt.Before(now) {
+			delete(m.revoked, jti)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// insertRefreshTokenLocked stores a new token in the given family. m.mu must be held.
+func (m *MemoryStore) insertRefreshTokenLocked(userID, familyID string, ttl time.Duration) (string, *RefreshToken, error) {
+	plain, err := generateRefreshTokenValue()
+	if err != nil {
+		return "", nil, err
+	}
+	rt := RefreshToken{
+		ID:        GenerateUUID(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	m.refreshTokens[hashRefreshToken(plain)] = &memoryRefreshToken{RefreshToken: rt}
+	return plain, &rt, nil
+}
+
+// CreateRefreshToken starts a new token family for a user and returns the plain token.
+func (m *MemoryStore) CreateRefreshToken(ctx context.Context, userID string, ttl time.Duration) (string, *RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insertRefreshTokenLocked(userID, GenerateUUID(), ttl)
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same family,
+// revoking the family if the token was already used.
+func (m *MemoryStore) RotateRefreshToken(ctx context.Context, token string, ttl time.Duration) (string, *RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	rt, ok := m.refreshTokens[hashRefreshToken(token)]
+	if !ok {
+		return "", nil, ErrRefreshTokenInvalid
+	}
+	if !rt.UsedAt.IsZero() && rt.RevokedAt.IsZero() {
+		// A rotated token came back: assume it was stolen and end the session everywhere
+		for _, other := range m.refreshTokens {
+			if other.FamilyID == rt.FamilyID && other.RevokedAt.IsZero() {
+				other.RevokedAt = now
+			}
+		}
+		log.Printf("Refresh token reuse detected for user %s, revoked token family %s", rt.UserID, rt.FamilyID)
+		return "", nil, ErrRefreshTokenReused
+	}
+	if !rt.UsedAt.IsZero() || !rt.RevokedAt.IsZero() || !rt.ExpiresAt.After(now) {
+		return "", nil, ErrRefreshTokenInvalid
+	}
+
+	rt.UsedAt = now
+	return m.insertRefreshTokenLocked(rt.UserID, rt.FamilyID, ttl)
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token of a user's token family.
+func (m *MemoryStore) RevokeRefreshTokenFamily(ctx context.Context, familyID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, rt := range m.refreshTokens {
+		if rt.FamilyID == familyID && rt.UserID == userID && rt.RevokedAt.IsZero() {
+			rt.RevokedAt = now
+		}
+	}
+	return nil
+}
+
+// DeleteExpiredRefreshTokens removes refresh tokens that have expired.
+func (m *MemoryStore) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var deleted int64
+	for hash, rt := range m.refreshTokens {
+		if rt.ExpiresAt.Before(now) {
+			delete(m.refreshTokens, hash)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// copyWebhook returns w with its own copy of the events.
+func copyWebhook(w Webhook) Webhook {
+	w.Events = append([]string{}, w.Events...)
+	return w
+}
+
+// copySubscription returns sub with its own copies of the tags and keywords.
+func copySubscription(sub Subscription) Subscription {
+	sub.Tags = append([]string{}, sub.Tags...)
+	sub.Keywords = append([]string{}, sub.Keywords...)
+	if sub.LastPolledAt != nil {
+		lastPolledAt := *sub.LastPolledAt
+		sub.LastPolledAt = &lastPolledAt
+	}
+	return sub
+}
+
+// CreateWebhook stores a new webhook.
+func (m *MemoryStore) CreateWebhook(ctx context.Context, w *Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.ID = GenerateUUID()
+	w.CreatedAt = time.Now().UTC()
+	w.UpdatedAt = w.CreatedAt
+	m.webhooks[w.ID] = copyWebhook(*w)
+	return nil
+}
+
+// GetWebhookByID retrieves a webhook owned by the given user.
+func (m *MemoryStore) GetWebhookByID(ctx context.Context, id, userID string) (*Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.webhooks[id]
+	if !ok || w.UserID != userID {
+		return nil, nil
+	}
+	w = copyWebhook(w)
+	return &w, nil
+}
+
+// GetWebhooksByUserID retrieves all of a user's webhooks, oldest first.
+func (m *MemoryStore) GetWebhooksByUserID(ctx context.Context, userID string) ([]Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	webhooks := []Webhook{}
+	for _, w := range m.webhooks {
+		if w.UserID == userID {
+			webhooks = append(webhooks, copyWebhook(w))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID < webhooks[j].ID
+	})
+	return webhooks, nil
+}
+
+// UpdateWebhook saves a webhook's URL, secret, events and active flag.
+func (m *MemoryStore) UpdateWebhook(ctx context.Context, w *Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.webhooks[w.ID]
+	if !ok || existing.UserID != w.UserID {
+		return fmt.Errorf("webhook with ID '%s': %w", w.ID, ErrWebhookNotFound)
+	}
+	w.CreatedAt = existing.CreatedAt
+	w.UpdatedAt = time.Now().UTC()
+	m.webhooks[w.ID] = copyWebhook(*w)
+	return nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func (m *MemoryStore) DeleteWebhook(ctx context.Context, id, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.webhooks[id]
+	if !ok || w.UserID != userID {
+		return fmt.Errorf("webhook with ID '%s': %w", id, ErrWebhookNotFound)
+	}
+	delete(m.webhooks, id)
+	for deliveryID, d := range m.deliveries {
+		if d.WebhookID == id {
+			delete(m.deliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+// CreateWebhookDelivery stores a delivery.
+func (m *MemoryStore) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if d.ID == "" {
+		d.ID = GenerateUUID()
+	}
+	d.CreatedAt = time.Now().UTC()
+	d.UpdatedAt = d.CreatedAt
+	if d.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = d.CreatedAt
+	}
+	m.deliveries[d.ID] = *d
+	return nil
+}
+
+// ClaimNextWebhookDelivery marks the next due pending delivery as sending and returns it.
+func (m *MemoryStore) ClaimNextWebhookDelivery(ctx context.Context) (*WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var next *WebhookDelivery
+	for id := range m.deliveries {
+		d := m.deliveries[id]
+		if d.Status != DeliveryStatusPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		if next == nil || d.NextAttemptAt.Before(next.NextAttemptAt) {
+			next = &d
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	next.Status = DeliveryStatusSending
+	next.Attempts++
+	next.UpdatedAt = now
+	m.deliveries[next.ID] = *next
+	return next, nil
+}
+
+func (m *MemoryStore) updateDelivery(id string, fn func(d *WebhookDelivery)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.deliveries[id]
+	if !ok {
+		return
+	}
+	fn(&d)
+	d.UpdatedAt = time.Now().UTC()
+	m.deliveries[id] = d
+}
+
+// CompleteWebhookDelivery marks a delivery as succeeded.
+func (m *MemoryStore) CompleteWebhookDelivery(ctx context.Context, id string, responseStatus int) error {
+	m.updateDelivery(id, func(d *WebhookDelivery) {
+		d.Status = DeliveryStatusSucceeded
+		d.ResponseStatus = responseStatus
+		d.LastError = ""
+	})
+	return nil
+}
+
+// RetryWebhookDelivery records a failed attempt and schedules the next one at nextAttemptAt.
+func (m *MemoryStore) RetryWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string, nextAttemptAt time.Time) error {
+	m.updateDelivery(id, func(d *WebhookDelivery) {
+		d.Status = DeliveryStatusPending
+		d.ResponseStatus = responseStatus
+		d.LastError = lastError
+		d.NextAttemptAt = nextAttemptAt.UTC()
+	})
+	return nil
+}
+
+// FailWebhookDelivery marks a delivery as permanently failed.
+func (m *MemoryStore) FailWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string) error {
+	m.updateDelivery(id, func(d *WebhookDelivery) {
+		d.Status = DeliveryStatusFailed
+		d.ResponseStatus = responseStatus
+		d.LastError = lastError
+	})
+	return nil
+}
+
+// RequeueSendingWebhookDeliveries puts deliveries left in "sending" back in the queue.
+func (m *MemoryStore) RequeueSendingWebhookDeliveries(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	now := time.Now().UTC()
+	for id, d := range m.deliveries {
+		if d.Status == DeliveryStatusSending {
+			d.Status = DeliveryStatusPending
+			d.NextAttemptAt = now
+			d.UpdatedAt = now
+			m.deliveries[id] = d
+			n++
+		}
+	}
+	return n, nil
+}
+
+// GetWebhookDeliveries retrieves a webhook's most recent deliveries, newest first.
+func (m *MemoryStore) GetWebhookDeliveries(ctx context.Context, webhookID, userID string, limit int) ([]WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deliveries := []WebhookDelivery{}
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID && d.UserID == userID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// copyImport returns imp with its own copy of the errors.
+func copyImport(imp Import) Import {
+	imp.Errors = append([]ImportError{}, imp.Errors...)
+	if imp.CompletedAt != nil {
+		completedAt := *imp.CompletedAt
+		imp.CompletedAt = &completedAt
+	}
+	return imp
+}
+
+// CreateImport stores a new import together with the entries it imports.
+func (m *MemoryStore) CreateImport(ctx context.Context, imp *Import, items []ImportItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imp.ID = GenerateUUID()
+	imp.Total = len(items)
+	imp.CreatedAt = time.Now().UTC()
+	imp.UpdatedAt = imp.CreatedAt
+	m.imports[imp.ID] = memoryImport{Import: copyImport(*imp), Items: append([]ImportItem{}, items...)}
+	return nil
+}
+
+// GetImportByID retrieves an import owned by the given user.
+func (m *MemoryStore) GetImportByID(ctx context.Context, id, userID string) (*Import, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.imports[id]
+	if !ok || stored.UserID != userID {
+		return nil, nil
+	}
+	imp := copyImport(stored.Import)
+	return &imp, nil
+}
+
+// GetImportsByUserID retrieves a user's imports, newest first.
+func (m *MemoryStore) GetImportsByUserID(ctx context.Context, userID string) ([]Import, error) {
+	return m.filterImports(func(imp *Import) bool { return imp.UserID == userID }, true), nil
+}
+
+// GetRunningImports retrieves the imports that have not finished.
+func (m *MemoryStore) GetRunningImports(ctx context.Context) ([]Import, error) {
+	return m.filterImports(func(imp *Import) bool { return imp.Status == ImportStatusRunning }, false), nil
+}
+
+func (m *MemoryStore) filterImports(keep func(imp *Import) bool, newestFirst bool) []Import {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	imports := []Import{}
+	for _, stored := range m.imports {
+		if keep(&stored.Import) {
+			imports = append(imports, copyImport(stored.Import))
+		}
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		a, b := imports[i], imports[j]
+		if newestFirst {
+			a, b = b, a
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return imports
+}
+
+// GetImportItems retrieves the entries of an import, in file order.
+func (m *MemoryStore) GetImportItems(ctx context.Context, id string) ([]ImportItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.imports[id]
+	if !ok {
+		return nil, fmt.Errorf("import with ID '%s' not found", id)
+	}
+	return append([]ImportItem{}, stored.Items...), nil
+}
+
+// UpdateImport saves an import's status, progress and errors.
+func (m *MemoryStore) UpdateImport(ctx context.Context, imp *Import) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.imports[imp.ID]
+	if !ok {
+		return fmt.Errorf("import with ID '%s' not found", imp.ID)
+	}
+	imp.UpdatedAt = time.Now().UTC()
+	stored.Import = copyImport(*imp)
+	m.imports[imp.ID] = stored
+	return nil
+}
+
+// SaveArticleImage stores the copy of an article's lead image, replacing any previous one.
+func (m *MemoryStore) SaveArticleImage(ctx context.Context, img *ArticleImage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	img.CreatedAt = time.Now().UTC()
+	stored := *img
+	stored.Data = append([]byte(nil), img.Data...)
+	m.images[img.ArticleID] = stored
+	return nil
+}
+
+// GetArticleImage retrieves the lead image of an article owned by the given user.
+func (m *MemoryStore) GetArticleImage(ctx context.Context, articleID, userID string) (*ArticleImage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	img, ok := m.images[articleID]
+	if !ok || m.articles[articleID].UserID != userID {
+		return nil, nil
+	}
+	return &img, nil
+}
+
+// DeleteArticleImage removes the copy of an article's lead image, if there is one.
+func (m *MemoryStore) DeleteArticleImage(ctx context.Context, articleID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.images, articleID)
+	return nil
+}
+
+// CreateFeedToken creates a feed token for a user and returns the plain token.
+func (m *MemoryStore) CreateFeedToken(ctx context.Context, userID, name string) (string, *FeedToken, error) {
+	plain, err := generateRefreshTokenValue()
+	if err != nil {
+		return "", nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ft := FeedToken{ID: GenerateUUID(), UserID: userID, Name: name, CreatedAt: time.Now().UTC()}
+	m.feedTokens[hashRefreshToken(plain)] = ft
+	return plain, &ft, nil
+}
+
+// GetFeedTokensByUserID retrieves a user's feed tokens, oldest first.
+func (m *MemoryStore) GetFeedTokensByUserID(ctx context.Context, userID string) ([]FeedToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tokens := []FeedToken{}
+	for _, ft := range m.feedTokens {
+		if ft.UserID == userID {
+			tokens = append(tokens, ft)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.Before(tokens[j].CreatedAt)
+		}
+		return tokens[i].ID < tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// UseFeedToken looks up a plain feed token and records that it was used.
+func (m *MemoryStore) UseFeedToken(ctx context.Context, token string) (*FeedToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hash := hashRefreshToken(token)
+	ft, ok := m.feedTokens[hash]
+	if !ok {
+		return nil, nil
+	}
+	now := time.Now().UTC()
+	ft.LastUsedAt = &now
+	m.feedTokens[hash] = ft
+	return &ft, nil
+}
+
+// DeleteFeedToken revokes a feed token by deleting it.
+func (m *MemoryStore) DeleteFeedToken(ctx context.Context, id, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for hash, ft := range m.feedTokens {
+		if ft.ID == id && ft.UserID == userID {
+			delete(m.feedTokens, hash)
+			return nil
+		}
+	}
+	return fmt.Errorf("feed token with ID '%s': %w", id, ErrFeedTokenNotFound)
+}
+
+// CreateSubscription stores a new subscription, due to be checked right away.
+func (m *MemoryStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.subscriptions {
+		if existing.UserID == sub.UserID && existing.URL == sub.URL {
+			return fmt.Errorf("subscription to '%s': %w", sub.URL, ErrSubscriptionExists)
+		}
+	}
+	sub.ID = GenerateUUID()
+	sub.Tags = NormalizeTags(sub.Tags)
+	sub.CreatedAt = time.Now().UTC()
+	sub.UpdatedAt = sub.CreatedAt
+	sub.NextPollAt = sub.CreatedAt
+	*sub = copySubscription(*sub)
+	m.subscriptions[sub.ID] = copySubscription(*sub)
+	return nil
+}
+
+// GetSubscriptionByID retrieves a subscription owned by the given user.
+func (m *MemoryStore) GetSubscriptionByID(ctx context.Context, id, userID string) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[id]
+	if !ok || sub.UserID != userID {
+		return nil, nil
+	}
+	sub = copySubscription(sub)
+	return &sub, nil
+}
+
+// GetSubscriptionsByUserID retrieves a user's subscriptions, oldest first.
+func (m *MemoryStore) GetSubscriptionsByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subs := []Subscription{}
+	for _, sub := range m.subscriptions {
+		if sub.UserID == userID {
+			subs = append(subs, copySubscription(sub))
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].CreatedAt.Equal(subs[j].CreatedAt) {
+			return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs, nil
+}
+
+// UpdateSubscription saves a subscription's title, tags, keywords and active flag.
+func (m *MemoryStore) UpdateSubscription(ctx context.Context, sub *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.subscriptions[sub.ID]
+	if !ok || existing.UserID != sub.UserID {
+		return fmt.Errorf("subscription with ID '%s': %w", sub.ID, ErrSubscriptionNotFound)
+	}
+	existing.Title = sub.Title
+	existing.Tags = NormalizeTags(sub.Tags)
+	existing.Keywords = sub.Keywords
+	existing.Active = sub.Active
+	existing.UpdatedAt = time.Now().UTC()
+	*sub = copySubscription(existing)
+	m.subscriptions[sub.ID] = copySubscription(existing)
+	return nil
+}
+
+// DeleteSubscription deletes a subscription and the record of the entries it has seen.
+func (m *MemoryStore) DeleteSubscription(ctx context.Context, id, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sub, ok := m.subscriptions[id]
+	if !ok || sub.UserID != userID {
+		return fmt.Errorf("subscription with ID '%s': %w", id, ErrSubscriptionNotFound)
+	}
+	delete(m.subscriptions, id)
+	delete(m.seenEntries, id)
+	return nil
+}
+
+// ClaimNextSubscription returns the active subscription whose check is the
+// most overdue, postponing its next check by lease.
+func (m *MemoryStore) ClaimNextSubscription(ctx context.Context, lease time.Duration) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	var next *Subscription
+	for id := range m.subscriptions {
+		sub := m.subscriptions[id]
+		if !sub.Active || sub.NextPollAt.After(now) {
+			continue
+		}
+		if next == nil || sub.NextPollAt.Before(next.NextPollAt) {
+			next = &sub
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+	claimed := copySubscription(*next)
+	next.NextPollAt = now.Add(lease)
+	m.subscriptions[next.ID] = *next
+	return &claimed, nil
+}
+
+// SaveSubscriptionPoll saves the outcome of checking a subscription's feed.
+func (m *MemoryStore) SaveSubscriptionPoll(ctx context.Context, sub *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.subscriptions[sub.ID]
+	if !ok {
+		return nil // Deleted while it was checked
+	}
+	if existing.Title == "" {
+		existing.Title = sub.Title
+	}
+	existing.ETag = sub.ETag
+	existing.LastModified = sub.LastModified
+	existing.LastPolledAt = sub.LastPolledAt
+	existing.NextPollAt = sub.NextPollAt.UTC()
+	existing.LastError = sub.LastError
+	existing.ErrorCount = sub.ErrorCount
+	existing.UpdatedAt = time.Now().UTC()
+	sub.UpdatedAt = existing.UpdatedAt
+	m.subscriptions[sub.ID] = copySubscription(existing)
+	return nil
+}
+
+// SubscriptionEntrySeen reports whether a subscription has seen an entry.
+func (m *MemoryStore) SubscriptionEntrySeen(ctx context.Context, subscriptionID, entryKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.seenEntries[subscriptionID][entryKey], nil
+}
+
+// MarkSubscriptionEntrySeen records that a subscription has seen an entry.
+func (m *MemoryStore) MarkSubscriptionEntrySeen(ctx context.Context, subscriptionID, entryKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := m.seenEntries[subscriptionID]
+	if seen == nil {
+		seen = make(map[string]bool)
+		m.seenEntries[subscriptionID] = seen
+	}
+	seen[entryKey] = true
+	return nil
+}
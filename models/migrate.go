@@ -0,0 +1,361 @@
+package models
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the SQL migrations of each dialect, named
+// "migrations/<dialect>/<version>_<name>.sql".
+//
+// PostgreSQL support started at SQLite schema version 10, so its 0010 migration
+// creates that whole schema at once. Later migrations use the same version in
+// both directories.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// Migration is a single, ordered schema change. A migration runs either SQL
+// (from an embedded file) or a Go function for data conversions that are
+// awkward to express in SQL.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+	Func    func(ctx context.Context, tx *dbTx) error
+}
+
+// MigrationStatus reports whether a migration has been applied to the database.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// goMigrations are migrations implemented in Go rather than SQL, per dialect.
+var goMigrations = map[dialect][]Migration{
+	dialectSQLite: {
+		{Version: 4, Name: "convert_legacy_tags", Func: convertLegacyTags},
+	},
+}
+
+// baselineVersion is the schema created by the original createTables. Databases
+// that predate schema_migrations are recorded as being at this version.
+const baselineVersion = 1
+
+// loadMigrations returns all known migrations of a dialect ordered by version.
+func loadMigrations(d dialect) ([]Migration, error) {
+	dir := path.Join("migrations", d.String())
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	migrations := append([]Migration(nil), goMigrations[d]...)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		versionStr, migrationName, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration file %q must be named <version>_<name>.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %q has an invalid version: %w", entry.Name(), err)
+		}
+		contents, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: migrationName, SQL: string(contents)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates schema_migrations and baselines SQLite databases
+// that were created before migrations existed.
+func (s *SQLStore) ensureMigrationsTable(ctx context.Context) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, s.db.dialect.tableExistsQuery(), "schema_migrations").Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check for schema_migrations table: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin schema_migrations transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Check again under the lock: another replica may have just created it
+	if err := lockMigrations(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, tx.dialect.tableExistsQuery(), "schema_migrations").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check for schema_migrations table: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	var legacy bool
+	if s.db.dialect == dialectSQLite {
+		err = tx.QueryRowContext(ctx, tx.dialect.tableExistsQuery(), "users").Scan(&legacy)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing tables: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	CREATE TABLE schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at `+s.db.dialect.timestampType()+` DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	if legacy {
+		migrations, err := loadMigrations(s.db.dialect)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if m.Version > baselineVersion {
+				break
+			}
+			if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, ?, ?)", m.Version, m.Name, time.Now()); err != nil {
+				return fmt.Errorf("failed to baseline migration %d: %w", m.Version, err)
+			}
+		}
+		log.Printf("Existing database detected, baselined at schema version %d.", baselineVersion)
+	}
+
+	return tx.Commit()
+}
+
+// GetMigrationStatus lists every known migration and whether it has been applied.
+func (s *SQLStore) GetMigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	if err := s.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(s.db.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations row: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schema_migrations rows: %w", err)
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		appliedAt, ok := applied[m.Version]
+		statuses[i] = MigrationStatus{Version: m.Version, Name: m.Name, Applied: ok, AppliedAt: appliedAt}
+	}
+	return statuses, nil
+}
+
+// PendingMigrations returns the migrations that have not been applied yet.
+func (s *SQLStore) PendingMigrations(ctx context.Context) ([]Migration, error) {
+	statuses, err := s.GetMigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadMigrations(s.db.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for i, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, migrations[i])
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies all pending migrations in order, each in its own transaction,
+// and returns the migrations that were applied.
+//
+// On PostgreSQL each transaction holds an advisory lock, and a migration
+// another replica applied in the meantime is skipped, so replicas starting at
+// once can all call Migrate.
+func (s *SQLStore) Migrate(ctx context.Context) ([]Migration, error) {
+	pending, err := s.PendingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, m := range pending {
+		ok, err := s.migrateOne(ctx, m)
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			applied = append(applied, m)
+			log.Printf("Applied migration %04d_%s.", m.Version, m.Name)
+		}
+	}
+	return applied, nil
+}
+
+// migrateOne applies a migration in a transaction of its own, unless it has
+// been applied since the pending migrations were read, and reports whether it
+// applied it.
+func (s *SQLStore) migrateOne(ctx context.Context, m Migration) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if err := lockMigrations(ctx, tx); err != nil {
+		return false, err
+	}
+	var applied bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)", m.Version).Scan(&applied)
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %w", m.Version, err)
+	}
+	if applied {
+		return false, nil
+	}
+
+	if err := applyMigration(ctx, tx, m); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+	return true, nil
+}
+
+// MigrateDryRun applies all pending migrations in a single transaction and rolls
+// it back, so problems surface without changing the database.
+func (s *SQLStore) MigrateDryRun(ctx context.Context) ([]Migration, error) {
+	pending, err := s.PendingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin dry run: %w", err)
+	}
+	defer tx.Rollback()
+	if err := lockMigrations(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	for i, m := range pending {
+		if err := applyMigration(ctx, tx, m); err != nil {
+			return pending[:i], err
+		}
+	}
+	return pending, nil
+}
+
+// lockMigrations takes the migration lock until tx ends.
+func lockMigrations(ctx context.Context, tx *dbTx) error {
+	query := tx.dialect.lockMigrationsQuery()
+	if query == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	return nil
+}
+
+// applyMigration runs a migration and records it in schema_migrations.
+func applyMigration(ctx context.Context, tx *dbTx, m Migration) error {
+	if m.Func != nil {
+		if err := m.Func(ctx, tx); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	if m.SQL != "" {
+		// Run the file as written; it may contain several statements and no placeholders
+		if _, err := tx.Tx.ExecContext(ctx, m.SQL); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, ?, ?)", m.Version, m.Name, time.Now()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+	return nil
+}
+
+// convertLegacyTags moves tags stored as comma-separated strings in articles.tags
+// into the tags and article_tags tables.
+func convertLegacyTags(ctx context.Context, tx *dbTx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT id, tags FROM articles WHERE tags IS NOT NULL AND tags != ''")
+	if err != nil {
+		return err
+	}
+	legacy := make(map[string]string)
+	for rows.Next() {
+		var id, tagsStr string
+		if err := rows.Scan(&id, &tagsStr); err != nil {
+			rows.Close()
+			return err
+		}
+		legacy[id] = tagsStr
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, tagsStr := range legacy {
+		if err := replaceArticleTags(ctx, tx, id, splitLegacyTags(tagsStr), nil); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE articles SET tags = NULL WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	if len(legacy) > 0 {
+		log.Printf("Converted comma-separated tags for %d articles.", len(legacy))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// migrateTo applies the pending migrations up to and including version.
+func migrateTo(t *testing.T, s *SQLStore, version int) {
+	t.Helper()
+	ctx := context.Background()
+	pending, err := s.PendingMigrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range pending {
+		if m.Version > version {
+			break
+		}
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := applyMigration(ctx, tx, m); err != nil {
+			tx.Rollback()
+			t.Fatal(err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrationVersionsMatch(t *testing.T) {
+	sqlite, err := loadMigrations(dialectSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := loadMigrations(dialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) == 0 || postgres[0].Version != 10 {
+		t.Fatalf("PostgreSQL migrations start at %+v, want version 10", postgres)
+	}
+	names := make(map[int]string)
+	for _, m := range sqlite {
+		names[m.Version] = m.Name
+	}
+	// Version 10 creates the whole schema in PostgreSQL; the later ones match SQLite's
+	for _, m := range postgres[1:] {
+		if names[m.Version] != m.Name {
+			t.Errorf("PostgreSQL migration %04d_%s has no SQLite counterpart (SQLite has %q)", m.Version, m.Name, names[m.Version])
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tables := []string{
+		"users", "articles", "processing_jobs", "tags", "article_tags", "revoked_tokens",
+		// Created by 0010 and 0016 to 0020
+		"refresh_tokens", "webhooks", "webhook_deliveries", "imports", "article_images", "feed_tokens",
+		"subscriptions", "subscription_entries",
+	}
+	run := func(t *testing.T, s *SQLStore) {
+		ctx := context.Background()
+		migrations, err := loadMigrations(s.db.dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// A dry run changes nothing
+		tried, err := s.MigrateDryRun(ctx)
+		if err != nil {
+			t.Fatalf("dry run: %v", err)
+		}
+		if len(tried) != len(migrations) {
+			t.Errorf("dry run tried %d migrations, want %d", len(tried), len(migrations))
+		}
+		if pending, err := s.PendingMigrations(ctx); err != nil || len(pending) != len(migrations) {
+			t.Errorf("after a dry run, %d migrations are pending (%v), want %d", len(pending), err, len(migrations))
+		}
+
+		applied, err := s.Migrate(ctx)
+		if err != nil {
+			t.Fatalf("migrating: %v", err)
+		}
+		if len(applied) != len(migrations) {
+			t.Errorf("applied %d migrations, want %d", len(applied), len(migrations))
+		}
+		statuses, err := s.GetMigrationStatus(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, status := range statuses {
+			if !status.Applied || status.AppliedAt.IsZero() {
+				t.Errorf("migration %04d_%s status = %+v, want applied", status.Version, status.Name, status)
+			}
+		}
+		if applied, err := s.Migrate(ctx); err != nil || len(applied) != 0 {
+			t.Errorf("migrating again applied %d migrations, %v; want none", len(applied), err)
+		}
+
+		searchTable := "articles_fts"
+		if s.db.dialect == dialectPostgres {
+			searchTable = "article_search"
+		}
+		for _, table := range append(tables, searchTable) {
+			var exists bool
+			if err := s.db.QueryRowContext(ctx, s.db.dialect.tableExistsQuery(), table).Scan(&exists); err != nil {
+				t.Fatal(err)
+			}
+			if !exists {
+				t.Errorf("table %s doesn't exist", table)
+			}
+		}
+		// Columns added by 0011 to 0015
+		_, err = s.db.ExecContext(ctx, `SELECT content_html, author, published_at, site_name, image_url, description,
+			language, canonical_url, word_count, reading_minutes, page_count, failure_reason, failure_stage
+			FROM articles WHERE id = ''`)
+		if err != nil {
+			t.Errorf("selecting the article columns: %v", err)
+		}
+		if _, err := s.db.ExecContext(ctx, "SELECT words_per_minute FROM users WHERE id = ''"); err != nil {
+			t.Errorf("selecting the reading speed: %v", err)
+		}
+	}
+	t.Run("sqlite", func(t *testing.T) { run(t, newSQLiteStore(t)) })
+	t.Run("postgres", func(t *testing.T) { run(t, newPostgresStore(t)) })
+}
+
+// TestMigrateConcurrently starts several replicas against the same empty
+// PostgreSQL schema at once; between them they apply each migration once.
+func TestMigrateConcurrently(t *testing.T) {
+	dsn := newPostgresSchema(t)
+	migrations, err := loadMigrations(dialectPostgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const replicas = 4
+	applied := make([][]Migration, replicas)
+	errs := make([]error, replicas)
+	var wg sync.WaitGroup
+	for i := 0; i < replicas; i++ {
+		s := openPostgres(t, dsn)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied[i], errs[i] = s.Migrate(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	versions := make(map[int]int)
+	for i := 0; i < replicas; i++ {
+		if errs[i] != nil {
+			t.Errorf("replica %d: %v", i, errs[i])
+		}
+		for _, m := range applied[i] {
+			versions[m.Version]++
+		}
+	}
+	for _, m := range migrations {
+		if versions[m.Version] != 1 {
+			t.Errorf("migration %04d_%s was applied %d times, want once", m.Version, m.Name, versions[m.Version])
+		}
+	}
+}
+
+// TestMigrateExistingArticles upgrades a database from before 0015 with
+// articles in it, so the migrations that convert data have some to convert.
+func TestMigrateExistingArticles(t *testing.T) {
+	run := func(t *testing.T, s *SQLStore) {
+		ctx := context.Background()
+		migrateTo(t, s, 14)
+
+		createdAt := time.Date(2025, 3, 1, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+		_, err := s.db.ExecContext(ctx, "INSERT INTO users(id, username, password_hash) VALUES('u1', 'reader', 'hash')")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range []struct{ id, status string }{{"failed", "failed"}, {"read", "read"}} {
+			_, err = s.db.ExecContext(ctx, "INSERT INTO articles(id, user_id, url, title, status, created_at, updated_at) VALUES(?, 'u1', ?, '', ?, ?, ?)",
+				a.id, "https://example.com/"+a.id, a.status, createdAt, createdAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.db.ExecContext(ctx, "INSERT INTO processing_jobs(article_id, user_id, status, max_attempts, last_error, next_run_at) VALUES(?, 'u1', ?, 3, ?, ?)",
+				a.id, JobStatusFailed, "fetch failed", createdAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		migrate(t, s)
+
+		failed, err := s.GetArticleByID(ctx, "failed", "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed.FailureReason != "fetch failed" || failed.FailureStage != "" {
+			t.Errorf("failed article's failure = %q at %q, want the job's last error", failed.FailureReason, failed.FailureStage)
+		}
+		read, err := s.GetArticleByID(ctx, "read", "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read.FailureReason != "" {
+			t.Errorf("read article's failure reason = %q, want none", read.FailureReason)
+		}
+		if !read.CreatedAt.Equal(createdAt) || !read.UpdatedAt.Equal(createdAt) {
+			t.Errorf("timestamps = %v, %v; want %v", read.CreatedAt, read.UpdatedAt, createdAt)
+		}
+
+		// 0021 stores SQLite's timestamps as UTC text, which sorts chronologically
+		if s.db.dialect == dialectSQLite {
+			var stored string
+			if err := s.db.QueryRowContext(ctx, "SELECT CAST(created_at AS TEXT) FROM articles WHERE id = 'read'").Scan(&stored); err != nil {
+				t.Fatal(err)
+			}
+			if want := "2025-03-01 14:30:00.000+00:00"; stored != want {
+				t.Errorf("stored created_at = %q, want %q", stored, want)
+			}
+			if read.CreatedAt.Location() != time.UTC {
+				t.Errorf("created_at is in %v, want UTC", read.CreatedAt.Location())
+			}
+		}
+	}
+	t.Run("sqlite", func(t *testing.T) { run(t, newSQLiteStore(t)) })
+	t.Run("postgres", func(t *testing.T) { run(t, newPostgresStore(t)) })
+}
@@ -0,0 +1,86 @@
+-- PostgreSQL schema equivalent to SQLite schema version 10. Later migrations use the
+-- same version numbers as their SQLite counterparts.
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username TEXT UNIQUE NOT NULL,
+	password_hash TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id),
+	url TEXT NOT NULL,
+	title TEXT NOT NULL,
+	summary TEXT,
+	status TEXT NOT NULL DEFAULT 'unread', -- 'read' or 'unread'
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	body_text TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_articles_user_created ON articles(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_articles_user_status ON articles(user_id, status);
+
+-- Durable queue of background article processing jobs, one per article.
+CREATE TABLE IF NOT EXISTS processing_jobs (
+	article_id TEXT PRIMARY KEY REFERENCES articles(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'succeeded' or 'failed'
+	attempts INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_run_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_processing_jobs_due ON processing_jobs(status, next_run_at);
+
+-- Normalized tags. Tags are shared between users; article_tags links them to individual articles.
+CREATE TABLE IF NOT EXISTS tags (
+	id TEXT PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL -- Normalized: lowercase, single spaces
+);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+	article_id TEXT NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+	tag_id TEXT NOT NULL REFERENCES tags(id),
+	PRIMARY KEY (article_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags(tag_id);
+
+-- Full-text search documents, kept in sync by the models package whenever an
+-- article or its tags change. Weighted by field: title, tags, summary, body.
+CREATE TABLE IF NOT EXISTS article_search (
+	article_id TEXT PRIMARY KEY REFERENCES articles(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL,
+	document TSVECTOR NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_search_document ON article_search USING GIN (document);
+CREATE INDEX IF NOT EXISTS idx_article_search_user_id ON article_search(user_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti TEXT PRIMARY KEY,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Long-lived refresh tokens, stored as SHA-256 hashes. Every rotation issues a new
+-- token in the same family; presenting a used token again revokes the whole family.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id),
+	family_id TEXT NOT NULL,
+	token_hash TEXT UNIQUE NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ, -- Set when the token is rotated
+	revoked_at TIMESTAMPTZ, -- Set when the family is revoked (logout or reuse)
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
@@ -0,0 +1,2 @@
+-- Sanitized HTML of the article's main content, alongside the extracted text in body_text.
+ALTER TABLE articles ADD COLUMN content_html TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Article length and the reading speed used to turn it into minutes. The default
+-- speed matches models.DefaultWordsPerMinute. Articles saved before this
+-- migration keep a zero word count until they are processed again.
+ALTER TABLE articles ADD COLUMN word_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE articles ADD COLUMN reading_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN words_per_minute INTEGER NOT NULL DEFAULT 238;
+
+CREATE INDEX IF NOT EXISTS idx_articles_user_reading_minutes ON articles(user_id, reading_minutes);
@@ -0,0 +1,2 @@
+-- Number of pages of PDF articles; zero for web pages and other documents.
+ALTER TABLE articles ADD COLUMN page_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- Why an article failed to process, and at which stage. Articles that failed
+-- before this migration get their job's last error; their stage is unknown.
+ALTER TABLE articles ADD COLUMN failure_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN failure_stage TEXT NOT NULL DEFAULT '';
+
+UPDATE articles SET failure_reason = COALESCE((SELECT last_error FROM processing_jobs WHERE article_id = articles.id), '')
+WHERE status = 'failed';
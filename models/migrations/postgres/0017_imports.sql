@@ -0,0 +1,24 @@
+-- Imports of reading lists exported from other services. The parsed entries are
+-- stored with the import, so an import interrupted by a restart can resume
+-- from the number of entries already processed.
+CREATE TABLE IF NOT EXISTS imports (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id),
+	format TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'running', -- 'running', 'completed' or 'failed'
+	total INTEGER NOT NULL DEFAULT 0,
+	processed INTEGER NOT NULL DEFAULT 0,
+	imported INTEGER NOT NULL DEFAULT 0,
+	duplicates INTEGER NOT NULL DEFAULT 0,
+	failed INTEGER NOT NULL DEFAULT 0,
+	errors TEXT NOT NULL DEFAULT '[]', -- JSON array of the entries that failed
+	items TEXT NOT NULL, -- JSON array of the parsed entries
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_imports_user_created ON imports(user_id, created_at);
+
+-- Imports skip URLs the user has already saved
+CREATE INDEX IF NOT EXISTS idx_articles_user_url ON articles(user_id, url);
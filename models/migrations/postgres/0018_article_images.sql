@@ -0,0 +1,8 @@
+-- Copies of articles' lead images, so EPUB exports can embed them for reading offline.
+CREATE TABLE IF NOT EXISTS article_images (
+	article_id TEXT PRIMARY KEY REFERENCES articles(id) ON DELETE CASCADE,
+	source_url TEXT NOT NULL, -- The article's image_url when the copy was made
+	content_type TEXT NOT NULL,
+	data BYTEA NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
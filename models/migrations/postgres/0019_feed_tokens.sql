@@ -0,0 +1,12 @@
+-- Tokens that give feed readers access to a user's feeds, which can't send a JWT.
+-- Like refresh tokens, they are stored as SHA-256 hashes. Deleting one revokes it.
+CREATE TABLE IF NOT EXISTS feed_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id),
+	name TEXT NOT NULL DEFAULT '', -- Chosen by the user, e.g. the feed reader it is for
+	token_hash TEXT UNIQUE NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	last_used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_feed_tokens_user_id ON feed_tokens(user_id);
@@ -0,0 +1,29 @@
+-- Feeds users subscribe to. Their new entries are added to the reading list by
+-- a poller, which remembers the entries it has seen in subscription_entries.
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id),
+	url TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	tags TEXT NOT NULL DEFAULT '[]', -- JSON array of the tags given to the articles added
+	keywords TEXT NOT NULL DEFAULT '[]', -- JSON array; when not empty, only entries mentioning one are added
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	etag TEXT NOT NULL DEFAULT '', -- Validators of the last response, for conditional requests
+	last_modified TEXT NOT NULL DEFAULT '',
+	last_polled_at TIMESTAMPTZ, -- Last successful check
+	next_poll_at TIMESTAMPTZ NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	error_count INTEGER NOT NULL DEFAULT 0, -- Failed checks since the last successful one
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_user_url ON subscriptions(user_id, url);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_due ON subscriptions(active, next_poll_at);
+
+CREATE TABLE IF NOT EXISTS subscription_entries (
+	subscription_id TEXT NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+	entry_key TEXT NOT NULL, -- The entry's ID, or its URL if it has none
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (subscription_id, entry_key)
+);
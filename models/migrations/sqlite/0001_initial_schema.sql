@@ -0,0 +1,25 @@
+-- Tables created by the original createTables. Existing databases are baselined at this version.
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username TEXT UNIQUE NOT NULL,
+	password_hash TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL,
+	summary TEXT,
+	tags TEXT, -- Storing as comma-separated string for simplicity initially
+	status TEXT NOT NULL DEFAULT 'unread', -- 'read' or 'unread'
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	token TEXT PRIMARY KEY,
+	expires_at TIMESTAMP
+);
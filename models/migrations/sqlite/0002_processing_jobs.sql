@@ -0,0 +1,15 @@
+-- Durable queue of background article processing jobs, one per article.
+CREATE TABLE IF NOT EXISTS processing_jobs (
+	article_id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'succeeded' or 'failed'
+	attempts INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	next_run_at DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (article_id) REFERENCES articles(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processing_jobs_due ON processing_jobs(status, next_run_at);
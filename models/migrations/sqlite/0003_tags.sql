@@ -0,0 +1,15 @@
+-- Normalized tags. Tags are shared between users; article_tags links them to individual articles.
+CREATE TABLE IF NOT EXISTS tags (
+	id TEXT PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL -- Normalized: lowercase, single spaces
+);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+	article_id TEXT NOT NULL,
+	tag_id TEXT NOT NULL,
+	PRIMARY KEY (article_id, tag_id),
+	FOREIGN KEY (article_id) REFERENCES articles(id),
+	FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_article_tags_tag_id ON article_tags(tag_id);
@@ -0,0 +1,2 @@
+-- Tags now live in article_tags (converted by migration 4).
+ALTER TABLE articles DROP COLUMN tags;
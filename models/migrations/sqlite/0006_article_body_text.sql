@@ -0,0 +1,2 @@
+-- Extracted article text, used for full-text search.
+ALTER TABLE articles ADD COLUMN body_text TEXT NOT NULL DEFAULT '';
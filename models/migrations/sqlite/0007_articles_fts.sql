@@ -0,0 +1,16 @@
+-- Full-text index over articles. Requires SQLite built with FTS5 (go build -tags sqlite_fts5).
+-- Rows are kept in sync by the models package whenever an article or its tags change.
+CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5(
+	article_id UNINDEXED,
+	user_id UNINDEXED,
+	title,
+	summary,
+	body,
+	tags,
+	tokenize = 'porter unicode61'
+);
+
+INSERT INTO articles_fts(article_id, user_id, title, summary, body, tags)
+SELECT a.id, a.user_id, a.title, COALESCE(a.summary, ''), a.body_text,
+	COALESCE((SELECT group_concat(t.name, ' ') FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE at.article_id = a.id), '')
+FROM articles a;
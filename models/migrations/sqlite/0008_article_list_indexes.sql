@@ -0,0 +1,3 @@
+-- Support the paginated article list, which filters by user and status and sorts by creation time.
+CREATE INDEX IF NOT EXISTS idx_articles_user_created ON articles(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_articles_user_status ON articles(user_id, status);
@@ -0,0 +1,10 @@
+-- Revoke tokens by their jti claim instead of the full token string.
+-- Tokens issued before this change carry no jti and are rejected outright, so the old rows are no longer needed.
+DROP TABLE IF EXISTS revoked_tokens;
+
+CREATE TABLE revoked_tokens (
+	jti TEXT PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
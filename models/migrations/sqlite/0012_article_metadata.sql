@@ -0,0 +1,8 @@
+-- Metadata read from the page head: OpenGraph, Twitter Card, JSON-LD and <meta> tags.
+ALTER TABLE articles ADD COLUMN author TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN published_at DATETIME;
+ALTER TABLE articles ADD COLUMN site_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN language TEXT NOT NULL DEFAULT '';
+ALTER TABLE articles ADD COLUMN canonical_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- Copies of articles' lead images, so EPUB exports can embed them for reading offline.
+CREATE TABLE IF NOT EXISTS article_images (
+	article_id TEXT PRIMARY KEY,
+	source_url TEXT NOT NULL, -- The article's image_url when the copy was made
+	content_type TEXT NOT NULL,
+	data BLOB NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (article_id) REFERENCES articles(id)
+);
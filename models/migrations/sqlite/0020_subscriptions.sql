@@ -0,0 +1,31 @@
+-- Feeds users subscribe to. Their new entries are added to the reading list by
+-- a poller, which remembers the entries it has seen in subscription_entries.
+CREATE TABLE IF NOT EXISTS subscriptions (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	url TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	tags TEXT NOT NULL DEFAULT '[]', -- JSON array of the tags given to the articles added
+	keywords TEXT NOT NULL DEFAULT '[]', -- JSON array; when not empty, only entries mentioning one are added
+	active BOOLEAN NOT NULL DEFAULT 1,
+	etag TEXT NOT NULL DEFAULT '', -- Validators of the last response, for conditional requests
+	last_modified TEXT NOT NULL DEFAULT '',
+	last_polled_at DATETIME, -- Last successful check
+	next_poll_at DATETIME NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	error_count INTEGER NOT NULL DEFAULT 0, -- Failed checks since the last successful one
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_subscriptions_user_url ON subscriptions(user_id, url);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_due ON subscriptions(active, next_poll_at);
+
+CREATE TABLE IF NOT EXISTS subscription_entries (
+	subscription_id TEXT NOT NULL,
+	entry_key TEXT NOT NULL, -- The entry's ID, or its URL if it has none
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (subscription_id, entry_key),
+	FOREIGN KEY (subscription_id) REFERENCES subscriptions(id)
+);
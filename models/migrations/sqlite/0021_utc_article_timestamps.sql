@@ -0,0 +1,10 @@
+-- Article timestamps used to be saved in local time by hand and in UTC by
+-- imports, or as CURRENT_TIMESTAMP without a zone. Lists sort and page by the
+-- text of these columns, so convert them all to UTC in the format the driver
+-- writes. PostgreSQL stores them as TIMESTAMPTZ and needs no conversion.
+UPDATE articles SET created_at = strftime('%Y-%m-%d %H:%M:%f+00:00', created_at)
+WHERE created_at NOT LIKE '%+00:00';
+UPDATE articles SET updated_at = strftime('%Y-%m-%d %H:%M:%f+00:00', updated_at)
+WHERE updated_at NOT LIKE '%+00:00';
+UPDATE articles SET published_at = strftime('%Y-%m-%d %H:%M:%f+00:00', published_at)
+WHERE published_at NOT LIKE '%+00:00';
@@ -0,0 +1,4 @@
+-- Whether processing added a tag to an article, rather than the user or an
+-- import. Reprocessing replaces an article's auto tags and keeps the others.
+-- Tags added before this migration can't be told apart and count as the user's.
+ALTER TABLE article_tags ADD COLUMN auto BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Processing job statuses.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+// ProcessingJob tracks the background processing of a single article.
+// There is at most one job per article; re-enqueueing an article resets its job.
+type ProcessingJob struct {
+	ArticleID   string    `json:"article_id"`
+	UserID      string    `json:"user_id"`
+	Status      string    `json:"status"` // "pending", "running", "succeeded" or "failed"
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextRunAt   time.Time `json:"next_run_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const processingJobColumns = "article_id, user_id, status, attempts, max_attempts, last_error, next_run_at, created_at, updated_at"
+
+func scanProcessingJob(row interface{ Scan(...interface{}) error }) (*ProcessingJob, error) {
+	job := &ProcessingJob{}
+	err := row.Scan(
+		&job.ArticleID, &job.UserID, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.LastError, &job.NextRunAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// EnqueueProcessingJob creates a pending job for an article, or resets the existing one.
+// It returns ErrProcessingJobActive if the existing job is still pending or running.
+func (s *SQLStore) EnqueueProcessingJob(ctx context.Context, articleID, userID string, maxAttempts int) error {
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO processing_jobs(article_id, user_id, status, attempts, max_attempts, last_error, next_run_at, created_at, updated_at)
+		VALUES(?, ?, ?, 0, ?, '', ?, ?, ?)
+		ON CONFLICT(article_id) DO UPDATE SET
+			status=excluded.status, attempts=0, max_attempts=excluded.max_attempts,
+			last_error='', next_run_at=excluded.next_run_at, updated_at=excluded.updated_at
+		WHERE processing_jobs.status NOT IN (?, ?)`,
+		articleID, userID, JobStatusPending, maxAttempts, now, now, now, JobStatusPending, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue processing job: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("processing job for article '%s': %w", articleID, ErrProcessingJobActive)
+	}
+	return nil
+}
+
+// ClaimNextProcessingJob atomically marks the next due pending job as running and returns it.
+// It returns nil if no job is due.
+func (s *SQLStore) ClaimNextProcessingJob(ctx context.Context) (*ProcessingJob, error) {
+	now := time.Now().UTC()
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE processing_jobs SET status=?, attempts=attempts+1, updated_at=?
+		WHERE article_id = (
+			SELECT article_id FROM processing_jobs
+			WHERE status=? AND next_run_at <= ?
+			ORDER BY next_run_at LIMIT 1`+s.db.dialect.skipLocked()+`
+		) AND status=?
+		RETURNING `+processingJobColumns,
+		JobStatusRunning, now, JobStatusPending, now, JobStatusPending)
+	job, err := scanProcessingJob(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Nothing to do
+		}
+		return nil, fmt.Errorf("failed to claim processing job: %w", err)
+	}
+	return job, nil
+}
+
+// CompleteProcessingJob marks a job as succeeded.
+func (s *SQLStore) CompleteProcessingJob(ctx context.Context, articleID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE processing_jobs SET status=?, last_error='', updated_at=? WHERE article_id=?",
+		JobStatusSucceeded, time.Now().UTC(), articleID)
+	if err != nil {
+		return fmt.Errorf("failed to complete processing job: %w", err)
+	}
+	return nil
+}
+
+// RetryProcessingJob records a failed attempt and schedules the job to run again at nextRunAt.
+func (s *SQLStore) RetryProcessingJob(ctx context.Context, articleID, lastError string, nextRunAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE processing_jobs SET status=?, last_error=?, next_run_at=?, updated_at=? WHERE article_id=?",
+		JobStatusPending, lastError, nextRunAt.UTC(), time.Now().UTC(), articleID)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule processing job: %w", err)
+	}
+	return nil
+}
+
+// FailProcessingJob marks a job as permanently failed.
+func (s *SQLStore) FailProcessingJob(ctx context.Context, articleID, lastError string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE processing_jobs SET status=?, last_error=?, updated_at=? WHERE article_id=?",
+		JobStatusFailed, lastError, time.Now().UTC(), articleID)
+	if err != nil {
+		return fmt.Errorf("failed to mark processing job as failed: %w", err)
+	}
+	return nil
+}
+
+// ReleaseProcessingJob returns a running job to the queue without counting the attempt,
+// e.g. when a worker is interrupted by shutdown.
+func (s *SQLStore) ReleaseProcessingJob(ctx context.Context, articleID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE processing_jobs SET status=?, attempts="+s.db.dialect.greatest()+"(attempts-1, 0), updated_at=? WHERE article_id=? AND status=?",
+		JobStatusPending, time.Now().UTC(), articleID, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to release processing job: %w", err)
+	}
+	return nil
+}
+
+// RequeueRunningProcessingJobs puts jobs left in "running" by a previous process back in the queue.
+func (s *SQLStore) RequeueRunningProcessingJobs(ctx context.Context) (int64, error) {
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, "UPDATE processing_jobs SET status=?, next_run_at=?, updated_at=? WHERE status=?",
+		JobStatusPending, now, now, JobStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue running processing jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetOrphanedProcessingArticles returns articles stuck in "processing" that have no pending or running job.
+func (s *SQLStore) GetOrphanedProcessingArticles(ctx context.Context) ([]Article, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id FROM articles
+		WHERE status = 'processing' AND id NOT IN (
+			SELECT article_id FROM processing_jobs WHERE status IN (?, ?)
+		)`, JobStatusPending, JobStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned articles: %w", err)
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var a Article
+		if err := rows.Scan(&a.ID, &a.UserID); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned article row: %w", err)
+		}
+		articles = append(articles, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orphaned article rows: %w", err)
+	}
+	return articles, nil
+}
+
+// GetProcessingJobByArticleID retrieves the processing job for an article owned by the given user.
+func (s *SQLStore) GetProcessingJobByArticleID(ctx context.Context, articleID, userID string) (*ProcessingJob, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+processingJobColumns+" FROM processing_jobs WHERE article_id = ? AND user_id = ?", articleID, userID)
+	job, err := scanProcessingJob(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Job not found
+		}
+		return nil, fmt.Errorf("failed to get processing job: %w", err)
+	}
+	return job, nil
+}
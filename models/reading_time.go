@@ -0,0 +1,43 @@
+package models
+
+import "unicode"
+
+// Reading speeds, in words per minute.
+const (
+	DefaultWordsPerMinute = 238 // Average adult silent reading speed for non-fiction
+	MinWordsPerMinute     = 50
+	MaxWordsPerMinute     = 1500
+)
+
+// CountWords counts the whitespace-separated words in text; punctuation on its
+// own isn't a word. Chinese and Japanese characters count as a word each,
+// since those scripts don't separate words with spaces and are read at a
+// similar number of characters per minute. Korean separates words with
+// spaces, so it is counted like other scripts.
+func CountWords(text string) int {
+	count := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			count++
+			inWord = false
+		case unicode.IsSpace(r):
+			inWord = false
+		case !inWord && !unicode.IsPunct(r):
+			count++
+			inWord = true
+		}
+	}
+	return count
+}
+
+// ReadingMinutes estimates how many minutes it takes to read wordCount words
+// at wpm words per minute, rounded up. SetUserWordsPerMinute does the same
+// calculation in SQL.
+func ReadingMinutes(wordCount, wpm int) int {
+	if wpm <= 0 {
+		wpm = DefaultWordsPerMinute
+	}
+	return (wordCount + wpm - 1) / wpm
+}
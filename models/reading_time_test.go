@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"whitespace only", " \t\n ", 0},
+		{"english", "The quick brown fox jumps.", 5},
+		{"extra whitespace", "  one\ttwo\n\nthree  ", 3},
+		{"punctuation stays with words", "well-known, isn't it?", 3},
+		{"lone punctuation", "wait — what ...", 2},
+		{"leading punctuation", "\"quoted\" (aside)", 2},
+		{"chinese", "我喜欢读书", 5},
+		{"japanese kana and kanji", "日本語のテキスト", 8},
+		{"korean words are space-separated", "한국어 문장", 2},
+		{"mixed scripts", "Go言語 is fun", 5},
+		{"cjk ends a latin word", "ab字cd", 3},
+		{"fullwidth punctuation isn't counted", "你好。", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountWords(tt.text); got != tt.want {
+				t.Errorf("CountWords(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingMinutes(t *testing.T) {
+	tests := []struct {
+		words, wpm, want int
+	}{
+		{0, 200, 0},
+		{1, 200, 1},
+		{200, 200, 1},
+		{201, 200, 2},
+		{DefaultWordsPerMinute * 3, 0, 3}, // The default speed when none is set
+	}
+	for _, tt := range tests {
+		if got := ReadingMinutes(tt.words, tt.wpm); got != tt.want {
+			t.Errorf("ReadingMinutes(%d, %d) = %d, want %d", tt.words, tt.wpm, got, tt.want)
+		}
+	}
+}
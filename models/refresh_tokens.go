@@ -0,0 +1,155 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown, expired or revoked refresh tokens.
+	ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is
+	// presented again. The token's whole family has been revoked when this is returned.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshToken represents an entry in the refresh_tokens table. The plain token
+// is only ever returned to the client; the database stores its hash.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string // Shared by every token rotated from the same login
+	ExpiresAt time.Time
+}
+
+// hashRefreshToken returns the hex SHA-256 of a refresh token. Refresh tokens are
+// long random values, so a fast hash is sufficient.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRefreshTokenValue returns a new random refresh token.
+func generateRefreshTokenValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// insertRefreshToken stores a new token in the given family and returns its plain value.
+func insertRefreshToken(ctx context.Context, exec interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}, userID, familyID string, ttl time.Duration) (string, *RefreshToken, error) {
+	plain, err := generateRefreshTokenValue()
+	if err != nil {
+		return "", nil, err
+	}
+	rt := &RefreshToken{
+		ID:        GenerateUUID(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	_, err = exec.ExecContext(ctx, "INSERT INTO refresh_tokens(id, user_id, family_id, token_hash, expires_at, created_at) VALUES(?, ?, ?, ?, ?, ?)",
+		rt.ID, rt.UserID, rt.FamilyID, hashRefreshToken(plain), rt.ExpiresAt, time.Now().UTC())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return plain, rt, nil
+}
+
+// CreateRefreshToken starts a new token family for a user (i.e. a new login)
+// and returns the plain token to hand to the client.
+func (s *SQLStore) CreateRefreshToken(ctx context.Context, userID string, ttl time.Duration) (string, *RefreshToken, error) {
+	return insertRefreshToken(ctx, s.db, userID, GenerateUUID(), ttl)
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same family.
+// The presented token can never be used again; if it already was, the family is
+// revoked and ErrRefreshTokenReused is returned.
+func (s *SQLStore) RotateRefreshToken(ctx context.Context, token string, ttl time.Duration) (string, *RefreshToken, error) {
+	hash := hashRefreshToken(token)
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin refresh token transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Claim the token first, so two concurrent rotations can't both succeed
+	result, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET used_at=? WHERE token_hash=? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?",
+		now, hash, now)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to claim refresh token: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	var userID, familyID string
+	var usedAt, revokedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT user_id, family_id, used_at, revoked_at FROM refresh_tokens WHERE token_hash=?", hash).
+		Scan(&userID, &familyID, &usedAt, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, ErrRefreshTokenInvalid
+		}
+		return "", nil, fmt.Errorf("failed to look up refresh token: %w", err)
+	}
+
+	if claimed == 0 {
+		if usedAt.Valid && !revokedAt.Valid {
+			// A rotated token came back: assume it was stolen and end the session everywhere
+			if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=? WHERE family_id=? AND revoked_at IS NULL", now, familyID); err != nil {
+				return "", nil, fmt.Errorf("failed to revoke refresh token family: %w", err)
+			}
+			if err := tx.Commit(); err != nil {
+				return "", nil, fmt.Errorf("failed to commit refresh token family revocation: %w", err)
+			}
+			log.Printf("Refresh token reuse detected for user %s, revoked token family %s", userID, familyID)
+			return "", nil, ErrRefreshTokenReused
+		}
+		return "", nil, ErrRefreshTokenInvalid
+	}
+
+	plain, rt, err := insertRefreshToken(ctx, tx, userID, familyID, ttl)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", nil, fmt.Errorf("failed to commit refresh token rotation: %w", err)
+	}
+	return plain, rt, nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token of a user's token family.
+func (s *SQLStore) RevokeRefreshTokenFamily(ctx context.Context, familyID, userID string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at=? WHERE family_id=? AND user_id=? AND revoked_at IS NULL",
+		time.Now().UTC(), familyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredRefreshTokens removes refresh tokens that have expired, and
+// returns how many rows were deleted.
+func (s *SQLStore) DeleteExpiredRefreshTokens(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotateRefreshToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		first, created, err := s.CreateRefreshToken(ctx, user.ID, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == "" || created.UserID != user.ID || created.FamilyID == "" {
+			t.Fatalf("created token %q, %+v; want a token of user %s in a new family", first, created, user.ID)
+		}
+
+		second, rotated, err := s.RotateRefreshToken(ctx, first, time.Hour)
+		if err != nil {
+			t.Fatalf("rotating a fresh token: %v", err)
+		}
+		if second == first || rotated.ID == created.ID {
+			t.Errorf("rotation returned the same token")
+		}
+		if rotated.UserID != user.ID || rotated.FamilyID != created.FamilyID {
+			t.Errorf("rotated token %+v, want user %s and family %s", rotated, user.ID, created.FamilyID)
+		}
+
+		if err := s.RevokeRefreshTokenFamily(ctx, created.FamilyID, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := s.RotateRefreshToken(ctx, second, time.Hour); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("rotating a token of a revoked family: got %v, want ErrRefreshTokenInvalid", err)
+		}
+		if _, _, err := s.RotateRefreshToken(ctx, "unknown", time.Hour); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("rotating an unknown token: got %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		first, _, err := s.CreateRefreshToken(ctx, user.ID, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		otherLogin, _, err := s.CreateRefreshToken(ctx, user.ID, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, _, err := s.RotateRefreshToken(ctx, first, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := s.RotateRefreshToken(ctx, first, time.Hour); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("rotating a used token: got %v, want ErrRefreshTokenReused", err)
+		}
+		if _, _, err := s.RotateRefreshToken(ctx, second, time.Hour); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("rotating the family's current token after reuse: got %v, want ErrRefreshTokenInvalid", err)
+		}
+		// Presenting the used token again doesn't count as reuse of a live family
+		if _, _, err := s.RotateRefreshToken(ctx, first, time.Hour); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("rotating a used token of a revoked family: got %v, want ErrRefreshTokenInvalid", err)
+		}
+		if _, _, err := s.RotateRefreshToken(ctx, otherLogin, time.Hour); err != nil {
+			t.Errorf("rotating the token of another login: %v", err)
+		}
+	})
+}
+
+func TestRefreshTokenExpiry(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		expired, _, err := s.CreateRefreshToken(ctx, user.ID, -time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		live, _, err := s.CreateRefreshToken(ctx, user.ID, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := s.RotateRefreshToken(ctx, expired, time.Hour); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("rotating an expired token: got %v, want ErrRefreshTokenInvalid", err)
+		}
+		n, err := s.DeleteExpiredRefreshTokens(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("DeleteExpiredRefreshTokens() = %d, want 1", n)
+		}
+		if _, _, err := s.RotateRefreshToken(ctx, live, time.Hour); err != nil {
+			t.Errorf("rotating a live token after cleanup: %v", err)
+		}
+	})
+}
+
+// TestRotateRefreshTokenConcurrently presents the same token several times at
+// once: one rotation succeeds, and the others count as reuse or find the family
+// already revoked.
+func TestRotateRefreshTokenConcurrently(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		token, _, err := s.CreateRefreshToken(ctx, user.ID, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const rotations = 8
+		errs := make(chan error, rotations)
+		var wg sync.WaitGroup
+		for i := 0; i < rotations; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := s.RotateRefreshToken(ctx, token, time.Hour)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				succeeded++
+			case !errors.Is(err, ErrRefreshTokenReused) && !errors.Is(err, ErrRefreshTokenInvalid):
+				t.Errorf("concurrent rotation: got %v, want success, ErrRefreshTokenReused or ErrRefreshTokenInvalid", err)
+			}
+		}
+		if succeeded != 1 {
+			t.Errorf("%d concurrent rotations succeeded, want 1", succeeded)
+		}
+	})
+}
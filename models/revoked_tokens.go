@@ -1,33 +1,44 @@
 package models
 
 import (
+	"context"
 	"time"
 )
 
 // RevokedToken represents an entry in the revoked_tokens table.
 type RevokedToken struct {
-	Token     string
+	JTI       string
 	ExpiresAt time.Time
 }
 
-// RevokeToken inserts a token into the revoked_tokens table.
-func RevokeToken(token string, expiresAt time.Time) error {
-	stmt, err := DB.Prepare("INSERT INTO revoked_tokens(token, expires_at) VALUES(?, ?)")
+// RevokeToken records a token's jti as revoked until the token expires.
+func (s *SQLStore) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO revoked_tokens(jti, expires_at) VALUES(?, ?) ON CONFLICT(jti) DO NOTHING")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(token, expiresAt)
+	_, err = stmt.ExecContext(ctx, jti, expiresAt.UTC())
 	return err
 }
 
-// IsTokenRevoked checks if a token exists in the revoked_tokens table.
-func IsTokenRevoked(token string) (bool, error) {
+// IsTokenRevoked checks if a token's jti exists in the revoked_tokens table.
+func (s *SQLStore) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
 	var exists bool
-	err := DB.QueryRow("SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE token = ?)", token).Scan(&exists)
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = ?)", jti).Scan(&exists)
 	if err != nil {
 		return false, err
 	}
 	return exists, nil
 }
+
+// DeleteExpiredRevokedTokens removes revocations for tokens that have expired
+// anyway, and returns how many rows were deleted.
+func (s *SQLStore) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevokedTokens(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		if err := s.RevokeToken(ctx, "expired", time.Now().Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.RevokeToken(ctx, "live", time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		// Logging out twice with the same token isn't an error
+		if err := s.RevokeToken(ctx, "live", time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("revoking a token again: %v", err)
+		}
+
+		for jti, want := range map[string]bool{"expired": true, "live": true, "unknown": false} {
+			revoked, err := s.IsTokenRevoked(ctx, jti)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != want {
+				t.Errorf("IsTokenRevoked(%q) = %v, want %v", jti, revoked, want)
+			}
+		}
+
+		n, err := s.DeleteExpiredRevokedTokens(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("DeleteExpiredRevokedTokens() = %d, want 1", n)
+		}
+		if revoked, err := s.IsTokenRevoked(ctx, "live"); err != nil || !revoked {
+			t.Errorf("IsTokenRevoked(%q) after cleanup = %v, %v; want true", "live", revoked, err)
+		}
+	})
+}
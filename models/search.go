@@ -0,0 +1,259 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Markers wrapped around matched terms in search snippets.
+const (
+	SnippetHighlightStart = "<mark>"
+	SnippetHighlightEnd   = "</mark>"
+)
+
+// Sentinels the search puts around matched terms. Snippets are cut from the
+// article text, which may contain markup, so the sentinels are only replaced
+// with the highlight markers once the rest of the snippet is HTML-escaped.
+const (
+	snippetMatchStart = "\x02"
+	snippetMatchEnd   = "\x03"
+)
+
+// ErrInvalidSearchQuery is returned when a search query has no searchable terms.
+var ErrInvalidSearchQuery = errors.New("search query has no searchable terms")
+
+// SearchResult is an article matching a full-text search, with a highlighted
+// snippet of the best matching text. The snippet is HTML: the text is escaped
+// and the matched terms are wrapped in the highlight markers.
+type SearchResult struct {
+	Article
+	Snippet string  `json:"snippet" example:"... using <mark>goroutines</mark> and channels ..."`
+	Score   float64 `json:"score" example:"4.2"` // Higher is more relevant
+}
+
+// syncArticleSearchIndex refreshes an article's row in the full-text index from
+// the articles and tags tables. If the article no longer exists its row is removed.
+func syncArticleSearchIndex(ctx context.Context, tx *dbTx, articleID string) error {
+	if tx.dialect == dialectPostgres {
+		return syncPostgresSearchIndex(ctx, tx, articleID)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM articles_fts WHERE article_id = ?", articleID); err != nil {
+		return fmt.Errorf("failed to remove article from search index: %w", err)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO articles_fts(article_id, user_id, title, summary, body, tags)
+		SELECT a.id, a.user_id, a.title, COALESCE(a.summary, ''), a.body_text,
+			COALESCE((SELECT group_concat(t.name, ' ') FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE at.article_id = a.id), '')
+		FROM articles a WHERE a.id = ?`, articleID)
+	if err != nil {
+		return fmt.Errorf("failed to index article for search: %w", err)
+	}
+	return nil
+}
+
+// SearchArticles runs a full-text search over a user's articles, best matches first.
+//
+// The query supports "quoted phrases", prefix* terms and the AND, OR and NOT
+// operators; bare terms are combined with AND. Anything else is treated as
+// literal text, so user input can never produce a syntax error in the database.
+func (s *SQLStore) SearchArticles(ctx context.Context, userID, query string, limit int) ([]SearchResult, error) {
+	var rows *sql.Rows
+	if s.db.dialect == dialectPostgres {
+		tsQuery, err := buildTSQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		rows, err = s.db.QueryContext(ctx, postgresSearchQuery, postgresHeadlineOptions, tsQuery, userID, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search articles: %w", err)
+		}
+		return s.scanSearchResults(ctx, rows)
+	}
+
+	match, err := buildFTSQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// bm25 weights follow the column order: article_id, user_id, title, summary, body, tags
+	rows, err = s.db.QueryContext(ctx, `
+		SELECT `+articleColumns+`, m.snippet, m.score
+		FROM articles
+		JOIN (
+			SELECT article_id,
+				snippet(articles_fts, -1, ?, ?, '…', 24) AS snippet,
+				-bm25(articles_fts, 0, 0, 10.0, 4.0, 1.0, 6.0) AS score
+			FROM articles_fts
+			WHERE articles_fts MATCH ? AND user_id = ?
+		) m ON m.article_id = articles.id
+		ORDER BY m.score DESC
+		LIMIT ?`,
+		snippetMatchStart, snippetMatchEnd, match, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search articles: %w", err)
+	}
+	return s.scanSearchResults(ctx, rows)
+}
+
+// scanSearchResults reads rows selected with articleColumns, a snippet and a score.
+func (s *SQLStore) scanSearchResults(ctx context.Context, rows *sql.Rows) ([]SearchResult, error) {
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var r SearchResult
+		a, err := scanArticle(rows, &r.Snippet, &r.Score)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result row: %w", err)
+		}
+		r.Article = *a
+		r.Snippet = highlightSnippet(r.Snippet)
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating search result rows: %w", err)
+	}
+
+	articles := make([]Article, len(results))
+	for i := range results {
+		articles[i] = results[i].Article
+	}
+	if err := s.loadArticleTags(ctx, articles); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Tags = articles[i].Tags
+	}
+	return results, nil
+}
+
+// searchToken is a parsed element of a search query: a term, a phrase or an operator.
+type searchToken struct {
+	words  []string // One word for a term, several for a phrase; nil for an operator
+	prefix bool     // Term with a trailing '*'
+	op     string   // "AND", "OR" or "NOT"
+}
+
+// parseSearchQuery splits a user search query into terms, phrases and operators.
+// AND/OR/NOT are kept as operators only where they are syntactically valid,
+// and punctuation around terms is dropped.
+func parseSearchQuery(query string) ([]searchToken, error) {
+	var tokens []searchToken
+	lastIsTerm := false
+
+	addTerm := func(term string, prefix bool) {
+		term = strings.TrimFunc(term, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if term == "" {
+			return
+		}
+		tokens = append(tokens, searchToken{words: []string{term}, prefix: prefix})
+		lastIsTerm = true
+	}
+
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch {
+		case unicode.IsSpace(runes[i]):
+			i++
+
+		case runes[i] == '"':
+			// Quoted phrase; an unterminated quote runs to the end of the query
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			words := strings.Fields(string(runes[i+1 : min(end, len(runes))]))
+			if len(words) > 0 {
+				tokens = append(tokens, searchToken{words: words})
+				lastIsTerm = true
+			}
+			i = end + 1
+
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && runes[end] != '"' {
+				end++
+			}
+			word := string(runes[i:end])
+			i = end
+
+			if word == "AND" || word == "OR" || word == "NOT" {
+				// Operators must sit between two terms
+				if lastIsTerm {
+					tokens = append(tokens, searchToken{op: word})
+					lastIsTerm = false
+				}
+				continue
+			}
+			addTerm(strings.TrimSuffix(word, "*"), strings.HasSuffix(word, "*"))
+		}
+	}
+
+	// Drop a dangling operator at the end
+	if len(tokens) > 0 && !lastIsTerm {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+	return tokens, nil
+}
+
+// buildFTSQuery translates a user search query into a safe FTS5 MATCH expression.
+// Terms and phrases are quoted and a trailing '*' on a term becomes a prefix query.
+func buildFTSQuery(query string) (string, error) {
+	tokens, err := parseSearchQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, len(tokens))
+	for i, t := range tokens {
+		if t.op != "" {
+			parts[i] = t.op
+			continue
+		}
+		parts[i] = `"` + strings.ReplaceAll(strings.Join(t.words, " "), `"`, `""`) + `"`
+		if t.prefix {
+			parts[i] += "*"
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// highlightSnippet HTML-escapes a snippet and replaces the sentinels around its
+// matches with the highlight markers. The markers always pair up, even if the
+// text itself contains sentinel characters: an end without a start is dropped,
+// and a start without an end is closed at the end of the snippet.
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	var b strings.Builder
+	b.Grow(len(escaped))
+	open := false
+	for i := 0; i < len(escaped); i++ {
+		switch c := escaped[i]; {
+		case c == snippetMatchStart[0]:
+			if !open {
+				b.WriteString(SnippetHighlightStart)
+				open = true
+			}
+		case c == snippetMatchEnd[0]:
+			if open {
+				b.WriteString(SnippetHighlightEnd)
+				open = false
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if open {
+		b.WriteString(SnippetHighlightEnd)
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// postgresSearchQuery is the PostgreSQL version of the FTS5 search in SearchArticles.
+// article_search.document weights title as A, tags as B, summary as C and body as D.
+// The parameters are the ts_headline options, from postgresHeadlineOptions, the
+// tsquery, the user ID and the limit.
+const postgresSearchQuery = `
+	SELECT ` + articleColumns + `,
+		ts_headline('english', COALESCE(summary, '') || ' ' || body_text, m.query, ?),
+		m.score
+	FROM articles
+	JOIN (
+		SELECT s.article_id, q.query, ts_rank(s.document, q.query) AS score
+		FROM article_search s, to_tsquery('english', ?) AS q(query)
+		WHERE s.document @@ q.query AND s.user_id = ?
+	) m ON m.article_id = articles.id
+	ORDER BY m.score DESC
+	LIMIT ?`
+
+// postgresHeadlineOptions has ts_headline put the snippet sentinels around matches.
+const postgresHeadlineOptions = "StartSel=" + snippetMatchStart + ", StopSel=" + snippetMatchEnd + ", MaxWords=24, MinWords=12"
+
+// syncPostgresSearchIndex refreshes an article's row in article_search, the
+// PostgreSQL counterpart of the FTS5 articles_fts table.
+func syncPostgresSearchIndex(ctx context.Context, tx *dbTx, articleID string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_search WHERE article_id = ?", articleID); err != nil {
+		return fmt.Errorf("failed to remove article from search index: %w", err)
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO article_search(article_id, user_id, document)
+		SELECT a.id, a.user_id,
+			setweight(to_tsvector('english', a.title), 'A') ||
+			setweight(to_tsvector('english', COALESCE((SELECT string_agg(t.name, ' ') FROM article_tags at JOIN tags t ON t.id = at.tag_id WHERE at.article_id = a.id), '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE(a.summary, '')), 'C') ||
+			setweight(to_tsvector('english', a.body_text), 'D')
+		FROM articles a WHERE a.id = ?`, articleID)
+	if err != nil {
+		return fmt.Errorf("failed to index article for search: %w", err)
+	}
+	return nil
+}
+
+// buildTSQuery translates a user search query into a safe to_tsquery expression
+// with the same meaning as buildFTSQuery: bare terms are ANDed, phrases must
+// match in order and a trailing '*' on a term becomes a prefix match.
+func buildTSQuery(query string) (string, error) {
+	tokens, err := parseSearchQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	quote := func(word string) string {
+		word = strings.ReplaceAll(word, `\`, `\\`)
+		return "'" + strings.ReplaceAll(word, "'", "''") + "'"
+	}
+
+	var b strings.Builder
+	for i, t := range tokens {
+		switch {
+		case t.op == "AND":
+			b.WriteString(" & ")
+		case t.op == "OR":
+			b.WriteString(" | ")
+		case t.op == "NOT":
+			b.WriteString(" & !")
+		default:
+			if i > 0 && tokens[i-1].op == "" {
+				b.WriteString(" & ") // Adjacent terms are ANDed
+			}
+			quoted := make([]string, len(t.words))
+			for j, word := range t.words {
+				quoted[j] = quote(word)
+			}
+			if len(quoted) > 1 {
+				b.WriteString("(" + strings.Join(quoted, " <-> ") + ")")
+			} else {
+				b.WriteString(quoted[0])
+			}
+			if t.prefix {
+				b.WriteString(":*")
+			}
+		}
+	}
+	return b.String(), nil
+}
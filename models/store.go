@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrArticleNotFound is returned when an article doesn't exist or belongs to another user.
+	ErrArticleNotFound = errors.New("article not found or not owned by user")
+	// ErrUserNotFound is returned when no user has the requested username or ID.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUsernameTaken is returned when registering a username that already exists.
+	ErrUsernameTaken = errors.New("username already exists")
+	// ErrWebhookNotFound is returned when a webhook doesn't exist or belongs to another user.
+	ErrWebhookNotFound = errors.New("webhook not found or not owned by user")
+	// ErrFeedTokenNotFound is returned when a feed token doesn't exist or belongs to another user.
+	ErrFeedTokenNotFound = errors.New("feed token not found or not owned by user")
+	// ErrSubscriptionNotFound is returned when a subscription doesn't exist or belongs to another user.
+	ErrSubscriptionNotFound = errors.New("subscription not found or not owned by user")
+	// ErrSubscriptionExists is returned when subscribing to a feed the user already subscribes to.
+	ErrSubscriptionExists = errors.New("already subscribed to this feed")
+	// ErrProcessingJobActive is returned when enqueueing an article whose job is still pending or running.
+	ErrProcessingJobActive = errors.New("article is already being processed")
+)
+
+// ArticleStore persists articles together with their tags and search index.
+type ArticleStore interface {
+	// SaveArticle inserts a new article, or updates it if its ID is set.
+	SaveArticle(ctx context.Context, a *Article) error
+	// GetArticleByID returns nil without an error if the article doesn't exist.
+	GetArticleByID(ctx context.Context, id, userID string) (*Article, error)
+	ArticleURLExists(ctx context.Context, userID, url string) (bool, error)
+	GetArticlesByUserID(ctx context.Context, userID string, opts ArticleListOptions) ([]Article, string, error)
+	SearchArticles(ctx context.Context, userID, query string, limit int) ([]SearchResult, error)
+	GetTagsByUserID(ctx context.Context, userID string) ([]TagCount, error)
+	// UpdateArticleStatus reports whether the status changed; setting the
+	// current status again leaves the article alone.
+	UpdateArticleStatus(ctx context.Context, id, userID, newStatus string) (bool, error)
+	UpdateArticleTags(ctx context.Context, id, userID string, newTags []string) error
+	DeleteArticle(ctx context.Context, id, userID string) error
+}
+
+// JobStore persists the queue of background processing jobs.
+type JobStore interface {
+	EnqueueProcessingJob(ctx context.Context, articleID, userID string, maxAttempts int) error
+	// ClaimNextProcessingJob returns nil without an error if no job is due.
+	ClaimNextProcessingJob(ctx context.Context) (*ProcessingJob, error)
+	CompleteProcessingJob(ctx context.Context, articleID string) error
+	RetryProcessingJob(ctx context.Context, articleID, lastError string, nextRunAt time.Time) error
+	FailProcessingJob(ctx context.Context, articleID, lastError string) error
+	ReleaseProcessingJob(ctx context.Context, articleID string) error
+	RequeueRunningProcessingJobs(ctx context.Context) (int64, error)
+	GetOrphanedProcessingArticles(ctx context.Context) ([]Article, error)
+	// GetProcessingJobByArticleID returns nil without an error if the article has no job.
+	GetProcessingJobByArticleID(ctx context.Context, articleID, userID string) (*ProcessingJob, error)
+}
+
+// UserStore persists user accounts.
+type UserStore interface {
+	CreateUser(ctx context.Context, user *User) error
+	GetUserByUsername(ctx context.Context, username string) (*User, error)
+	GetUserByID(ctx context.Context, id string) (*User, error)
+	// SetUserWordsPerMinute also recalculates the reading time of the user's articles.
+	SetUserWordsPerMinute(ctx context.Context, userID string, wpm int) error
+}
+
+// TokenStore persists access token revocations and refresh tokens.
+type TokenStore interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	CreateRefreshToken(ctx context.Context, userID string, ttl time.Duration) (string, *RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, token string, ttl time.Duration) (string, *RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID, userID string) error
+	DeleteExpiredRefreshTokens(ctx context.Context) (int64, error)
+}
+
+// WebhookStore persists webhooks and the log of their deliveries, which is
+// also the queue of deliveries to send.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, w *Webhook) error
+	// GetWebhookByID returns nil without an error if the webhook doesn't exist.
+	GetWebhookByID(ctx context.Context, id, userID string) (*Webhook, error)
+	GetWebhooksByUserID(ctx context.Context, userID string) ([]Webhook, error)
+	UpdateWebhook(ctx context.Context, w *Webhook) error
+	DeleteWebhook(ctx context.Context, id, userID string) error
+	CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error
+	// ClaimNextWebhookDelivery returns nil without an error if no delivery is due.
+	ClaimNextWebhookDelivery(ctx context.Context) (*WebhookDelivery, error)
+	CompleteWebhookDelivery(ctx context.Context, id string, responseStatus int) error
+	RetryWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string, nextAttemptAt time.Time) error
+	FailWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string) error
+	RequeueSendingWebhookDeliveries(ctx context.Context) (int64, error)
+	GetWebhookDeliveries(ctx context.Context, webhookID, userID string, limit int) ([]WebhookDelivery, error)
+}
+
+// ImportStore persists imports of reading lists from other services.
+type ImportStore interface {
+	CreateImport(ctx context.Context, imp *Import, items []ImportItem) error
+	// GetImportByID returns nil without an error if the import doesn't exist.
+	GetImportByID(ctx context.Context, id, userID string) (*Import, error)
+	GetImportsByUserID(ctx context.Context, userID string) ([]Import, error)
+	GetRunningImports(ctx context.Context) ([]Import, error)
+	GetImportItems(ctx context.Context, id string) ([]ImportItem, error)
+	UpdateImport(ctx context.Context, imp *Import) error
+}
+
+// ArticleImageStore persists the copies of articles' lead images.
+type ArticleImageStore interface {
+	SaveArticleImage(ctx context.Context, img *ArticleImage) error
+	// GetArticleImage returns nil without an error if the article has no cached image.
+	GetArticleImage(ctx context.Context, articleID, userID string) (*ArticleImage, error)
+	DeleteArticleImage(ctx context.Context, articleID string) error
+}
+
+// FeedTokenStore persists the tokens that give feed readers access to users' feeds.
+type FeedTokenStore interface {
+	CreateFeedToken(ctx context.Context, userID, name string) (string, *FeedToken, error)
+	GetFeedTokensByUserID(ctx context.Context, userID string) ([]FeedToken, error)
+	// UseFeedToken returns nil without an error if the token doesn't exist.
+	UseFeedToken(ctx context.Context, token string) (*FeedToken, error)
+	DeleteFeedToken(ctx context.Context, id, userID string) error
+}
+
+// SubscriptionStore persists feed subscriptions and the entries they have seen.
+type SubscriptionStore interface {
+	CreateSubscription(ctx context.Context, sub *Subscription) error
+	// GetSubscriptionByID returns nil without an error if the subscription doesn't exist.
+	GetSubscriptionByID(ctx context.Context, id, userID string) (*Subscription, error)
+	GetSubscriptionsByUserID(ctx context.Context, userID string) ([]Subscription, error)
+	UpdateSubscription(ctx context.Context, sub *Subscription) error
+	DeleteSubscription(ctx context.Context, id, userID string) error
+	// ClaimNextSubscription returns nil without an error if no subscription is due.
+	ClaimNextSubscription(ctx context.Context, lease time.Duration) (*Subscription, error)
+	SaveSubscriptionPoll(ctx context.Context, sub *Subscription) error
+	SubscriptionEntrySeen(ctx context.Context, subscriptionID, entryKey string) (bool, error)
+	MarkSubscriptionEntrySeen(ctx context.Context, subscriptionID, entryKey string) error
+}
+
+// Store is a complete storage backend.
+type Store interface {
+	ArticleStore
+	JobStore
+	UserStore
+	TokenStore
+	WebhookStore
+	ImportStore
+	ArticleImageStore
+	FeedTokenStore
+	SubscriptionStore
+	Close() error
+}
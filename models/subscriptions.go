@@ -0,0 +1,241 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Subscription is a feed whose new entries are added to the user's reading list.
+type Subscription struct {
+	ID       string   `json:"id"`
+	UserID   string   `json:"user_id"`
+	URL      string   `json:"url" example:"https://example.com/feed.xml"`
+	Title    string   `json:"title" example:"Example Blog"` // The feed's title unless the user sets one
+	Tags     []string `json:"tags"`                         // Given to the articles added
+	Keywords []string `json:"keywords"`                     // When not empty, only entries mentioning one are added
+	Active   bool     `json:"active"`
+
+	// Validators of the feed's last response, sent back to only download it when it changed
+	ETag         string `json:"-"`
+	LastModified string `json:"-"`
+
+	LastPolledAt *time.Time `json:"last_polled_at,omitempty"` // Last successful check; nil until the first
+	NextPollAt   time.Time  `json:"next_poll_at"`
+	LastError    string     `json:"last_error,omitempty"` // Why the checks since the last successful one failed
+	ErrorCount   int        `json:"error_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+const subscriptionColumns = "id, user_id, url, title, tags, keywords, active, etag, last_modified, " +
+	"last_polled_at, next_poll_at, last_error, error_count, created_at, updated_at"
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (*Subscription, error) {
+	sub := &Subscription{}
+	var tags, keywords string
+	var lastPolledAt sql.NullTime
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.URL, &sub.Title, &tags, &keywords, &sub.Active, &sub.ETag, &sub.LastModified,
+		&lastPolledAt, &sub.NextPollAt, &sub.LastError, &sub.ErrorCount, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &sub.Tags); err != nil {
+		return nil, fmt.Errorf("failed to decode subscription tags: %w", err)
+	}
+	if err := json.Unmarshal([]byte(keywords), &sub.Keywords); err != nil {
+		return nil, fmt.Errorf("failed to decode subscription keywords: %w", err)
+	}
+	if lastPolledAt.Valid {
+		sub.LastPolledAt = &lastPolledAt.Time
+	}
+	return sub, nil
+}
+
+// encodeStringList encodes a list for a JSON column, as [] when it is empty.
+func encodeStringList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	encoded, _ := json.Marshal(list) // Marshaling strings can't fail
+	return string(encoded)
+}
+
+// CreateSubscription stores a new subscription, due to be checked right away.
+func (s *SQLStore) CreateSubscription(ctx context.Context, sub *Subscription) error {
+	sub.ID = GenerateUUID()
+	sub.Tags = NormalizeTags(sub.Tags)
+	if sub.Keywords == nil {
+		sub.Keywords = []string{}
+	}
+	sub.CreatedAt = time.Now().UTC()
+	sub.UpdatedAt = sub.CreatedAt
+	sub.NextPollAt = sub.CreatedAt
+	_, err := s.db.ExecContext(ctx, `INSERT INTO subscriptions(id, user_id, url, title, tags, keywords, active,
+		next_poll_at, created_at, updated_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.ID, sub.UserID, sub.URL, sub.Title, encodeStringList(sub.Tags), encodeStringList(sub.Keywords), sub.Active,
+		sub.NextPollAt, sub.CreatedAt, sub.UpdatedAt)
+	if err != nil {
+		if s.db.dialect.isUniqueViolation(err) {
+			return fmt.Errorf("subscription to '%s': %w", sub.URL, ErrSubscriptionExists)
+		}
+		return fmt.Errorf("failed to insert subscription: %w", err)
+	}
+	return nil
+}
+
+// GetSubscriptionByID retrieves a subscription owned by the given user.
+func (s *SQLStore) GetSubscriptionByID(ctx context.Context, id, userID string) (*Subscription, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = ? AND user_id = ?", id, userID)
+	sub, err := scanSubscription(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Subscription not found
+		}
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// GetSubscriptionsByUserID retrieves a user's subscriptions, oldest first.
+func (s *SQLStore) GetSubscriptionsByUserID(ctx context.Context, userID string) ([]Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM subscriptions WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan subscription row: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating subscription rows: %w", err)
+	}
+	return subs, nil
+}
+
+// UpdateSubscription saves the settings a user can change: the title, tags,
+// keywords and whether the subscription is active.
+func (s *SQLStore) UpdateSubscription(ctx context.Context, sub *Subscription) error {
+	sub.Tags = NormalizeTags(sub.Tags)
+	if sub.Keywords == nil {
+		sub.Keywords = []string{}
+	}
+	sub.UpdatedAt = time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, "UPDATE subscriptions SET title=?, tags=?, keywords=?, active=?, updated_at=? WHERE id=? AND user_id=?",
+		sub.Title, encodeStringList(sub.Tags), encodeStringList(sub.Keywords), sub.Active, sub.UpdatedAt, sub.ID, sub.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update subscription: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("subscription with ID '%s': %w", sub.ID, ErrSubscriptionNotFound)
+	}
+	return nil
+}
+
+// DeleteSubscription deletes a subscription and the record of the entries it has seen.
+func (s *SQLStore) DeleteSubscription(ctx context.Context, id, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin subscription delete transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM subscriptions WHERE id=? AND user_id=?", id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("subscription with ID '%s': %w", id, ErrSubscriptionNotFound)
+	}
+
+	// SQLite only cascades with foreign keys enabled, so don't rely on it
+	if _, err = tx.ExecContext(ctx, "DELETE FROM subscription_entries WHERE subscription_id=?", id); err != nil {
+		return fmt.Errorf("failed to delete subscription entries: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit subscription deletion: %w", err)
+	}
+	return nil
+}
+
+// ClaimNextSubscription returns the active subscription whose check is the
+// most overdue, postponing its next check by lease so no other poller takes
+// it meanwhile. It returns nil if no check is due.
+func (s *SQLStore) ClaimNextSubscription(ctx context.Context, lease time.Duration) (*Subscription, error) {
+	now := time.Now().UTC()
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE subscriptions SET next_poll_at=?
+		WHERE id = (
+			SELECT id FROM subscriptions
+			WHERE active=? AND next_poll_at <= ?
+			ORDER BY next_poll_at LIMIT 1`+s.db.dialect.skipLocked()+`
+		) AND next_poll_at <= ?
+		RETURNING `+subscriptionColumns,
+		now.Add(lease), true, now, now)
+	sub, err := scanSubscription(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Nothing to do
+		}
+		return nil, fmt.Errorf("failed to claim subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// SaveSubscriptionPoll saves the outcome of checking a subscription's feed:
+// its validators, when it was checked, the error if it failed and when to
+// check it next. The title is only saved if the subscription has none, so a
+// title the user set meanwhile is kept.
+func (s *SQLStore) SaveSubscriptionPoll(ctx context.Context, sub *Subscription) error {
+	sub.UpdatedAt = time.Now().UTC()
+	_, err := s.db.ExecContext(ctx, `UPDATE subscriptions SET title=CASE WHEN title='' THEN ? ELSE title END, etag=?, last_modified=?, last_polled_at=?,
+		next_poll_at=?, last_error=?, error_count=?, updated_at=? WHERE id=?`,
+		sub.Title, sub.ETag, sub.LastModified, sub.LastPolledAt, sub.NextPollAt.UTC(), sub.LastError, sub.ErrorCount,
+		sub.UpdatedAt, sub.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save subscription poll: %w", err)
+	}
+	return nil
+}
+
+// SubscriptionEntrySeen reports whether a subscription has seen an entry.
+func (s *SQLStore) SubscriptionEntrySeen(ctx context.Context, subscriptionID, entryKey string) (bool, error) {
+	var seen int
+	err := s.db.QueryRowContext(ctx, "SELECT 1 FROM subscription_entries WHERE subscription_id = ? AND entry_key = ?",
+		subscriptionID, entryKey).Scan(&seen)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to look up subscription entry: %w", err)
+	}
+	return true, nil
+}
+
+// MarkSubscriptionEntrySeen records that a subscription has seen an entry, so
+// it isn't added again.
+func (s *SQLStore) MarkSubscriptionEntrySeen(ctx context.Context, subscriptionID, entryKey string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO subscription_entries(subscription_id, entry_key, created_at) VALUES(?, ?, ?)
+		ON CONFLICT(subscription_id, entry_key) DO NOTHING`, subscriptionID, entryKey, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record subscription entry: %w", err)
+	}
+	return nil
+}
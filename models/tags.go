@@ -0,0 +1,230 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TagCount is a tag together with the number of a user's articles that carry it.
+type TagCount struct {
+	Name  string `json:"name" example:"golang"`
+	Count int    `json:"count" example:"3"`
+}
+
+// NormalizeTag lowercases a tag, strips list/markdown decoration that LLMs like to
+// add (quotes, '#', '*', '-') and collapses internal whitespace to single spaces.
+func NormalizeTag(tag string) string {
+	tag = strings.TrimSpace(tag)
+	tag = strings.Trim(tag, "\"'`#*-•. ")
+	tag = strings.Join(strings.Fields(tag), " ")
+	return strings.ToLower(tag)
+}
+
+// NormalizeTags normalizes every tag, dropping empty values and duplicates while
+// keeping the original order. It never returns nil so tags encode as [] in JSON.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if _, dup := seen[tag]; dup {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// intersectTags normalizes tags and keeps the ones that are also in of, which
+// must already be normalized.
+func intersectTags(tags, of []string) []string {
+	kept := []string{}
+	for _, tag := range NormalizeTags(tags) {
+		if containsString(of, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// SetAutoTags replaces the tags processing added to the article with tags, and
+// keeps its other tags, such as imported or user-set ones. A new tag the article
+// already has otherwise stays the user's.
+func (a *Article) SetAutoTags(tags []string) {
+	kept := []string{}
+	for _, tag := range NormalizeTags(a.Tags) {
+		if !containsString(a.AutoTags, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	a.AutoTags = []string{}
+	for _, tag := range NormalizeTags(tags) {
+		if !containsString(kept, tag) {
+			a.AutoTags = append(a.AutoTags, tag)
+		}
+	}
+	a.Tags = append(kept, a.AutoTags...)
+}
+
+// splitLegacyTags parses the comma-separated representation used by the old articles.tags column.
+func splitLegacyTags(tagsStr string) []string {
+	if strings.TrimSpace(tagsStr) == "" {
+		return []string{}
+	}
+	return NormalizeTags(strings.Split(tagsStr, ","))
+}
+
+// replaceArticleTags sets the tags of an article, creating tags that don't exist yet,
+// and marks the ones in autoTags as added by processing. The tags must already be
+// normalized.
+func replaceArticleTags(ctx context.Context, tx *dbTx, articleID string, tags, autoTags []string) error {
+	unlinked, err := unlinkArticleTags(ctx, tx, articleID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range tags {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO tags(id, name) VALUES(?, ?) ON CONFLICT(name) DO NOTHING", GenerateUUID(), name); err != nil {
+			return fmt.Errorf("failed to insert tag %q: %w", name, err)
+		}
+		var tagID string
+		if err := tx.QueryRowContext(ctx, "SELECT id FROM tags WHERE name = ?", name).Scan(&tagID); err != nil {
+			return fmt.Errorf("failed to look up tag %q: %w", name, err)
+		}
+		_, err := tx.ExecContext(ctx, "INSERT INTO article_tags(article_id, tag_id, auto) VALUES(?, ?, ?)", articleID, tagID, containsString(autoTags, name))
+		if err != nil {
+			return fmt.Errorf("failed to link tag %q to article: %w", name, err)
+		}
+	}
+
+	return pruneUnusedTags(ctx, tx, unlinked)
+}
+
+// unlinkArticleTags removes the links between an article and its tags, and
+// returns the IDs of the tags that were linked.
+func unlinkArticleTags(ctx context.Context, tx *dbTx, articleID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT tag_id FROM article_tags WHERE article_id = ?", articleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query article tags: %w", err)
+	}
+	var tagIDs []string
+	for rows.Next() {
+		var tagID string
+		if err := rows.Scan(&tagID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan article tag row: %w", err)
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating article tag rows: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_tags WHERE article_id = ?", articleID); err != nil {
+		return nil, fmt.Errorf("failed to clear article tags: %w", err)
+	}
+	return tagIDs, nil
+}
+
+// pruneUnusedTags deletes those of the given tags that no article uses anymore.
+// Only the tags this transaction unlinked are candidates, so tags other
+// transactions are linking at the same time are left alone.
+func pruneUnusedTags(ctx context.Context, tx *dbTx, tagIDs []string) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(tagIDs))
+	args := make([]interface{}, len(tagIDs))
+	for i, id := range tagIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	_, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id IN (`+strings.Join(placeholders, ", ")+`)
+		AND NOT EXISTS (SELECT 1 FROM article_tags WHERE article_tags.tag_id = tags.id)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to prune unused tags: %w", err)
+	}
+	return nil
+}
+
+// loadArticleTags fills in the Tags and AutoTags fields of each article from the
+// article_tags table.
+func (s *SQLStore) loadArticleTags(ctx context.Context, articles []Article) error {
+	if len(articles) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(articles))
+	args := make([]interface{}, len(articles))
+	byID := make(map[string]*Article, len(articles))
+	for i := range articles {
+		placeholders[i] = "?"
+		args[i] = articles[i].ID
+		articles[i].Tags = []string{}
+		articles[i].AutoTags = []string{}
+		byID[articles[i].ID] = &articles[i]
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT at.article_id, t.name, at.auto FROM article_tags at
+		JOIN tags t ON t.id = at.tag_id
+		WHERE at.article_id IN (`+strings.Join(placeholders, ", ")+`)
+		ORDER BY t.name`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query article tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var articleID, name string
+		var auto bool
+		if err := rows.Scan(&articleID, &name, &auto); err != nil {
+			return fmt.Errorf("failed to scan article tag row: %w", err)
+		}
+		if a, ok := byID[articleID]; ok {
+			a.Tags = append(a.Tags, name)
+			if auto {
+				a.AutoTags = append(a.AutoTags, name)
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating article tag rows: %w", err)
+	}
+	return nil
+}
+
+// GetTagsByUserID returns every tag used by a user's articles with its article count,
+// most used first.
+func (s *SQLStore) GetTagsByUserID(ctx context.Context, userID string) ([]TagCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.name, COUNT(*) FROM tags t
+		JOIN article_tags at ON at.tag_id = t.id
+		JOIN articles a ON a.id = at.article_id
+		WHERE a.user_id = ?
+		GROUP BY t.name
+		ORDER BY COUNT(*) DESC, t.name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tag rows: %w", err)
+	}
+	return tags, nil
+}
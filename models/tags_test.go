@@ -0,0 +1,127 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetTagsByUserID(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		other := createUser(t, s, "other")
+		saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/1", Status: "processed", Tags: []string{"Go", "databases"}})
+		saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/2", Status: "processed", Tags: []string{"go", "testing"}})
+		third := saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/3", Status: "processed", Tags: []string{"go"}})
+		saveArticle(t, s, &Article{UserID: other.ID, URL: "https://example.com/1", Status: "processed", Tags: []string{"testing", "rust"}})
+
+		want := []TagCount{{Name: "go", Count: 3}, {Name: "databases", Count: 1}, {Name: "testing", Count: 1}}
+		got, err := s.GetTagsByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetTagsByUserID() = %v, want %v", got, want)
+		}
+
+		if err := s.UpdateArticleTags(ctx, third.ID, user.ID, []string{"testing"}); err != nil {
+			t.Fatal(err)
+		}
+		want = []TagCount{{Name: "go", Count: 2}, {Name: "testing", Count: 2}, {Name: "databases", Count: 1}}
+		got, err = s.GetTagsByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetTagsByUserID() after retagging = %v, want %v", got, want)
+		}
+
+		got, err = s.GetTagsByUserID(ctx, createUser(t, s, "new").ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 0 {
+			t.Errorf("GetTagsByUserID() of a user without articles = %v, want none", got)
+		}
+	})
+}
+
+func TestAutoTags(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		article := saveArticle(t, s, &Article{
+			UserID:   user.ID,
+			URL:      "https://example.com/a",
+			Status:   "processed",
+			Tags:     []string{"mine", "Go", "databases"},
+			AutoTags: []string{"go", "databases", "not a tag of the article"},
+		})
+
+		got, err := s.GetArticleByID(ctx, article.ID, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"databases", "go"}; !reflect.DeepEqual(got.AutoTags, want) {
+			t.Errorf("AutoTags = %v, want %v", got.AutoTags, want)
+		}
+
+		// Tags the user sets are theirs, even ones processing added before
+		if err := s.UpdateArticleTags(ctx, article.ID, user.ID, []string{"mine", "go"}); err != nil {
+			t.Fatal(err)
+		}
+		got, err = s.GetArticleByID(ctx, article.ID, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"go", "mine"}; !reflect.DeepEqual(got.Tags, want) || len(got.AutoTags) != 0 {
+			t.Errorf("after setting tags, tags = %v with auto tags %v; want %v with none", got.Tags, got.AutoTags, want)
+		}
+	})
+}
+
+func TestUnusedTagsArePruned(t *testing.T) {
+	forEachSQLStore(t, func(t *testing.T, s *SQLStore) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		first := saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/1", Status: "processed", Tags: []string{"shared", "first"}})
+		second := saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/2", Status: "processed", Tags: []string{"shared", "second"}})
+		// A tag no article uses, as left behind by earlier versions, isn't this transaction's to delete
+		if _, err := s.db.ExecContext(ctx, "INSERT INTO tags(id, name) VALUES(?, ?)", GenerateUUID(), "orphan"); err != nil {
+			t.Fatal(err)
+		}
+
+		tagNames := func() string {
+			t.Helper()
+			rows, err := s.db.QueryContext(ctx, "SELECT name FROM tags ORDER BY name")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			var names []string
+			for rows.Next() {
+				var name string
+				if err := rows.Scan(&name); err != nil {
+					t.Fatal(err)
+				}
+				names = append(names, name)
+			}
+			return strings.Join(names, " ")
+		}
+
+		if err := s.UpdateArticleTags(ctx, first.ID, user.ID, []string{"new"}); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := tagNames(), "new orphan second shared"; got != want {
+			t.Errorf("tags after retagging = %q, want %q", got, want)
+		}
+		if err := s.DeleteArticle(ctx, second.ID, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := tagNames(), "new orphan"; got != want {
+			t.Errorf("tags after deleting = %q, want %q", got, want)
+		}
+	})
+}
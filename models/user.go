@@ -2,9 +2,10 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt" // For password hashing
@@ -12,10 +13,11 @@ import (
 
 // User represents a user in the system.
 type User struct {
-	ID           string    `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"` // Don't expose this in JSON
-	CreatedAt    time.Time `json:"created_at"`
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	PasswordHash   string    `json:"-"`                              // Don't expose this in JSON
+	WordsPerMinute int       `json:"words_per_minute" example:"238"` // Reading speed used for reading time estimates
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 // HashPassword hashes the user's plain-text password using bcrypt.
@@ -35,7 +37,7 @@ func (u *User) CheckPasswordHash(password string) bool {
 }
 
 // CreateUser inserts a new user into the database.
-func CreateUser(user *User) error {
+func (s *SQLStore) CreateUser(ctx context.Context, user *User) error {
 	// Assign a new UUID if one isn't already set (e.g., from an external source)
 	if user.ID == "" {
 		user.ID = GenerateUUID()
@@ -44,35 +46,35 @@ func CreateUser(user *User) error {
 	if user.CreatedAt.IsZero() {
 		user.CreatedAt = time.Now()
 	}
-	stmt, err := DB.Prepare("INSERT INTO users(id, username, password_hash, created_at) VALUES(?, ?, ?, ?)")
+	if user.WordsPerMinute == 0 {
+		user.WordsPerMinute = DefaultWordsPerMinute
+	}
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO users(id, username, password_hash, words_per_minute, created_at) VALUES(?, ?, ?, ?, ?)")
 	if err != nil {
 		return fmt.Errorf("failed to prepare user insert statement: %w", err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(user.ID, user.Username, user.PasswordHash, user.CreatedAt)
+	_, err = stmt.ExecContext(ctx, user.ID, user.Username, user.PasswordHash, user.WordsPerMinute, user.CreatedAt)
 	if err != nil {
-		// Specific error handling for sqlite3 unique constraint violation
+		// Specific error handling for unique constraint violation
 		// (e.g., if username already exists)
-		// For sqlite3, the error message often contains "UNIQUE constraint failed"
-		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			return fmt.Errorf("username '%s' already exists", user.Username)
+		if s.db.dialect.isUniqueViolation(err) {
+			return fmt.Errorf("username '%s': %w", user.Username, ErrUsernameTaken)
 		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 	return nil
 }
 
-// AuthenticateUser checks if the provided username and password match a user in the database.
-func AuthenticateUser(username, password string) (*User, error) {
-	user := &User{}
-	row := DB.QueryRow("SELECT id, username, password_hash, created_at FROM users WHERE username = ?", username)
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+// AuthenticateUser checks if the provided username and password match a user in the store.
+func AuthenticateUser(ctx context.Context, users UserStore, username, password string) (*User, error) {
+	user, err := users.GetUserByUsername(ctx, username)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, ErrUserNotFound) {
 			return nil, fmt.Errorf("invalid username or password")
 		}
-		return nil, fmt.Errorf("failed to get user by username: %w", err)
+		return nil, err
 	}
 
 	// Check if the provided password matches the stored hash
@@ -84,15 +86,61 @@ func AuthenticateUser(username, password string) (*User, error) {
 }
 
 // GetUserByUsername retrieves a user by their username.
-func GetUserByUsername(username string) (*User, error) {
+func (s *SQLStore) GetUserByUsername(ctx context.Context, username string) (*User, error) {
 	user := &User{}
-	row := DB.QueryRow("SELECT id, username, password_hash, created_at FROM users WHERE username = ?", username)
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	row := s.db.QueryRowContext(ctx, "SELECT id, username, password_hash, words_per_minute, created_at FROM users WHERE username = ?", username)
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.WordsPerMinute, &user.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, sql.ErrNoRows
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to get user by username: %w", err)
 	}
 	return user, nil
 }
+
+// GetUserByID retrieves a user by their ID.
+func (s *SQLStore) GetUserByID(ctx context.Context, id string) (*User, error) {
+	user := &User{}
+	row := s.db.QueryRowContext(ctx, "SELECT id, username, password_hash, words_per_minute, created_at FROM users WHERE id = ?", id)
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.WordsPerMinute, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+	return user, nil
+}
+
+// SetUserWordsPerMinute changes a user's reading speed and recalculates the
+// reading time of their articles.
+func (s *SQLStore) SetUserWordsPerMinute(ctx context.Context, userID string, wpm int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin reading speed transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE users SET words_per_minute = ? WHERE id = ?", wpm, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update reading speed: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	// Same rounding as ReadingMinutes
+	_, err = tx.ExecContext(ctx, "UPDATE articles SET reading_minutes = (word_count + ? - 1) / ? WHERE user_id = ?", wpm, wpm, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update reading times: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reading speed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCreateUser(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := &User{Username: "reader"}
+		if err := user.HashPassword("secret"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.CreateUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		if user.ID == "" || user.CreatedAt.IsZero() {
+			t.Errorf("created user %+v, want an ID and a creation time", user)
+		}
+		if user.WordsPerMinute != DefaultWordsPerMinute {
+			t.Errorf("WordsPerMinute = %d, want the default %d", user.WordsPerMinute, DefaultWordsPerMinute)
+		}
+
+		byName, err := s.GetUserByUsername(ctx, "reader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID, err := s.GetUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range []*User{byName, byID} {
+			if got.ID != user.ID || got.Username != "reader" || got.WordsPerMinute != DefaultWordsPerMinute {
+				t.Errorf("got user %+v, want %+v", got, user)
+			}
+			if !got.CheckPasswordHash("secret") {
+				t.Errorf("stored password hash doesn't match the password")
+			}
+		}
+
+		if _, err := AuthenticateUser(ctx, s, "reader", "secret"); err != nil {
+			t.Errorf("AuthenticateUser with the right password: %v", err)
+		}
+		if _, err := AuthenticateUser(ctx, s, "reader", "wrong"); err == nil {
+			t.Errorf("AuthenticateUser with the wrong password succeeded")
+		}
+	})
+}
+
+func TestCreateUserTakenUsername(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		createUser(t, s, "reader")
+		err := s.CreateUser(context.Background(), &User{Username: "reader", PasswordHash: "not-a-hash"})
+		if !errors.Is(err, ErrUsernameTaken) {
+			t.Errorf("creating a second user named reader: got %v, want ErrUsernameTaken", err)
+		}
+	})
+}
+
+func TestGetUserNotFound(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		if _, err := s.GetUserByUsername(ctx, "nobody"); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetUserByUsername: got %v, want ErrUserNotFound", err)
+		}
+		if _, err := s.GetUserByID(ctx, GenerateUUID()); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("GetUserByID: got %v, want ErrUserNotFound", err)
+		}
+		if err := s.SetUserWordsPerMinute(ctx, GenerateUUID(), 300); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("SetUserWordsPerMinute: got %v, want ErrUserNotFound", err)
+		}
+	})
+}
+
+func TestSetUserWordsPerMinute(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		user := createUser(t, s, "reader")
+		article := saveArticle(t, s, &Article{UserID: user.ID, URL: "https://example.com/a", Status: "processed", WordCount: 1000})
+		if article.ReadingMinutes != ReadingMinutes(1000, DefaultWordsPerMinute) {
+			t.Fatalf("ReadingMinutes = %d, want %d", article.ReadingMinutes, ReadingMinutes(1000, DefaultWordsPerMinute))
+		}
+
+		if err := s.SetUserWordsPerMinute(ctx, user.ID, 100); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.GetUserByID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.WordsPerMinute != 100 {
+			t.Errorf("WordsPerMinute = %d, want 100", got.WordsPerMinute)
+		}
+		a, err := s.GetArticleByID(ctx, article.ID, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.ReadingMinutes != 10 {
+			t.Errorf("ReadingMinutes after the change = %d, want 10", a.ReadingMinutes)
+		}
+	})
+}
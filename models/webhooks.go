@@ -0,0 +1,311 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook event types.
+const (
+	WebhookEventArticleCreated       = "article.created"
+	WebhookEventArticleProcessed     = "article.processed"
+	WebhookEventArticleFailed        = "article.failed"
+	WebhookEventArticleStatusChanged = "article.status_changed"
+	WebhookEventArticleDeleted       = "article.deleted"
+	// WebhookEventTest is only sent by the test endpoint, whatever the webhook's events.
+	WebhookEventTest = "webhook.test"
+)
+
+// WebhookEvents lists the events webhooks can subscribe to.
+var WebhookEvents = []string{
+	WebhookEventArticleCreated,
+	WebhookEventArticleProcessed,
+	WebhookEventArticleFailed,
+	WebhookEventArticleStatusChanged,
+	WebhookEventArticleDeleted,
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSending   = "sending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// Webhook is an endpoint that receives a user's article events.
+type Webhook struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`      // Signs deliveries; only returned when the webhook is created
+	Events    []string  `json:"events"` // Empty for every event
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook should receive an event.
+func (w *Webhook) Subscribes(event string) bool {
+	return w.Active && (len(w.Events) == 0 || containsString(w.Events, event))
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook. Failed
+// attempts are retried until MaxAttempts.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	UserID         string          `json:"user_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"` // "pending", "sending", "succeeded" or "failed"
+	Attempts       int             `json:"attempts"`
+	MaxAttempts    int             `json:"max_attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"` // HTTP status of the last attempt
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+const webhookColumns = "id, user_id, url, secret, events, active, created_at, updated_at"
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*Webhook, error) {
+	w := &Webhook{}
+	var events string
+	if err := row.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &events, &w.Active, &w.CreatedAt, &w.UpdatedAt); err != nil {
+		return nil, err
+	}
+	w.Events = splitWebhookEvents(events)
+	return w, nil
+}
+
+func splitWebhookEvents(events string) []string {
+	if events == "" {
+		return []string{}
+	}
+	return strings.Split(events, ",")
+}
+
+const webhookDeliveryColumns = "id, webhook_id, user_id, event, payload, status, attempts, max_attempts, " +
+	"response_status, last_error, next_attempt_at, created_at, updated_at"
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*WebhookDelivery, error) {
+	d := &WebhookDelivery{}
+	var payload string
+	err := row.Scan(&d.ID, &d.WebhookID, &d.UserID, &d.Event, &payload, &d.Status, &d.Attempts, &d.MaxAttempts,
+		&d.ResponseStatus, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = json.RawMessage(payload)
+	return d, nil
+}
+
+// CreateWebhook stores a new webhook.
+func (s *SQLStore) CreateWebhook(ctx context.Context, w *Webhook) error {
+	w.ID = GenerateUUID()
+	w.CreatedAt = time.Now().UTC()
+	w.UpdatedAt = w.CreatedAt
+	_, err := s.db.ExecContext(ctx, "INSERT INTO webhooks("+webhookColumns+") VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+		w.ID, w.UserID, w.URL, w.Secret, strings.Join(w.Events, ","), w.Active, w.CreatedAt, w.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookByID retrieves a webhook owned by the given user.
+func (s *SQLStore) GetWebhookByID(ctx context.Context, id, userID string) (*Webhook, error) {
+	row := s.db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ? AND user_id = ?", id, userID)
+	w, err := scanWebhook(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Webhook not found
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return w, nil
+}
+
+// GetWebhooksByUserID retrieves all of a user's webhooks, oldest first.
+func (s *SQLStore) GetWebhooksByUserID(ctx context.Context, userID string) ([]Webhook, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook row: %w", err)
+		}
+		webhooks = append(webhooks, *w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook rows: %w", err)
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook saves a webhook's URL, secret, events and active flag.
+func (s *SQLStore) UpdateWebhook(ctx context.Context, w *Webhook) error {
+	w.UpdatedAt = time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, "UPDATE webhooks SET url=?, secret=?, events=?, active=?, updated_at=? WHERE id=? AND user_id=?",
+		w.URL, w.Secret, strings.Join(w.Events, ","), w.Active, w.UpdatedAt, w.ID, w.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook with ID '%s': %w", w.ID, ErrWebhookNotFound)
+	}
+	return nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func (s *SQLStore) DeleteWebhook(ctx context.Context, id, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin webhook delete transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id=? AND user_id=?", id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook with ID '%s': %w", id, ErrWebhookNotFound)
+	}
+
+	// SQLite only cascades with foreign keys enabled, so don't rely on it
+	if _, err = tx.ExecContext(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id=?", id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook deletion: %w", err)
+	}
+	return nil
+}
+
+// CreateWebhookDelivery stores a delivery. A pending delivery is picked up by
+// ClaimNextWebhookDelivery once its next attempt is due.
+func (s *SQLStore) CreateWebhookDelivery(ctx context.Context, d *WebhookDelivery) error {
+	if d.ID == "" {
+		d.ID = GenerateUUID()
+	}
+	d.CreatedAt = time.Now().UTC()
+	d.UpdatedAt = d.CreatedAt
+	if d.NextAttemptAt.IsZero() {
+		d.NextAttemptAt = d.CreatedAt
+	}
+	_, err := s.db.ExecContext(ctx, "INSERT INTO webhook_deliveries("+webhookDeliveryColumns+") VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		d.ID, d.WebhookID, d.UserID, d.Event, string(d.Payload), d.Status, d.Attempts, d.MaxAttempts,
+		d.ResponseStatus, d.LastError, d.NextAttemptAt.UTC(), d.CreatedAt, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimNextWebhookDelivery atomically marks the next due pending delivery as
+// sending and returns it. It returns nil if no delivery is due.
+func (s *SQLStore) ClaimNextWebhookDelivery(ctx context.Context) (*WebhookDelivery, error) {
+	now := time.Now().UTC()
+	row := s.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries SET status=?, attempts=attempts+1, updated_at=?
+		WHERE id = (
+			SELECT id FROM webhook_deliveries
+			WHERE status=? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at LIMIT 1`+s.db.dialect.skipLocked()+`
+		) AND status=?
+		RETURNING `+webhookDeliveryColumns,
+		DeliveryStatusSending, now, DeliveryStatusPending, now, DeliveryStatusPending)
+	d, err := scanWebhookDelivery(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Nothing to do
+		}
+		return nil, fmt.Errorf("failed to claim webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// CompleteWebhookDelivery marks a delivery as succeeded.
+func (s *SQLStore) CompleteWebhookDelivery(ctx context.Context, id string, responseStatus int) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, response_status=?, last_error='', updated_at=? WHERE id=?",
+		DeliveryStatusSucceeded, responseStatus, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to complete webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// RetryWebhookDelivery records a failed attempt and schedules the next one at nextAttemptAt.
+func (s *SQLStore) RetryWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string, nextAttemptAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, response_status=?, last_error=?, next_attempt_at=?, updated_at=? WHERE id=?",
+		DeliveryStatusPending, responseStatus, lastError, nextAttemptAt.UTC(), time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// FailWebhookDelivery marks a delivery as permanently failed.
+func (s *SQLStore) FailWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, response_status=?, last_error=?, updated_at=? WHERE id=?",
+		DeliveryStatusFailed, responseStatus, lastError, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery as failed: %w", err)
+	}
+	return nil
+}
+
+// RequeueSendingWebhookDeliveries puts deliveries left in "sending" by a previous process back in the queue.
+func (s *SQLStore) RequeueSendingWebhookDeliveries(ctx context.Context) (int64, error) {
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx, "UPDATE webhook_deliveries SET status=?, next_attempt_at=?, updated_at=? WHERE status=?",
+		DeliveryStatusPending, now, now, DeliveryStatusSending)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetWebhookDeliveries retrieves a webhook's most recent deliveries, newest first.
+func (s *SQLStore) GetWebhookDeliveries(ctx context.Context, webhookID, userID string, limit int) ([]WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT "+webhookDeliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = ? AND user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`, webhookID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook delivery rows: %w", err)
+	}
+	return deliveries, nil
+}
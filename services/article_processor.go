@@ -2,112 +2,121 @@ package services
 
 import (
 	"context"
-	"io"
+	"errors"
+	"fmt"
 	"log"
-	"net/http"
-	"os"
-	"strings"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/jeana-hines/personal-reading-list-api/models"
-	"google.golang.org/genai"
 )
 
-// ArticleProcessor handles the processing of articles, including summarization and tagging.
-func ProcessNewArticle(article *models.Article) {
+// Processing stages, recorded on articles that fail to process.
+const (
+	stageFetch     = "fetch"
+	stageParse     = "parse"
+	stageSummarize = "summarize"
+	stageTag       = "tag"
+	stageSave      = "save"
+)
+
+// stageError is an error from one stage of processing an article.
+type stageError struct {
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string { return e.err.Error() }
+func (e *stageError) Unwrap() error { return e.err }
+
+func stageErrorf(stage, format string, args ...interface{}) error {
+	return &stageError{stage: stage, err: fmt.Errorf(format, args...)}
+}
+
+// failureStage returns the stage an error from ProcessNewArticle occurred in.
+func failureStage(err error) string {
+	var se *stageError
+	if errors.As(err, &se) {
+		return se.stage
+	}
+	return ""
+}
+
+// ArticleProcessStore is the storage processing needs: the articles and the
+// copies of their lead images.
+type ArticleProcessStore interface {
+	models.ArticleStore
+	models.ArticleImageStore
+}
+
+// ProcessNewArticle fetches, summarizes and tags an article.
+// It returns an error if any step fails so the caller can retry the job.
+func ProcessNewArticle(ctx context.Context, articles ArticleProcessStore, article *models.Article) error {
 	log.Printf("Starting background processing for article ID: %s", article.ID)
 
 	// 1. Fetch the content
-	fullContent, err := http.Get(article.URL)
+	fullContent, err := fetcher.Fetch(ctx, article.URL)
 	if err != nil {
-		log.Printf("Failed to fetch content for article %s: %v", article.ID, err)
-		// You might want to update the article status to "failed" here
-		article.Status = "failed"
-		err = article.Save()
-		if err != nil {
-			log.Printf("Failed to update article status to 'failed' for article %s: %v", article.ID, err)
-		}
-		// Exit the function early if fetching content fails
-		return
+		return stageErrorf(stageFetch, "failed to fetch content for article %s: %w", article.ID, err)
 	}
-	defer fullContent.Body.Close()
-	if fullContent.StatusCode != http.StatusOK {
-		log.Printf("Failed to fetch content for article %s: HTTP %d", article.ID, fullContent.StatusCode)
-		return
-	}
-	body, err := io.ReadAll(fullContent.Body)
-	if err != nil {
-		log.Printf("Failed to read content for article %s: %v", article.ID, err)
-		return
-
-	}
-	// Parse the content with goquery
-
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	publishArticleEvent(models.EventArticleFetched, article)
+	// Decode the document by content type, extracting the title, metadata and main
+	// content without navigation, ads and other boilerplate
+	parsed, err := parseArticle(ctx, fullContent)
 	if err != nil {
-		log.Printf("Failed to parse content for article %s: %v", article.ID, err)
-		return
+		return stageErrorf(stageParse, "failed to parse content for article %s: %w", article.ID, err)
 	}
-	// Extract the title and body text
-	title := doc.Find("title").Text()
-	bodyText := doc.Find("body").Text()
+	title := parsed.Title
 	if title == "" {
 		log.Printf("No title found for article %s, using URL as title", article.ID)
 		title = article.URL
 	}
 	article.Title = title
-	article.URL = fullContent.Request.URL.String() // Normalize URL
+	article.URL = fullContent.URL.String() // Normalize URL
+	content, metadata := parsed.Content, parsed.Metadata
+	bodyText := content.Text
+	publishArticleEvent(models.EventArticleExtracted, article)
 
-	// 2. Summarize the content (using a hypothetical API call)
-	// summary, err := callSummarizationAPI(fullContent)
-	// if err != nil { ... }
-	ctx := context.Background()
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("GEMINI_API_KEY environment variable not set")
-	}
-	config := &genai.ClientConfig{
-		APIKey: apiKey,
-	}
-	client, err := genai.NewClient(ctx, config)
-	if err != nil {
-		log.Printf("Failed to create GenAI client: %v", err)
-		return
-	}
-	// Generate summary
-	summaryResponse, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", genai.Text("Summarize the following article: "+bodyText), nil)
+	// 2. Summarize and tag the content with the configured provider
+	summaryText, err := provider.Summarize(ctx, bodyText)
 	if err != nil {
-		log.Printf("Failed to summarize article %s: %v", article.ID, err)
-		return
+		return stageErrorf(stageSummarize, "failed to summarize article %s: %w", article.ID, err)
 	}
-	if summaryResponse == nil {
-		log.Printf("No summary generated for article %s", article.ID)
-		return
-	}
-	summaryText := summaryResponse.Text()
+	publishArticleEvent(models.EventArticleSummarized, article)
 
-	// Generate tags
-	tagsResponse, err := client.Models.GenerateContent(ctx, "gemini-2.5-flash", genai.Text("Generate a comma-separated list of tags for the following article: "+bodyText), nil)
+	tags, err := provider.Tag(ctx, bodyText)
 	if err != nil {
-		log.Printf("Failed to get tags for %s: %v", article.ID, err)
-		return
-	}
-	if tagsResponse == nil {
-		log.Printf("No tags generated for article %s", article.ID)
-		return
+		return stageErrorf(stageTag, "failed to get tags for %s: %w", article.ID, err)
 	}
-	tagsText := tagsResponse.Text()
+	publishArticleEvent(models.EventArticleTagged, article)
 
 	// 3. Update the article in the database
-	article.Summary = string(summaryText)               // Convert the genai.Text to a string
-	article.Tags = strings.Split(string(tagsText), ",") // Split the comma-separated string into a slice of strings
-	article.Status = "unread"                           // Or "processed", "read", etc.
+	article.Summary = summaryText
+	// Replace the tags the last processing added, keeping imported or user-set ones
+	article.SetAutoTags(tags)
+	article.BodyText = bodyText
+	article.WordCount = models.CountWords(bodyText) // The store turns this into reading time
+	article.PageCount = parsed.PageCount
+	article.ContentHTML = content.HTML
+	article.Author = metadata.Author
+	article.PublishedAt = metadata.PublishedAt
+	article.SiteName = metadata.SiteName
+	article.ImageURL = metadata.ImageURL
+	article.Description = metadata.Description
+	article.Language = metadata.Language
+	article.CanonicalURL = metadata.CanonicalURL
+	if article.Status != "read" { // Articles imported as read stay read
+		article.Status = "unread"
+	}
+	article.FailureReason = ""
+	article.FailureStage = ""
 
-	err = article.Save()
+	err = articles.SaveArticle(ctx, article)
 	if err != nil {
-		log.Printf("Failed to save processed article %s: %v", article.ID, err)
-		return
+		return stageErrorf(stageSave, "failed to save processed article %s: %w", article.ID, err)
 	}
+	// 4. Keep a copy of the lead image for exports; the article is usable without it
+	cacheLeadImage(ctx, articles, article)
+	publishArticleEvent(models.EventArticleProcessed, article)
 
 	log.Printf("Successfully processed and updated article ID: %s", article.ID)
+	return nil
 }
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// fakeProvider summarizes every article the same way and returns the next of
+// its tag lists on each call.
+type fakeProvider struct {
+	tags [][]string
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Summarize(ctx context.Context, text string) (string, error) {
+	return "A summary.", nil
+}
+
+func (p *fakeProvider) Tag(ctx context.Context, text string) ([]string, error) {
+	tags := p.tags[0]
+	p.tags = p.tags[1:]
+	return tags, nil
+}
+
+func TestReprocessReplacesAutoTags(t *testing.T) {
+	page := newCountingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>Tags</title></head><body><article><p>Some text about databases.</p></article></body></html>"))
+	})
+	previousFetcher, previousProvider := fetcher, provider
+	t.Cleanup(func() { fetcher, provider = previousFetcher, previousProvider })
+	fetcher = newTestFetcher([]string{"127.0.0.1"}, nil)
+	provider = &fakeProvider{tags: [][]string{{"Go", "databases", "imported"}, {"go", "testing"}}}
+
+	ctx := context.Background()
+	store := models.NewMemoryStore()
+	article := &models.Article{UserID: "user", URL: page.URL, Status: "processing", Tags: []string{"imported"}}
+	if err := store.SaveArticle(ctx, article); err != nil {
+		t.Fatal(err)
+	}
+
+	wants := []struct {
+		tags, autoTags []string
+	}{
+		{[]string{"databases", "go", "imported"}, []string{"databases", "go"}},
+		{[]string{"go", "imported", "testing"}, []string{"go", "testing"}},
+	}
+	for i, want := range wants {
+		article, err := store.GetArticleByID(ctx, article.ID, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ProcessNewArticle(ctx, store, article); err != nil {
+			t.Fatalf("processing #%d: %v", i+1, err)
+		}
+		got, err := store.GetArticleByID(ctx, article.ID, "user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Tags, want.tags) || !reflect.DeepEqual(got.AutoTags, want.autoTags) {
+			t.Errorf("after processing #%d, tags = %v with auto tags %v; want %v with %v", i+1, got.Tags, got.AutoTags, want.tags, want.autoTags)
+		}
+	}
+}
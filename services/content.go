@@ -0,0 +1,190 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/yuin/goldmark"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrUnsupportedContentType is returned for responses that aren't a web page,
+// plain text, Markdown or PDF, such as images, archives or JSON.
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// maxTextTitleLength caps titles taken from the first line of a text document.
+const maxTextTitleLength = 200
+
+// parsedArticle is the content of a downloaded article, whatever its format.
+type parsedArticle struct {
+	Title     string // Empty when the document has none
+	Metadata  pageMetadata
+	Content   *extractedContent
+	PageCount int // Only known for paged documents such as PDFs
+}
+
+// parseArticle decodes a fetched document according to its content type.
+// Types the server doesn't declare, or declares as generic binary, are sniffed.
+func parseArticle(ctx context.Context, result *FetchResult) (*parsedArticle, error) {
+	contentType := result.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "application/octet-stream" {
+		// Sniffing guesses a charset too, but decodeText detects that better
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(result.Body))
+		contentType = mediaType
+	}
+	// Markdown is often served as text/plain; go by the file extension then
+	if mediaType == "text/plain" && isMarkdownPath(result.URL.Path) {
+		mediaType = "text/markdown"
+	}
+
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return parseHTMLArticle(result, contentType)
+	case "text/plain":
+		text, err := decodeText(result.Body, contentType)
+		if err != nil {
+			return nil, err
+		}
+		return parseTextArticle(text), nil
+	case "text/markdown", "text/x-markdown":
+		text, err := decodeText(result.Body, contentType)
+		if err != nil {
+			return nil, err
+		}
+		return parseMarkdownArticle(result, text)
+	case "application/pdf", "application/x-pdf":
+		return parsePDFArticle(ctx, result.Body)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedContentType, mediaType)
+	}
+}
+
+// decodeText converts a text document to UTF-8. The encoding comes from a byte
+// order mark, the Content-Type charset or an HTML <meta> tag, in that order;
+// without any of those, valid UTF-8 is kept and anything else is read as
+// Windows-1252.
+func decodeText(body []byte, contentType string) (string, error) {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	// DetermineEncoding only looks at the first 1KB, which is often plain ASCII.
+	// A <meta> tag is only a hint: documents that are valid UTF-8 throughout
+	// almost always are UTF-8, whatever they declare.
+	if !certain && utf8.Valid(body) {
+		name = "utf-8"
+	}
+	if name == "utf-8" {
+		body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+		if !utf8.Valid(body) {
+			return string(bytes.ToValidUTF8(body, []byte("�"))), nil
+		}
+		return string(body), nil
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %s text: %w", name, err)
+	}
+	// UTF-16 decoders keep the byte order mark
+	return strings.TrimPrefix(string(decoded), "\ufeff"), nil
+}
+
+// parseHTMLArticle reads a web page's metadata, title and main content.
+func parseHTMLArticle(result *FetchResult, contentType string) (*parsedArticle, error) {
+	text, err := decodeText(result.Body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	// Metadata and title first: extraction removes the elements they come from
+	metadata := extractMetadata(doc, result.URL)
+	return &parsedArticle{
+		Title:    firstNonEmpty(metadata.Title, doc.Find("title").Text()),
+		Metadata: metadata,
+		Content:  extractContent(doc, result.URL),
+	}, nil
+}
+
+// parseTextArticle treats blank-line separated blocks of plain text as
+// paragraphs and the first line as the title.
+func parseTextArticle(text string) *parsedArticle {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var paragraphs []string
+	for _, block := range strings.Split(text, "\n\n") {
+		var lines []string
+		for _, line := range strings.Split(block, "\n") {
+			if line = strings.TrimRight(line, " \t\r"); strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		paragraphs = append(paragraphs, strings.Join(lines, "\n"))
+	}
+
+	var title string
+	if len(paragraphs) > 0 {
+		title = textTitle(strings.SplitN(paragraphs[0], "\n", 2)[0])
+	}
+	return &parsedArticle{Title: title, Content: paragraphContent(paragraphs)}
+}
+
+// paragraphContent turns paragraphs of plain text into article content. Line
+// breaks within a paragraph are kept.
+func paragraphContent(paragraphs []string) *extractedContent {
+	var htmlParagraphs strings.Builder
+	for i, paragraph := range paragraphs {
+		if i > 0 {
+			htmlParagraphs.WriteByte('\n')
+		}
+		htmlParagraphs.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br/>") + "</p>")
+	}
+	return &extractedContent{Text: strings.Join(paragraphs, "\n\n"), HTML: htmlParagraphs.String()}
+}
+
+// textTitle trims a line of text to a reasonable title length.
+func textTitle(line string) string {
+	title := strings.TrimSpace(line)
+	if len(title) > maxTextTitleLength {
+		title = strings.ToValidUTF8(title[:maxTextTitleLength], "") + "…"
+	}
+	return title
+}
+
+// parseMarkdownArticle renders Markdown to HTML, which is then sanitized like a
+// web page's content. The first heading is the title.
+func parseMarkdownArticle(result *FetchResult, text string) (*parsedArticle, error) {
+	var rendered bytes.Buffer
+	if err := goldmark.Convert([]byte(text), &rendered); err != nil {
+		return nil, fmt.Errorf("failed to render Markdown: %w", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(&rendered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered Markdown: %w", err)
+	}
+	return &parsedArticle{
+		Title:   strings.TrimSpace(doc.Find("h1, h2, h3").First().Text()),
+		Content: sanitize(doc.Find("body").Nodes, result.URL),
+	}, nil
+}
+
+func isMarkdownPath(p string) bool {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	}
+	return false
+}
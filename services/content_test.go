@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseArticle checks that documents in testdata/content are decoded
+// according to their content type and charset.
+func TestParseArticle(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		path        string // URL path, which matters for Markdown served as text/plain
+		contentType string
+		wantTitle   string
+		wantText    string
+	}{
+		{"html", "page.html", "/page", "text/html; charset=utf-8", "A Web Page", "A Web Page\n\nThe article body of a page served as HTML."},
+		{"xhtml", "page.html", "/page", "application/xhtml+xml", "A Web Page", "A Web Page\n\nThe article body of a page served as HTML."},
+		{"sniffed html", "page.html", "/page", "", "A Web Page", "A Web Page\n\nThe article body of a page served as HTML."},
+		{"octet-stream html", "page.html", "/page", "application/octet-stream", "A Web Page", "A Web Page\n\nThe article body of a page served as HTML."},
+		{"plain text", "notes.txt", "/notes.txt", "text/plain; charset=utf-8", "Plain Text Notes",
+			"Plain Text Notes\n\nThe first line of a text document is its title.\nLines of a paragraph stay together.\n\nA blank line starts a new paragraph."},
+		{"markdown", "guide.md", "/guide", "text/markdown", "A Markdown Guide", "A Markdown Guide\n\nMarkdown is rendered to HTML first.\n\none\n\ntwo"},
+		{"markdown served as text", "guide.md", "/guide.md", "text/plain", "A Markdown Guide", "A Markdown Guide\n\nMarkdown is rendered to HTML first.\n\none\n\ntwo"},
+		{"declared latin-1", "latin1.txt", "/latin1.txt", "text/plain; charset=iso-8859-1", "Café crème", "Café crème\n\nDéjà vu à Paris."},
+		{"undeclared windows-1252", "windows1252.txt", "/windows1252.txt", "text/plain", "“Smart quotes”", "“Smart quotes”\n\nCost: 5€ — cheap."},
+		{"utf-16 with a byte order mark", "utf16.txt", "/utf16.txt", "text/plain", "UTF-16 Text", "UTF-16 Text\n\nGrüße aus Köln."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", "content", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := &FetchResult{
+				URL:    &url.URL{Scheme: "https", Host: "example.com", Path: tt.path},
+				Header: http.Header{"Content-Type": {tt.contentType}},
+				Body:   body,
+			}
+			parsed, err := parseArticle(context.Background(), result)
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+			if parsed.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", parsed.Title, tt.wantTitle)
+			}
+			if parsed.Content.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", parsed.Content.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestParseArticleUnsupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"image", "image/png", "\x89PNG\r\n\x1a\n"},
+		{"json", "application/json", `{"title": "not an article"}`},
+		{"sniffed binary", "", "\x00\x01\x02\x03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &FetchResult{
+				URL:    &url.URL{Scheme: "https", Host: "example.com", Path: "/file"},
+				Header: http.Header{"Content-Type": {tt.contentType}},
+				Body:   []byte(tt.body),
+			}
+			_, err := parseArticle(context.Background(), result)
+			if !errors.Is(err, ErrUnsupportedContentType) {
+				t.Fatalf("got %v, want ErrUnsupportedContentType", err)
+			}
+			if !isPermanent(err) {
+				t.Errorf("isPermanent(%v) = false, want true", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+const (
+	// eventHistorySize is how many recent events are kept for clients resuming
+	// with Last-Event-ID, across all users.
+	eventHistorySize = 1000
+	// eventBufferSize is how many events a subscriber may fall behind before it
+	// is disconnected. It can resume from the history when it reconnects.
+	eventBufferSize = 64
+)
+
+type eventSubscriber struct {
+	userID string
+	ch     chan models.ArticleEvent
+}
+
+// EventBus fans out article events to the subscribers of the article's owner.
+// Events only live in memory: subscribers miss events published while they
+// were disconnected once those drop out of the history, or the process restarts.
+type EventBus struct {
+	mu          sync.Mutex
+	nextID      int64
+	history     []models.ArticleEvent // Oldest first
+	subscribers map[*eventSubscriber]struct{}
+	closed      bool
+}
+
+// NewEventBus creates an empty event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		// Start from the clock rather than 1, so IDs keep increasing across restarts
+		// and a client resuming with an ID from a previous run gets every new event
+		nextID:      time.Now().UnixMicro(),
+		subscribers: make(map[*eventSubscriber]struct{}),
+	}
+}
+
+// Publish assigns the event an ID and delivers it to the user's subscribers.
+func (b *EventBus) Publish(event models.ArticleEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event.ID = b.nextID
+	event.Time = time.Now().UTC()
+	if len(b.history) == eventHistorySize {
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	b.history = append(b.history, event)
+
+	for sub := range b.subscribers {
+		if sub.userID != event.UserID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// Too slow to keep up; it resumes from the history after reconnecting
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribe returns the user's events published after lastEventID that are
+// still in the history, and a channel of the events that follow. The channel
+// is closed if the subscriber falls behind or the bus is closed. Call
+// unsubscribe when done.
+func (b *EventBus) Subscribe(userID string, lastEventID int64) (missed []models.ArticleEvent, events <-chan models.ArticleEvent, unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lastEventID > 0 {
+		for _, event := range b.history {
+			if event.ID > lastEventID && event.UserID == userID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	sub := &eventSubscriber{userID: userID, ch: make(chan models.ArticleEvent, eventBufferSize)}
+	if b.closed {
+		close(sub.ch)
+		return missed, sub.ch, func() {}
+	}
+	b.subscribers[sub] = struct{}{}
+	return missed, sub.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// Close disconnects all subscribers, e.g. so open streams don't hold up server shutdown.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// events is the bus article processing publishes to.
+var events = NewEventBus()
+
+// SetEventBus replaces the bus article processing publishes to.
+func SetEventBus(b *EventBus) {
+	events = b
+}
+
+// publishArticleEvent reports the article's progress to its owner.
+func publishArticleEvent(eventType string, article *models.Article) {
+	events.Publish(models.ArticleEvent{
+		Type:          eventType,
+		ArticleID:     article.ID,
+		UserID:        article.UserID,
+		Status:        article.Status,
+		Title:         article.Title,
+		FailureReason: article.FailureReason,
+		FailureStage:  article.FailureStage,
+	})
+}
@@ -0,0 +1,502 @@
+package services
+
+import (
+	"bytes"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Main content extraction, in the spirit of Mozilla's Readability: boilerplate
+// is stripped, every text block scores its ancestors, and the best scoring
+// element (plus related siblings) is taken as the article.
+
+const (
+	// minParagraphLength is the shortest text block that counts towards a score.
+	minParagraphLength = 25
+	// minContentLength is the least text an extraction must produce; below it the
+	// whole page body is used instead.
+	minContentLength = 140
+)
+
+var (
+	// boilerplateSelector matches elements that never hold article content.
+	boilerplateSelector = strings.Join([]string{
+		"script", "style", "noscript", "template", "iframe", "object", "embed", "svg", "canvas",
+		"form", "button", "input", "select", "textarea", "nav", "footer", "aside", "dialog",
+		"[hidden]", "[aria-hidden=true]", `[style*="display:none"]`, `[style*="display: none"]`,
+		"[role=navigation]", "[role=complementary]", "[role=contentinfo]", "[role=dialog]", "[role=alertdialog]",
+	}, ", ")
+
+	// Class and id patterns, adapted from Readability.
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ad-break|agegate|banner|breadcrumb|combx|comment|community|consent|cookie|cover-wrap|disqus|extra|gdpr|header|legends|menu|modal|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|supplemental|yom-remote`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveNames      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeNames      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$|banner|combx|comment|com-|contact|cookie|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// blockTags are elements that start a new block of text.
+var blockTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Dd: true,
+	atom.Details: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Section: true, atom.Summary: true, atom.Table: true, atom.Tbody: true,
+	atom.Td: true, atom.Tfoot: true, atom.Th: true, atom.Thead: true, atom.Tr: true, atom.Ul: true,
+}
+
+// allowedTags are the elements kept in sanitized HTML. Any other element is
+// replaced by its children.
+var allowedTags = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.Blockquote: true, atom.Br: true, atom.Code: true, atom.Dd: true,
+	atom.Dl: true, atom.Dt: true, atom.Em: true, atom.Figcaption: true, atom.Figure: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Hr: true, atom.I: true,
+	atom.Img: true, atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Strong: true,
+	atom.Sub: true, atom.Sup: true, atom.Table: true, atom.Tbody: true, atom.Td: true, atom.Th: true,
+	atom.Thead: true, atom.Tr: true, atom.Ul: true,
+}
+
+// voidTags are allowed elements that are kept even without content.
+var voidTags = map[atom.Atom]bool{atom.Br: true, atom.Hr: true, atom.Img: true}
+
+// extractedContent is the main content of a page.
+type extractedContent struct {
+	Text string // Plain text, paragraphs separated by blank lines
+	HTML string // Sanitized HTML
+}
+
+// extractContent finds the main content of a parsed HTML page. Relative links
+// and image sources are resolved against base.
+//
+// It modifies doc, so read anything else needed from the page first.
+func extractContent(doc *goquery.Document, base *url.URL) *extractedContent {
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	body := doc.Find("body")
+	body.Find(boilerplateSelector).Remove()
+	// Page headers hold site navigation; article headers hold the headline
+	body.Find("header").Each(func(_ int, s *goquery.Selection) {
+		if s.Closest("article, main").Length() == 0 {
+			s.Remove()
+		}
+	})
+	removeUnlikelyCandidates(body)
+
+	var nodes []*html.Node
+	if top, scores := topCandidate(body); top != nil {
+		nodes = collectSiblings(top, scores)
+	}
+	content := sanitize(nodes, base)
+	if len(content.Text) < minContentLength {
+		// Scoring found nothing convincing; fall back to the whole (cleaned) body
+		if whole := sanitize(body.Nodes, base); len(whole.Text) > len(content.Text) {
+			content = whole
+		}
+	}
+	return content
+}
+
+// removeUnlikelyCandidates removes elements whose class or id marks them as
+// comments, banners, share widgets and the like.
+func removeUnlikelyCandidates(body *goquery.Selection) {
+	body.Find("*").Each(func(_ int, s *goquery.Selection) {
+		switch s.Nodes[0].DataAtom {
+		case atom.Html, atom.Body, atom.Article, atom.Main, atom.A:
+			return
+		}
+		names := classAndID(s.Nodes[0])
+		if names == "" || s.Closest("table, pre, code").Length() > 0 {
+			return
+		}
+		if unlikelyCandidates.MatchString(names) && !maybeCandidate.MatchString(names) {
+			s.Remove()
+		}
+	})
+}
+
+// topCandidate scores the ancestors of every text block and returns the element
+// most likely to contain the article, or nil when nothing scored, along with
+// the scores of all candidates.
+func topCandidate(body *goquery.Selection) (*html.Node, map[*html.Node]float64) {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node // Keeps ties deterministic
+
+	body.Find("p, pre, td, blockquote, div").Each(func(_ int, s *goquery.Selection) {
+		// A div only counts when it holds text directly rather than other blocks
+		if s.Nodes[0].DataAtom == atom.Div && hasBlockChild(s.Nodes[0]) {
+			return
+		}
+		text := normalizeSpace(s.Text())
+		if len(text) < minParagraphLength {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text)/100), 3)
+
+		level := 0
+		for n := s.Nodes[0].Parent; n != nil && n.Type == html.ElementNode && level < 3; n = n.Parent {
+			if _, ok := scores[n]; !ok {
+				scores[n] = initialScore(n)
+				order = append(order, n)
+			}
+			switch level {
+			case 0:
+				scores[n] += score
+			case 1:
+				scores[n] += score / 2
+			default:
+				scores[n] += score / float64(level*3)
+			}
+			level++
+		}
+	})
+
+	var top *html.Node
+	best := 0.0
+	for _, n := range order {
+		score := scores[n] * (1 - linkDensity(n))
+		scores[n] = score
+		if score > best {
+			top, best = n, score
+		}
+	}
+	return top, scores
+}
+
+// initialScore weighs an element by its tag and by its class and id.
+func initialScore(n *html.Node) float64 {
+	score := classWeight(n)
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main, atom.Section:
+		score += 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		score += 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		score -= 3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score -= 5
+	}
+	return score
+}
+
+// classWeight is positive for elements whose class or id suggests content and
+// negative for those that suggest boilerplate.
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		if name == "" {
+			continue
+		}
+		if negativeNames.MatchString(name) {
+			weight -= 25
+		}
+		if positiveNames.MatchString(name) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// collectSiblings returns the top candidate together with the siblings that
+// look like part of the same article, such as a lead paragraph kept outside
+// the main container.
+func collectSiblings(top *html.Node, scores map[*html.Node]float64) []*html.Node {
+	if top.Parent == nil || top.DataAtom == atom.Body {
+		return []*html.Node{top}
+	}
+	threshold := math.Max(10, scores[top]*0.2)
+	topClass := attr(top, "class")
+
+	var nodes []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == top {
+			nodes = append(nodes, sibling)
+			continue
+		}
+		if sibling.Type != html.ElementNode {
+			continue
+		}
+		score, scored := scores[sibling]
+		if scored && topClass != "" && attr(sibling, "class") == topClass {
+			score += scores[top] * 0.2
+		}
+		appendSibling := scored && score >= threshold
+		if !appendSibling && sibling.DataAtom == atom.P {
+			text := normalizeSpace(nodeText(sibling))
+			density := linkDensity(sibling)
+			switch {
+			case len(text) > 80 && density < 0.25:
+				appendSibling = true
+			case len(text) > 0 && density == 0 && strings.HasSuffix(text, "."):
+				appendSibling = true
+			}
+		}
+		if appendSibling {
+			nodes = append(nodes, sibling)
+		}
+	}
+	return nodes
+}
+
+// sanitize copies nodes into a tree of allowedTags only and renders it as HTML
+// and as plain text.
+func sanitize(nodes []*html.Node, base *url.URL) *extractedContent {
+	s := &sanitizer{base: base}
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, n := range nodes {
+		s.appendBlock(root, n)
+	}
+
+	var buf bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		if err := html.Render(&buf, c); err != nil {
+			break // Rendering into a buffer only fails on malformed trees, which sanitize never builds
+		}
+	}
+	return &extractedContent{Text: renderText(root), HTML: buf.String()}
+}
+
+type sanitizer struct {
+	base *url.URL
+}
+
+// appendBlock appends the content of a block container to parent, wrapping runs
+// of loose text and inline elements in paragraphs.
+func (s *sanitizer) appendBlock(parent, n *html.Node) {
+	if n.Type != html.ElementNode || allowedTags[n.DataAtom] {
+		s.appendNode(parent, n)
+		return
+	}
+	var para *html.Node
+	flush := func() {
+		if para != nil && hasContent(para) {
+			parent.AppendChild(para)
+		}
+		para = nil
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode || (c.Type == html.ElementNode && !blockTags[c.DataAtom]) {
+			if para == nil {
+				para = &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P}
+			}
+			s.appendNode(para, c)
+			continue
+		}
+		flush()
+		s.appendBlock(parent, c)
+	}
+	flush()
+}
+
+// appendNode appends a sanitized copy of n to parent.
+func (s *sanitizer) appendNode(parent, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		parent.AppendChild(&html.Node{Type: html.TextNode, Data: n.Data})
+		return
+	case html.ElementNode:
+	default:
+		return // Comments, doctypes
+	}
+
+	if !allowedTags[n.DataAtom] {
+		if blockTags[n.DataAtom] {
+			s.appendBlock(parent, n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s.appendNode(parent, c)
+		}
+		return
+	}
+
+	el := &html.Node{Type: html.ElementNode, Data: n.Data, DataAtom: n.DataAtom}
+	switch n.DataAtom {
+	case atom.A:
+		if href := s.resolve(attr(n, "href"), "http", "https", "mailto"); href != "" {
+			el.Attr = append(el.Attr, html.Attribute{Key: "href", Val: href})
+		}
+	case atom.Img:
+		src := s.resolve(attr(n, "src"), "http", "https")
+		for _, lazy := range []string{"data-src", "data-original"} {
+			if src == "" {
+				src = s.resolve(attr(n, lazy), "http", "https")
+			}
+		}
+		if src == "" {
+			return
+		}
+		el.Attr = append(el.Attr, html.Attribute{Key: "src", Val: src})
+		if alt := attr(n, "alt"); alt != "" {
+			el.Attr = append(el.Attr, html.Attribute{Key: "alt", Val: alt})
+		}
+	case atom.Td, atom.Th:
+		for _, key := range []string{"colspan", "rowspan"} {
+			if v := attr(n, key); v != "" {
+				el.Attr = append(el.Attr, html.Attribute{Key: key, Val: v})
+			}
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.appendNode(el, c)
+	}
+	if voidTags[n.DataAtom] || hasContent(el) {
+		parent.AppendChild(el)
+	}
+}
+
+// resolve makes ref absolute and returns it if its scheme is allowed.
+func (s *sanitizer) resolve(ref string, schemes ...string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := s.base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// renderText converts a sanitized tree to plain text with one paragraph per block.
+func renderText(root *html.Node) string {
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		var lines []string
+		for _, line := range strings.Split(current.String(), "\n") {
+			if line = normalizeSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, strings.Join(lines, "\n"))
+		}
+		current.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			current.WriteString(strings.ReplaceAll(n.Data, "\n", " "))
+		case n.DataAtom == atom.Br:
+			current.WriteString("\n")
+		case n.DataAtom == atom.Pre:
+			flush()
+			if text := strings.Trim(nodeText(n), "\n"); strings.TrimSpace(text) != "" {
+				paragraphs = append(paragraphs, text)
+			}
+		case n.DataAtom == atom.Td || n.DataAtom == atom.Th:
+			// A table row is one line, with its cells separated by spaces
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			current.WriteString(" ")
+		case blockTags[n.DataAtom]:
+			flush()
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+			flush()
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+	flush()
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// linkDensity is the share of an element's text that is inside links.
+func linkDensity(n *html.Node) float64 {
+	textLength := len(normalizeSpace(nodeText(n)))
+	if textLength == 0 {
+		return 0
+	}
+	linkLength := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linkLength += len(normalizeSpace(nodeText(n)))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linkLength) / float64(textLength)
+}
+
+func hasBlockChild(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (blockTags[c.DataAtom] || hasBlockChild(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasContent reports whether an element has any text or image inside it.
+func hasContent(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) != "" {
+			return true
+		}
+		if c.Type == html.ElementNode && (c.DataAtom == atom.Img || hasContent(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(nodeText(c))
+	}
+	return b.String()
+}
+
+func classAndID(n *html.Node) string {
+	return strings.TrimSpace(attr(n, "class") + " " + attr(n, "id"))
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
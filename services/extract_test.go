@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestExtractContent parses each page in testdata/extract and compares the
+// extracted text and HTML with <page>.txt.golden and <page>.html.golden. Run
+// with -update to rewrite the golden files after changing the extraction.
+func TestExtractContent(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "extract", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("no pages in testdata/extract")
+	}
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			body, err := os.ReadFile(page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := &FetchResult{
+				URL:    &url.URL{Scheme: "https", Host: "example.com", Path: "/articles/" + name},
+				Header: http.Header{"Content-Type": {"text/html"}},
+				Body:   body,
+			}
+			parsed, err := parseArticle(context.Background(), result)
+			if err != nil {
+				t.Fatalf("parsing: %v", err)
+			}
+			compareGolden(t, strings.TrimSuffix(page, ".html")+".txt.golden", parsed.Content.Text)
+			compareGolden(t, page+".golden", parsed.Content.HTML)
+		})
+	}
+}
+
+// compareGolden compares got with the contents of a golden file, or rewrites
+// the file with -update.
+func compareGolden(t *testing.T, path, got string) {
+	t.Helper()
+	got += "\n" // Golden files end with a newline like other text files
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs:\n--- got\n%s--- want\n%s", path, got, want)
+	}
+}
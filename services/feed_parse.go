@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrInvalidFeed is returned for documents that aren't an RSS, Atom or JSON feed.
+var ErrInvalidFeed = errors.New("invalid feed")
+
+// parsedFeed is a subscribed feed's title and entries, in document order.
+type parsedFeed struct {
+	Title   string
+	Entries []feedEntry
+}
+
+// feedEntry is an entry of a subscribed feed that links to a web page.
+type feedEntry struct {
+	Key   string // The entry's ID, or its URL if it has none
+	URL   string
+	Title string
+	Text  string // The summary and content as plain text, for keyword filters
+}
+
+// RSS 2.0 and RSS 1.0 (RDF) documents. RSS 1.0 puts the items next to the
+// channel rather than in it. Elements match in any namespace unless one is
+// given, so the links include Atom self links, which have no text.
+type rssDocument struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title string   `xml:"title"`
+	Links []string `xml:"link"`
+	GUID  struct {
+		IsPermaLink string `xml:"isPermaLink,attr"`
+		ID          string `xml:",chardata"`
+	} `xml:"guid"`
+	About       string `xml:"about,attr"` // RSS 1.0 items are identified by their rdf:about
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// Atom documents (RFC 4287).
+type atomDocument struct {
+	Title   atomText    `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID    string   `xml:"id"`
+	Title atomText `xml:"title"`
+	Links []struct {
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+	Summary atomText `xml:"summary"`
+	Content atomText `xml:"content"`
+}
+
+// atomText is an Atom text construct. XHTML text is markup inside the element,
+// the other types are character data.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) plain() string {
+	switch t.Type {
+	case "html":
+		return htmlText(t.Text)
+	case "xhtml":
+		return htmlText(t.Inner)
+	default:
+		return normalizeSpace(t.Text)
+	}
+}
+
+// JSON Feed documents (https://www.jsonfeed.org/version/1.1/).
+type jsonFeedDocument struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Items   []struct {
+		ID          json.RawMessage `json:"id"` // A string, though some feeds use numbers
+		URL         string          `json:"url"`
+		ExternalURL string          `json:"external_url"`
+		Title       string          `json:"title"`
+		Summary     string          `json:"summary"`
+		ContentHTML string          `json:"content_html"`
+		ContentText string          `json:"content_text"`
+	} `json:"items"`
+}
+
+// parseFeed reads an RSS, Atom or JSON feed downloaded from base. Relative
+// entry URLs are resolved against base, and entries without a web URL are
+// left out.
+func parseFeed(data []byte, base *url.URL) (*parsedFeed, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	var feed *parsedFeed
+	var err error
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		feed, err = parseJSONFeed(trimmed, base)
+	} else {
+		feed, err = parseXMLFeed(trimmed, base)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFeed, err)
+	}
+	return feed, nil
+}
+
+func parseJSONFeed(data []byte, base *url.URL) (*parsedFeed, error) {
+	var doc jsonFeedDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, errors.New("not a JSON Feed")
+	}
+
+	feed := &parsedFeed{Title: normalizeSpace(doc.Title)}
+	for _, item := range doc.Items {
+		var id string
+		if err := json.Unmarshal(item.ID, &id); err != nil {
+			id = string(item.ID) // A number
+		}
+		text := item.ContentText
+		if text == "" {
+			text = htmlText(item.ContentHTML)
+		}
+		feed.add(base, id, firstNonEmpty(item.URL, item.ExternalURL), item.Title, item.Summary+" "+text)
+	}
+	return feed, nil
+}
+
+func parseXMLFeed(data []byte, base *url.URL) (*parsedFeed, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false // Feeds in the wild use HTML entities and unescaped ampersands
+	decoder.Entity = xml.HTMLEntity
+
+	// Find the root element to tell the format
+	var root xml.StartElement
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, errors.New("not an RSS, Atom or JSON feed")
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+
+	switch strings.ToLower(root.Name.Local) {
+	case "rss", "rdf":
+		var doc rssDocument
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		feed := &parsedFeed{Title: normalizeSpace(doc.Channel.Title)}
+		for _, item := range append(doc.Channel.Items, doc.Items...) {
+			feed.addRSSItem(base, item)
+		}
+		return feed, nil
+	case "feed":
+		var doc atomDocument
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+		feed := &parsedFeed{Title: doc.Title.plain()}
+		for _, entry := range doc.Entries {
+			var link string
+			for _, l := range entry.Links {
+				if (l.Rel == "" || l.Rel == "alternate") && (link == "" || l.Type == "text/html") {
+					link = l.Href
+				}
+			}
+			feed.add(base, entry.ID, link, entry.Title.plain(), entry.Summary.plain()+" "+entry.Content.plain())
+		}
+		return feed, nil
+	default:
+		return nil, fmt.Errorf("unexpected root element <%s>", root.Name.Local)
+	}
+}
+
+func (feed *parsedFeed) addRSSItem(base *url.URL, item rssItem) {
+	var link string
+	for _, l := range item.Links {
+		if link = strings.TrimSpace(l); link != "" {
+			break
+		}
+	}
+	id := firstNonEmpty(item.GUID.ID, item.About)
+	// A GUID is the item's URL unless it says otherwise
+	if link == "" && item.GUID.IsPermaLink != "false" {
+		link = item.GUID.ID
+	}
+	feed.add(base, id, link, htmlText(item.Title), htmlText(item.Description)+" "+htmlText(item.Content))
+}
+
+// add appends an entry, unless it has no web URL.
+func (feed *parsedFeed) add(base *url.URL, id, link, title, text string) {
+	link = resolveURL(base, link)
+	if link == "" {
+		return
+	}
+	feed.Entries = append(feed.Entries, feedEntry{
+		Key:   firstNonEmpty(id, link),
+		URL:   link,
+		Title: normalizeSpace(title),
+		Text:  normalizeSpace(text),
+	})
+}
+
+// htmlText returns the text of an HTML fragment.
+func htmlText(fragment string) string {
+	if !strings.ContainsAny(fragment, "<&") {
+		return normalizeSpace(fragment)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return normalizeSpace(fragment)
+	}
+	return normalizeSpace(doc.Text())
+}
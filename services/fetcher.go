@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+)
+
+// fetchUserAgent identifies the fetcher to the sites it downloads from.
+const fetchUserAgent = "Mozilla/5.0 (compatible; ReadingListBot/1.0)"
+
+// Errors returned by Fetcher.Fetch. They are permanent: retrying won't help.
+var (
+	// ErrURLNotAllowed is returned for URLs with an unsupported scheme, denied
+	// hosts, and hosts that resolve to private, loopback or link-local addresses.
+	ErrURLNotAllowed = errors.New("URL not allowed")
+	// ErrBodyTooLarge is returned when a response exceeds the size limit.
+	ErrBodyTooLarge = errors.New("response body too large")
+	// ErrTooManyRedirects is returned when a URL redirects too many times.
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// HTTPStatusError is returned when a server answers with a status other than
+// 200 OK.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string { return fmt.Sprintf("HTTP %d", e.StatusCode) }
+
+// ErrNotModified is returned by FetchFeed when the feed hasn't changed since
+// the response whose validators were sent.
+var ErrNotModified = errors.New("not modified")
+
+// Accept headers for the documents the fetcher downloads.
+const (
+	articleAccept = "text/html,application/xhtml+xml,text/plain;q=0.9,application/pdf;q=0.9,*/*;q=0.8"
+	feedAccept    = "application/rss+xml,application/atom+xml,application/feed+json,application/xml;q=0.9,text/xml;q=0.9,*/*;q=0.8"
+)
+
+// blockedPrefixes are address ranges that don't belong to the public internet:
+// private networks, loopback, link-local (including cloud metadata endpoints),
+// carrier-grade NAT and reserved ranges.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// isPublicAddr reports whether ip is a routable public address.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// hostList matches host names (and their subdomains), IP addresses and CIDR ranges.
+type hostList struct {
+	names    []string
+	prefixes []netip.Prefix
+}
+
+func newHostList(entries []string) hostList {
+	var l hostList
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			l.prefixes = append(l.prefixes, prefix.Masked())
+		} else if ip, err := netip.ParseAddr(entry); err == nil {
+			l.prefixes = append(l.prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+		} else if entry = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), "."); entry != "" {
+			l.names = append(l.names, entry)
+		}
+	}
+	return l
+}
+
+// matchHost reports whether a host name or IP literal is in the list.
+func (l hostList) matchHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return l.matchIP(ip)
+	}
+	for _, name := range l.names {
+		if host == name || strings.HasSuffix(host, "."+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchIP reports whether an address is in one of the list's ranges.
+func (l hostList) matchIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	for _, prefix := range l.prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchResult is a successfully downloaded response.
+type FetchResult struct {
+	URL    *url.URL // Final URL, after redirects
+	Header http.Header
+	Body   []byte
+}
+
+// Fetcher downloads article URLs on behalf of users. It only speaks HTTP(S),
+// bounds time and size, and refuses to connect to non-public addresses, which
+// it checks after DNS resolution so a public name can't point at an internal
+// service.
+type Fetcher struct {
+	client       *http.Client
+	poster       *http.Client // Same transport, but doesn't follow redirects
+	maxBodyBytes int64
+	allow        hostList
+	deny         hostList
+}
+
+// NewFetcher creates a fetcher with the given limits.
+func NewFetcher(cfg config.FetcherConfig) *Fetcher {
+	f := &Fetcher{
+		maxBodyBytes: int64(cfg.MaxBodyBytes),
+		allow:        newHostList(cfg.AllowHosts),
+		deny:         newHostList(cfg.DenyHosts),
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // A proxy would connect on our behalf, bypassing the address checks
+		DialContext:           f.dialContext(cfg),
+		TLSHandshakeTimeout:   cfg.ConnectTimeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       cfg.Timeout,
+		ForceAttemptHTTP2:     true,
+	}
+	f.client = &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, cfg.MaxRedirects)
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+	f.poster = &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return f
+}
+
+// dialContext returns a dial function that refuses denied addresses, and
+// non-public addresses unless the host is explicitly allowed.
+func (f *Fetcher) dialContext(cfg config.FetcherConfig) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	// control runs for every address the host resolved to, right before connecting
+	control := func(allowPrivate bool) func(network, address string, _ syscall.RawConn) error {
+		return func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if f.deny.matchIP(ip) {
+				return fmt.Errorf("%w: %s is denied", ErrURLNotAllowed, ip)
+			}
+			if !allowPrivate && !isPublicAddr(ip) && !f.allow.matchIP(ip) {
+				return fmt.Errorf("%w: %s is not a public address", ErrURLNotAllowed, ip)
+			}
+			return nil
+		}
+	}
+	// Allowed hosts may resolve to private addresses, but not to denied ones
+	open := &net.Dialer{Timeout: cfg.ConnectTimeout, Control: control(true)}
+	guarded := &net.Dialer{Timeout: cfg.ConnectTimeout, Control: control(false)}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if f.allow.matchHost(host) && !f.deny.matchHost(host) {
+			return open.DialContext(ctx, network, addr)
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// checkURL rejects URLs the fetcher must not request, before any connection is made.
+func (f *Fetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrURLNotAllowed, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%w: missing host", ErrURLNotAllowed)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in URL", ErrURLNotAllowed)
+	}
+	if f.deny.matchHost(u.Hostname()) {
+		return fmt.Errorf("%w: host %s is denied", ErrURLNotAllowed, u.Hostname())
+	}
+	return nil
+}
+
+// Fetch downloads rawURL and returns the response if it succeeded with 200 OK.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*FetchResult, error) {
+	return f.get(ctx, rawURL, http.Header{"Accept": {articleAccept}})
+}
+
+// FetchFeed downloads a feed like Fetch, sending the validators of the previous
+// response so an unchanged feed isn't downloaded again; it then returns
+// ErrNotModified. Either validator may be empty.
+func (f *Fetcher) FetchFeed(ctx context.Context, rawURL, etag, lastModified string) (*FetchResult, error) {
+	header := http.Header{"Accept": {feedAccept}}
+	if etag != "" {
+		header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		header.Set("If-Modified-Since", lastModified)
+	}
+	return f.get(ctx, rawURL, header)
+}
+
+// get downloads rawURL with the given request headers.
+func (f *Fetcher) get(ctx context.Context, rawURL string, header http.Header) (*FetchResult, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrURLNotAllowed, err)
+	}
+	if err := f.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("User-Agent", fetchUserAgent)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+	if resp.ContentLength > f.maxBodyBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrBodyTooLarge, resp.ContentLength, f.maxBodyBytes)
+	}
+
+	// Read one byte past the limit to tell a body of exactly the limit from a larger one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if int64(len(body)) > f.maxBodyBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, f.maxBodyBytes)
+	}
+	return &FetchResult{URL: resp.Request.URL, Header: resp.Header, Body: body}, nil
+}
+
+// Post sends body to rawURL with the same restrictions as Fetch, and returns
+// the response status. Redirects are not followed, since they would turn the
+// request into a GET.
+func (f *Fetcher) Post(ctx context.Context, rawURL string, header http.Header, body []byte) (int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrURLNotAllowed, err)
+	}
+	if err := f.checkURL(u); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("User-Agent", fetchUserAgent)
+
+	resp, err := f.poster.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the response so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// fetcher is the fetcher used by ProcessNewArticle.
+var fetcher = NewFetcher(config.Default().Fetcher)
+
+// SetFetcher replaces the fetcher used to download articles.
+func SetFetcher(f *Fetcher) {
+	fetcher = f
+	if len(f.allow.names) > 0 || len(f.allow.prefixes) > 0 {
+		log.Printf("Fetcher allows private addresses for %d allow-listed hosts", len(f.allow.names)+len(f.allow.prefixes))
+	}
+}
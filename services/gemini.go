@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// GeminiProvider summarizes and tags articles with Google's Gemini models.
+type GeminiProvider struct {
+	client *genai.Client
+	model  string
+}
+
+// NewGeminiProvider creates a Gemini-backed provider for the given model.
+func NewGeminiProvider(ctx context.Context, apiKey, model string) (*GeminiProvider, error) {
+	if apiKey == "" {
+		return nil, errors.New("no Gemini API key configured (ai.gemini.api_key or GEMINI_API_KEY)")
+	}
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey: apiKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GenAI client: %w", err)
+	}
+	return &GeminiProvider{client: client, model: model}, nil
+}
+
+// Name identifies the provider in logs.
+func (p *GeminiProvider) Name() string {
+	return "gemini (" + p.model + ")"
+}
+
+// Summarize asks Gemini for a summary of the text.
+func (p *GeminiProvider) Summarize(ctx context.Context, text string) (string, error) {
+	return p.generate(ctx, summarizePrompt+text)
+}
+
+// Tag asks Gemini for a comma-separated list of tags for the text.
+func (p *GeminiProvider) Tag(ctx context.Context, text string) ([]string, error) {
+	response, err := p.generate(ctx, tagPrompt+text)
+	if err != nil {
+		return nil, err
+	}
+	return parseTagList(response), nil
+}
+
+func (p *GeminiProvider) generate(ctx context.Context, prompt string) (string, error) {
+	response, err := p.client.Models.GenerateContent(ctx, p.model, genai.Text(prompt), nil)
+	if err != nil {
+		return "", fmt.Errorf("gemini request failed: %w", err)
+	}
+	if response == nil || response.Text() == "" {
+		return "", errors.New("gemini returned an empty response")
+	}
+	return response.Text(), nil
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// Import file formats. Pocket exports are either HTML or CSV; the rest have a single format.
+const (
+	ImportFormatPocket     = "pocket"     // Pocket HTML or CSV export
+	ImportFormatInstapaper = "instapaper" // Instapaper CSV export
+	ImportFormatOmnivore   = "omnivore"   // Omnivore JSON metadata, or the export ZIP containing it
+	ImportFormatBookmarks  = "bookmarks"  // Netscape bookmark file, as exported by browsers
+)
+
+// ImportFormats lists the formats ParseImport accepts.
+var ImportFormats = []string{ImportFormatPocket, ImportFormatInstapaper, ImportFormatOmnivore, ImportFormatBookmarks}
+
+// ErrInvalidImport is returned for files that can't be read as the given, or any, export format.
+var ErrInvalidImport = errors.New("invalid import file")
+
+// ParseImport reads the entries of an export file. When format is empty it is
+// detected from the contents. It returns the format and the entries, in file
+// order; entries that can't be imported, such as ones without a web URL, are
+// returned with their Error set.
+func ParseImport(format string, data []byte) (string, []models.ImportItem, error) {
+	if format == "" {
+		format = detectImportFormat(data)
+	}
+
+	var items []models.ImportItem
+	var err error
+	switch format {
+	case ImportFormatPocket:
+		if looksLikeHTML(data) {
+			items, err = parseImportHTML(data)
+		} else {
+			items, err = parsePocketCSV(data)
+		}
+	case ImportFormatInstapaper:
+		items, err = parseInstapaperCSV(data)
+	case ImportFormatOmnivore:
+		items, err = parseOmnivore(data)
+	case ImportFormatBookmarks:
+		items, err = parseImportHTML(data)
+	case "":
+		return "", nil, fmt.Errorf("%w: unrecognized format", ErrInvalidImport)
+	default:
+		return "", nil, fmt.Errorf("%w: unknown format %q", ErrInvalidImport, format)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	if len(items) == 0 {
+		return "", nil, fmt.Errorf("%w: no articles found", ErrInvalidImport)
+	}
+
+	for i := range items {
+		items[i].URL = strings.TrimSpace(items[i].URL)
+		if items[i].Error == "" && !isWebURL(items[i].URL) {
+			items[i].Error = "not an http or https URL"
+		}
+	}
+	return format, items, nil
+}
+
+// detectImportFormat guesses the format of an export file, returning "" if it
+// matches none.
+func detectImportFormat(data []byte) string {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return ImportFormatOmnivore
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		return ImportFormatOmnivore
+	}
+	if looksLikeHTML(data) {
+		lower := bytes.ToLower(data)
+		if !bytes.Contains(lower, []byte("netscape-bookmark-file")) && bytes.Contains(lower, []byte("time_added")) {
+			return ImportFormatPocket
+		}
+		return ImportFormatBookmarks
+	}
+
+	header, err := csv.NewReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		return ""
+	}
+	columns := csvColumns(header)
+	if _, ok := columns["folder"]; ok {
+		return ImportFormatInstapaper
+	}
+	if _, ok := columns["time_added"]; ok {
+		return ImportFormatPocket
+	}
+	return ""
+}
+
+func looksLikeHTML(data []byte) bool {
+	start := bytes.ToLower(bytes.TrimSpace(data[:min(len(data), 1024)]))
+	return bytes.HasPrefix(start, []byte("<!doctype")) || bytes.HasPrefix(start, []byte("<html")) ||
+		bytes.HasPrefix(start, []byte("<meta")) || bytes.HasPrefix(start, []byte("<dl"))
+}
+
+func isWebURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// parseImportHTML reads a Pocket HTML export or a Netscape bookmark file. Both
+// list the entries as links with the date they were saved and their tags in
+// attributes. Pocket puts read articles under a "Read Archive" heading.
+func parseImportHTML(data []byte) ([]models.ImportItem, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var items []models.ImportItem
+	read := false
+	doc.Find("h1, a[href]").Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "h1" {
+			read = strings.Contains(strings.ToLower(s.Text()), "archive")
+			return
+		}
+		href, _ := s.Attr("href")
+		added := s.AttrOr("time_added", s.AttrOr("add_date", ""))
+		items = append(items, models.ImportItem{
+			URL:     href,
+			Title:   strings.TrimSpace(s.Text()),
+			Tags:    splitImportTags(s.AttrOr("tags", ""), ","),
+			Read:    read,
+			SavedAt: unixTime(added),
+		})
+	})
+	return items, nil
+}
+
+// parsePocketCSV reads a Pocket CSV export, with the columns title, url,
+// time_added, tags (separated by "|") and status ("unread" or "archive").
+func parsePocketCSV(data []byte) ([]models.ImportItem, error) {
+	return parseImportCSV(data, []string{"url"}, func(get func(string) string) models.ImportItem {
+		return models.ImportItem{
+			URL:     get("url"),
+			Title:   get("title"),
+			Tags:    splitImportTags(get("tags"), "|"),
+			Read:    strings.EqualFold(get("status"), "archive"),
+			SavedAt: unixTime(get("time_added")),
+		}
+	})
+}
+
+// parseInstapaperCSV reads an Instapaper CSV export, with the columns URL,
+// Title, Selection, Folder, Timestamp and, in newer exports, Tags. Articles in
+// the Archive folder are read; custom folders become tags.
+func parseInstapaperCSV(data []byte) ([]models.ImportItem, error) {
+	return parseImportCSV(data, []string{"url", "folder"}, func(get func(string) string) models.ImportItem {
+		folder := get("folder")
+		tags := parseInstapaperTags(get("tags"))
+		switch strings.ToLower(folder) {
+		case "", "unread", "archive", "starred":
+		default:
+			tags = append(tags, folder)
+		}
+		return models.ImportItem{
+			URL:     get("url"),
+			Title:   get("title"),
+			Tags:    tags,
+			Read:    strings.EqualFold(folder, "archive"),
+			SavedAt: unixTime(get("timestamp")),
+		}
+	})
+}
+
+// parseInstapaperTags reads the Tags column, a JSON array in current exports.
+func parseInstapaperTags(value string) []string {
+	var tags []string
+	if err := json.Unmarshal([]byte(value), &tags); err == nil {
+		return tags
+	}
+	return splitImportTags(value, ",")
+}
+
+// parseImportCSV reads a CSV file with a header row, which must contain the
+// required columns (matched case-insensitively), converting each row with item.
+func parseImportCSV(data []byte, required []string, item func(get func(string) string) models.ImportItem) ([]models.ImportItem, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff")))) // Skip a byte order mark
+	reader.FieldsPerRecord = -1                                                        // Some exporters leave out trailing empty fields
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := csvColumns(header)
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV has no %q column", name)
+		}
+	}
+
+	var items []models.ImportItem
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		items = append(items, item(get))
+	}
+	return items, nil
+}
+
+func csvColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	return columns
+}
+
+// omnivoreItem is an entry of Omnivore's metadata JSON.
+type omnivoreItem struct {
+	URL             string          `json:"url"`
+	Title           string          `json:"title"`
+	Labels          json.RawMessage `json:"labels"` // Names, or objects with a name
+	State           string          `json:"state"`
+	ReadingProgress float64         `json:"readingProgress"`
+	SavedAt         string          `json:"savedAt"`
+}
+
+// parseOmnivore reads Omnivore's metadata JSON, or the export ZIP, which splits
+// it across metadata_*.json files. Archived and fully read articles are read.
+func parseOmnivore(data []byte) ([]models.ImportItem, error) {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return parseOmnivoreJSON(data)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ZIP: %w", err)
+	}
+	var items []models.ImportItem
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if !strings.HasPrefix(name, "metadata_") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+		}
+		fileItems, err := parseOmnivoreJSON(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		items = append(items, fileItems...)
+	}
+	return items, nil
+}
+
+func parseOmnivoreJSON(data []byte) ([]models.ImportItem, error) {
+	var entries []omnivoreItem
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	items := make([]models.ImportItem, 0, len(entries))
+	for _, entry := range entries {
+		item := models.ImportItem{
+			URL:   entry.URL,
+			Title: strings.TrimSpace(entry.Title),
+			Tags:  omnivoreLabels(entry.Labels),
+			Read:  strings.EqualFold(entry.State, "archived") || entry.ReadingProgress >= 100,
+		}
+		if savedAt, err := time.Parse(time.RFC3339, entry.SavedAt); err == nil {
+			item.SavedAt = savedAt.UTC()
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func omnivoreLabels(raw json.RawMessage) []string {
+	var names []string
+	if err := json.Unmarshal(raw, &names); err == nil {
+		return names
+	}
+	var labels []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &labels); err != nil {
+		return nil
+	}
+	names = make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+func splitImportTags(value, sep string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, sep) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// unixTime parses a Unix timestamp in seconds, returning the zero time if it isn't one.
+func unixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
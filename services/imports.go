@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// importProgressInterval is how many entries an import handles between saving
+// its progress. After a restart, at most this many entries are looked at
+// again; the ones already imported are then skipped as duplicates.
+const importProgressInterval = 25
+
+// ImportRunStore is the storage the importer needs: the imports and the
+// articles they create.
+type ImportRunStore interface {
+	models.ArticleStore
+	models.ImportStore
+}
+
+// Importer runs imports in the background, saving the imported articles and
+// queueing them for processing at a limited rate.
+type Importer struct {
+	store   ImportRunStore
+	queue   *WorkerPool
+	limiter *time.Ticker // Shared by all imports, so together they queue at most one article per tick
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewImporter creates an importer that queues articles on queue, waiting
+// interval between articles.
+func NewImporter(store ImportRunStore, queue *WorkerPool, interval time.Duration) *Importer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Importer{store: store, queue: queue, limiter: time.NewTicker(interval), ctx: ctx, cancel: cancel}
+}
+
+// ParseImport reads the entries of an export file; see ParseImport.
+func (im *Importer) ParseImport(format string, data []byte) (string, []models.ImportItem, error) {
+	return ParseImport(format, data)
+}
+
+// StartImport records an import of the entries and runs it in the background.
+func (im *Importer) StartImport(ctx context.Context, userID, format string, items []models.ImportItem) (*models.Import, error) {
+	imp := &models.Import{UserID: userID, Format: format, Status: models.ImportStatusRunning}
+	if err := im.store.CreateImport(ctx, imp, items); err != nil {
+		return nil, err
+	}
+	im.launch(*imp, items)
+	return imp, nil
+}
+
+// Recover resumes the imports that were running when the process stopped.
+func (im *Importer) Recover(ctx context.Context) error {
+	imports, err := im.store.GetRunningImports(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range imports {
+		items, err := im.store.GetImportItems(ctx, imports[i].ID)
+		if err != nil {
+			return err
+		}
+		im.launch(imports[i], items)
+	}
+	if len(imports) > 0 {
+		log.Printf("Resumed %d interrupted imports", len(imports))
+	}
+	return nil
+}
+
+// Stop interrupts the running imports and waits until they have saved their
+// progress or ctx expires. They resume on the next start.
+func (im *Importer) Stop(ctx context.Context) error {
+	im.cancel()
+	defer im.limiter.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		im.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (im *Importer) launch(imp models.Import, items []models.ImportItem) {
+	im.wg.Add(1)
+	go func() {
+		defer im.wg.Done()
+		im.run(im.ctx, &imp, items)
+	}()
+}
+
+// run imports the entries after the ones already processed, saving its progress as it goes.
+func (im *Importer) run(ctx context.Context, imp *models.Import, items []models.ImportItem) {
+	// Progress must be recorded even when ctx is cancelled by shutdown
+	dbCtx := context.WithoutCancel(ctx)
+
+	log.Printf("Importing %d of %d entries for import %s", len(items)-imp.Processed, len(items), imp.ID)
+	for imp.Processed < len(items) {
+		item := items[imp.Processed]
+		duplicate, err := im.importItem(ctx, imp.UserID, item)
+		if ctx.Err() != nil {
+			// Shutting down: the entry is imported again on the next start
+			im.saveProgress(dbCtx, imp)
+			return
+		}
+		switch {
+		case err != nil:
+			imp.Failed++
+			if len(imp.Errors) < models.MaxImportErrors {
+				imp.Errors = append(imp.Errors, models.ImportError{Entry: imp.Processed + 1, URL: item.URL, Error: err.Error()})
+			}
+		case duplicate:
+			imp.Duplicates++
+		default:
+			imp.Imported++
+		}
+		imp.Processed++
+		if imp.Processed%importProgressInterval == 0 {
+			im.saveProgress(dbCtx, imp)
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	imp.Status = models.ImportStatusCompleted
+	imp.CompletedAt = &completedAt
+	im.saveProgress(dbCtx, imp)
+	log.Printf("Finished import %s: %d imported, %d duplicates, %d failed", imp.ID, imp.Imported, imp.Duplicates, imp.Failed)
+}
+
+func (im *Importer) saveProgress(ctx context.Context, imp *models.Import) {
+	if err := im.store.UpdateImport(ctx, imp); err != nil {
+		log.Printf("Error saving progress of import %s: %v", imp.ID, err)
+	}
+}
+
+// importItem saves an entry as a new article and queues it for processing,
+// unless the user already has an article with its URL.
+func (im *Importer) importItem(ctx context.Context, userID string, item models.ImportItem) (duplicate bool, err error) {
+	if item.Error != "" {
+		return false, errors.New(item.Error)
+	}
+	exists, err := im.store.ArticleURLExists(ctx, userID, item.URL)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-im.limiter.C:
+	}
+
+	// Save and queue the article together, even if shutdown starts in between
+	dbCtx := context.WithoutCancel(ctx)
+	article := &models.Article{
+		UserID:    userID,
+		URL:       item.URL,
+		Title:     item.Title, // Replaced by the page's title once processed
+		Tags:      item.Tags,
+		Status:    "processing",
+		CreatedAt: item.SavedAt,
+	}
+	if item.Read {
+		article.Status = "read" // Processing keeps it read
+	}
+	if err := im.store.SaveArticle(dbCtx, article); err != nil {
+		return false, err
+	}
+	if err := im.queue.EnqueueArticle(dbCtx, article); err != nil {
+		return false, err
+	}
+	notifyWebhooks(dbCtx, models.WebhookEventArticleCreated, article)
+	return false, nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// leadImageMaxBytes caps the size of the lead images kept for exports; larger
+// ones are left out rather than bloating every export they appear in.
+const leadImageMaxBytes = 5 << 20
+
+// leadImageTypes are the image types e-readers are required to display.
+var leadImageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true}
+
+// cacheLeadImage keeps a copy of the article's lead image, replacing the copy
+// of a previous one. Failures are logged, not returned: the article is complete
+// without it.
+func cacheLeadImage(ctx context.Context, images models.ArticleImageStore, article *models.Article) {
+	if article.ImageURL == "" {
+		if err := images.DeleteArticleImage(ctx, article.ID); err != nil {
+			log.Printf("Error deleting lead image of article %s: %v", article.ID, err)
+		}
+		return
+	}
+
+	cached, err := images.GetArticleImage(ctx, article.ID, article.UserID)
+	if err != nil {
+		log.Printf("Error checking lead image of article %s: %v", article.ID, err)
+		return
+	}
+	if cached != nil && cached.SourceURL == article.ImageURL {
+		return // Reprocessed with the same image
+	}
+
+	result, err := fetcher.Fetch(ctx, article.ImageURL)
+	if err != nil {
+		log.Printf("Could not fetch lead image of article %s: %v", article.ID, err)
+		return
+	}
+	// Sniff the type rather than trusting the header, which is often generic or wrong
+	contentType := http.DetectContentType(result.Body)
+	if !leadImageTypes[contentType] || len(result.Body) > leadImageMaxBytes {
+		log.Printf("Not keeping lead image of article %s: %s of %d bytes", article.ID, contentType, len(result.Body))
+		return
+	}
+
+	img := &models.ArticleImage{ArticleID: article.ID, SourceURL: article.ImageURL, ContentType: contentType, Data: result.Body}
+	if err := images.SaveArticleImage(ctx, img); err != nil {
+		log.Printf("Error saving lead image of article %s: %v", article.ID, err)
+	}
+}
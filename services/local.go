@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// localSummarySentences is how many sentences the extractive summarizer keeps.
+	localSummarySentences = 3
+	// localTagCount is how many keywords the local tagger returns.
+	localTagCount = 5
+	// localMinKeywordLength ignores short words that rarely make useful tags.
+	localMinKeywordLength = 4
+)
+
+// stopWords are common English words ignored when scoring sentences and picking keywords.
+var stopWords = map[string]struct{}{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all also am an and any are as at be because been
+		before being below between both but by can could did do does doing down during each few for from further had has
+		have having he her here hers herself him himself his how however i if in into is it its itself just like may me
+		might more most must my myself new no nor not now of off on once one only or other our ours ourselves out over own
+		same says she should so some such than that the their theirs them themselves then there these they this those
+		through to too under until up us use used using very was we were what when where which while who whom why will
+		with would you your yours yourself yourselves many much make made get got also still even well back way want
+		said per via within without across among around`) {
+		stopWords[w] = struct{}{}
+	}
+}
+
+// LocalProvider summarizes and tags articles without any network access, using
+// a frequency-based extractive summarizer and keyword tagger.
+type LocalProvider struct{}
+
+// NewLocalProvider creates the offline provider.
+func NewLocalProvider() *LocalProvider {
+	return &LocalProvider{}
+}
+
+// Name identifies the provider in logs.
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// Summarize picks the highest-scoring sentences of the text, in their original order.
+// Text too short to have sentences is its own summary.
+func (p *LocalProvider) Summarize(ctx context.Context, text string) (string, error) {
+	sentences := splitSentences(text)
+	if len(sentences) == 0 {
+		return strings.Join(strings.Fields(text), " "), nil
+	}
+	if len(sentences) <= localSummarySentences {
+		return strings.Join(sentences, " "), nil
+	}
+
+	freq := wordFrequencies(text)
+
+	type scored struct {
+		index int
+		score float64
+	}
+	scores := make([]scored, len(sentences))
+	for i, sentence := range sentences {
+		words := tokenize(sentence)
+		var total float64
+		for _, w := range words {
+			total += float64(freq[w])
+		}
+		if len(words) > 0 {
+			// Normalize by length so long sentences don't always win
+			total /= float64(len(words))
+		}
+		scores[i] = scored{index: i, score: total}
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+	picked := scores[:localSummarySentences]
+	sort.Slice(picked, func(i, j int) bool { return picked[i].index < picked[j].index })
+
+	summary := make([]string, len(picked))
+	for i, s := range picked {
+		summary[i] = sentences[s.index]
+	}
+	return strings.Join(summary, " "), nil
+}
+
+// Tag returns the most frequent meaningful words of the text, or none if it
+// has no such words.
+func (p *LocalProvider) Tag(ctx context.Context, text string) ([]string, error) {
+	freq := wordFrequencies(text)
+	if len(freq) == 0 {
+		return nil, nil
+	}
+
+	words := make([]string, 0, len(freq))
+	for w := range freq {
+		if len(w) >= localMinKeywordLength {
+			words = append(words, w)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if freq[words[i]] != freq[words[j]] {
+			return freq[words[i]] > freq[words[j]]
+		}
+		return words[i] < words[j] // Deterministic order for ties
+	})
+	if len(words) > localTagCount {
+		words = words[:localTagCount]
+	}
+	return words, nil
+}
+
+// tokenize lowercases text and splits it into words, dropping stop words and numbers.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	})
+	words := fields[:0]
+	for _, w := range fields {
+		w = strings.Trim(w, "-'")
+		if w == "" {
+			continue
+		}
+		if _, stop := stopWords[w]; stop {
+			continue
+		}
+		if strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// wordFrequencies counts occurrences of each meaningful word in text.
+func wordFrequencies(text string) map[string]int {
+	freq := make(map[string]int)
+	for _, w := range tokenize(text) {
+		freq[w]++
+	}
+	return freq
+}
+
+// splitSentences breaks text into sentences on terminal punctuation and line breaks,
+// collapsing whitespace left over from HTML extraction.
+func splitSentences(text string) []string {
+	var sentences []string
+	for _, block := range strings.Split(text, "\n") {
+		block = strings.Join(strings.Fields(block), " ")
+		start := 0
+		for i := 0; i < len(block); i++ {
+			if block[i] != '.' && block[i] != '!' && block[i] != '?' {
+				continue
+			}
+			// A sentence ends at punctuation followed by a space or the end of the block
+			if i+1 < len(block) && block[i+1] != ' ' {
+				continue
+			}
+			sentences = appendSentence(sentences, block[start:i+1])
+			start = i + 1
+		}
+		sentences = appendSentence(sentences, block[start:])
+	}
+	return sentences
+}
+
+// appendSentence keeps sentences that contain enough words to be worth summarizing.
+func appendSentence(sentences []string, s string) []string {
+	s = strings.TrimSpace(s)
+	if len(strings.Fields(s)) < 4 {
+		return sentences
+	}
+	return append(sentences, s)
+}
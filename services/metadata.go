@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// pageMetadata is what a page says about itself in its head: JSON-LD, OpenGraph,
+// Twitter Card and plain <meta> tags, in that order of preference.
+type pageMetadata struct {
+	Title        string
+	Author       string
+	PublishedAt  *time.Time
+	SiteName     string
+	ImageURL     string
+	Description  string
+	Language     string
+	CanonicalURL string
+}
+
+// jsonLDArticleTypes are the schema.org types whose properties describe the article.
+var jsonLDArticleTypes = map[string]bool{
+	"Article": true, "NewsArticle": true, "BlogPosting": true, "TechArticle": true, "ScholarlyArticle": true,
+	"Report": true, "AnalysisNewsArticle": true, "OpinionNewsArticle": true, "ReportageNewsArticle": true,
+	"SocialMediaPosting": true, "LiveBlogPosting": true,
+}
+
+// publishedDateLayouts are the date formats found in the wild, most common first.
+var publishedDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+}
+
+// extractMetadata reads the page's metadata. URLs are resolved against base.
+func extractMetadata(doc *goquery.Document, base *url.URL) pageMetadata {
+	meta := func(keys ...string) string {
+		for _, key := range keys {
+			var value string
+			doc.Find("meta").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+				name := s.AttrOr("property", s.AttrOr("name", s.AttrOr("itemprop", s.AttrOr("http-equiv", ""))))
+				if strings.EqualFold(name, key) {
+					value = strings.TrimSpace(s.AttrOr("content", ""))
+				}
+				return value == ""
+			})
+			if value != "" {
+				return value
+			}
+		}
+		return ""
+	}
+
+	ld := jsonLDArticle(doc)
+	md := pageMetadata{
+		Title:       firstNonEmpty(ld.Headline, meta("og:title", "twitter:title")),
+		Author:      firstNonEmpty(ld.author(), meta("author", "article:author", "citation_author", "parsely-author", "dc.creator", "twitter:creator")),
+		SiteName:    firstNonEmpty(ld.Publisher.Name, meta("og:site_name", "application-name", "twitter:site")),
+		Description: firstNonEmpty(ld.Description, meta("og:description", "twitter:description", "description", "dc.description")),
+		Language:    firstNonEmpty(ld.InLanguage, doc.Find("html").AttrOr("lang", ""), meta("og:locale", "content-language", "language", "dc.language")),
+	}
+	// article:author is often a profile URL rather than a name
+	if strings.HasPrefix(md.Author, "http://") || strings.HasPrefix(md.Author, "https://") {
+		md.Author = ""
+	}
+	md.Author = strings.TrimPrefix(md.Author, "@")
+	md.Language = strings.ReplaceAll(strings.TrimSpace(md.Language), "_", "-")
+
+	published := firstNonEmpty(ld.DatePublished, meta("article:published_time", "datePublished", "citation_publication_date",
+		"dc.date.issued", "dc.date", "pubdate", "publishdate", "publish-date", "date", "parsely-pub-date"))
+	if published == "" {
+		published = doc.Find("time[datetime]").First().AttrOr("datetime", "")
+	}
+	md.PublishedAt = parsePublishedDate(published)
+
+	md.ImageURL = resolveURL(base, firstNonEmpty(ld.image(), meta("og:image", "og:image:url", "og:image:secure_url", "twitter:image", "twitter:image:src")))
+	md.CanonicalURL = resolveURL(base, firstNonEmpty(doc.Find(`link[rel="canonical"]`).AttrOr("href", ""), meta("og:url"), ld.URL))
+	return md
+}
+
+// jsonLDObject holds the schema.org properties we read. Several of them may be
+// a string, an object or an array, so they are decoded lazily.
+type jsonLDObject struct {
+	Type          json.RawMessage `json:"@type"`
+	Graph         []jsonLDObject  `json:"@graph"`
+	Headline      string          `json:"headline"`
+	Author        json.RawMessage `json:"author"`
+	DatePublished string          `json:"datePublished"`
+	Publisher     struct {
+		Name string `json:"name"`
+	} `json:"publisher"`
+	Image       json.RawMessage `json:"image"`
+	Description string          `json:"description"`
+	InLanguage  string          `json:"inLanguage"`
+	URL         string          `json:"url"`
+}
+
+// jsonLDArticle returns the first Article-like JSON-LD object on the page, or
+// an empty object when there is none.
+func jsonLDArticle(doc *goquery.Document) jsonLDObject {
+	var found jsonLDObject
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		data := []byte(strings.TrimSpace(s.Text()))
+		var objects []jsonLDObject
+		if err := json.Unmarshal(data, &objects); err != nil {
+			var single jsonLDObject
+			if err := json.Unmarshal(data, &single); err != nil {
+				return true // Malformed JSON-LD is common; ignore it
+			}
+			objects = []jsonLDObject{single}
+		}
+		for len(objects) > 0 {
+			o := objects[0]
+			objects = append(objects[1:], o.Graph...)
+			if o.isArticle() {
+				found = o
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+func (o jsonLDObject) isArticle() bool {
+	var types []string
+	if err := json.Unmarshal(o.Type, &types); err != nil {
+		var t string
+		if json.Unmarshal(o.Type, &t) != nil {
+			return false
+		}
+		types = []string{t}
+	}
+	for _, t := range types {
+		if jsonLDArticleTypes[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// author returns the names of the article's authors, comma separated.
+func (o jsonLDObject) author() string {
+	return strings.Join(jsonLDNames(o.Author, "name"), ", ")
+}
+
+// image returns the URL of the article's first image.
+func (o jsonLDObject) image() string {
+	if urls := jsonLDNames(o.Image, "url"); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// jsonLDNames reads a property that is a string, an object with the given
+// field, or an array of either.
+func jsonLDNames(raw json.RawMessage, field string) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if s = strings.TrimSpace(s); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) == nil {
+		return jsonLDNames(obj[field], field)
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(raw, &list) != nil {
+		return nil
+	}
+	var names []string
+	for _, item := range list {
+		names = append(names, jsonLDNames(item, field)...)
+	}
+	return names
+}
+
+// parsePublishedDate parses a publication date in any of publishedDateLayouts.
+func parsePublishedDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range publishedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// resolveURL makes ref absolute, returning "" unless it is an http(s) URL.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
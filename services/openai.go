@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider summarizes and tags articles through any server that
+// implements the OpenAI chat completions API, including local model servers.
+type OpenAIProvider struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAIProvider creates a provider that talks to the chat completions
+// endpoint under baseURL. The API key is optional for local servers.
+func NewOpenAIProvider(baseURL, apiKey, model string) (*OpenAIProvider, error) {
+	if baseURL == "" {
+		return nil, errors.New("no base URL configured (ai.openai.base_url or OPENAI_BASE_URL)")
+	}
+	if model == "" {
+		return nil, errors.New("no model configured (ai.openai.model or OPENAI_MODEL)")
+	}
+	return &OpenAIProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// Name identifies the provider in logs.
+func (p *OpenAIProvider) Name() string {
+	return "openai-compatible (" + p.model + " at " + p.baseURL + ")"
+}
+
+// Summarize asks the model for a summary of the text.
+func (p *OpenAIProvider) Summarize(ctx context.Context, text string) (string, error) {
+	return p.complete(ctx, summarizePrompt+text)
+}
+
+// Tag asks the model for a comma-separated list of tags for the text.
+func (p *OpenAIProvider) Tag(ctx context.Context, text string) ([]string, error) {
+	response, err := p.complete(ctx, tagPrompt+text)
+	if err != nil {
+		return nil, err
+	}
+	return parseTagList(response), nil
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) complete(ctx context.Context, prompt string) (string, error) {
+	payload, err := json.Marshal(chatCompletionRequest{
+		Model:    p.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode chat completion request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("failed to build chat completion request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("chat completion request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("chat completion request failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var completion chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("failed to decode chat completion response: %w", err)
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", errors.New("chat completion returned an empty response")
+	}
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
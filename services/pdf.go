@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ErrUnreadablePDF is returned for PDFs that can't be parsed, are encrypted or
+// contain no text, such as scanned documents.
+var ErrUnreadablePDF = errors.New("unreadable PDF")
+
+// Layout thresholds, relative to the font size, for turning positioned glyphs
+// back into words, lines and paragraphs.
+const (
+	pdfWordGap      = 0.15 // Horizontal gap between glyphs that starts a new word
+	pdfLineGap      = 0.5  // Vertical move that starts a new line
+	pdfParagraphGap = 1.6  // Vertical move that starts a new paragraph
+)
+
+// maxPDFTextPages bounds how many pages text is extracted from. Extraction is
+// slow, typically a few hundred milliseconds a page, and the opening pages are
+// enough to summarize and tag a long document.
+const maxPDFTextPages = 100
+
+// pdfLigatures expands the ligature glyphs typeset PDFs are full of, so words
+// like "ﬁle" can be searched and tagged.
+var pdfLigatures = strings.NewReplacer("ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl", "ﬅ", "st", "ﬆ", "st")
+
+// pdfFileTitleExts are extensions of the source files that PDF producers often
+// put in the title field instead of a real title.
+var pdfFileTitleExts = map[string]bool{
+	".doc": true, ".docx": true, ".odt": true, ".rtf": true, ".tex": true, ".dvi": true,
+	".ppt": true, ".pptx": true, ".indd": true, ".pdf": true, ".txt": true,
+}
+
+// parsePDFArticle extracts the text and document information of a PDF. The
+// title comes from the document information, or else the first line of text.
+// Extraction stops between pages once ctx is done.
+func parsePDFArticle(ctx context.Context, body []byte) (parsed *parsedArticle, err error) {
+	// The PDF library panics on malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			parsed, err = nil, fmt.Errorf("%w: %v", ErrUnreadablePDF, r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadablePDF, err)
+	}
+
+	var paragraphs []string
+	pageCount := reader.NumPage()
+	for i := 1; i <= pageCount && i <= maxPDFTextPages; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("PDF text extraction stopped at page %d: %w", i, err)
+		}
+		paragraphs = append(paragraphs, pdfPageParagraphs(reader.Page(i))...)
+	}
+	if len(paragraphs) == 0 {
+		return nil, fmt.Errorf("%w: no text found", ErrUnreadablePDF)
+	}
+
+	info := reader.Trailer().Key("Info")
+	title := pdfTitle(info.Key("Title").Text())
+	if title == "" {
+		title = textTitle(strings.SplitN(paragraphs[0], "\n", 2)[0])
+	}
+	for i, paragraph := range paragraphs {
+		paragraphs[i] = joinPDFLines(paragraph)
+	}
+	return &parsedArticle{
+		Title: title,
+		Metadata: pageMetadata{
+			Author:      strings.TrimSpace(info.Key("Author").Text()),
+			Description: strings.TrimSpace(info.Key("Subject").Text()),
+			Language:    strings.TrimSpace(reader.Trailer().Key("Root").Key("Lang").Text()),
+		},
+		Content:   paragraphContent(paragraphs),
+		PageCount: pageCount,
+	}, nil
+}
+
+// pdfPageParagraphs lays out a page's glyphs as paragraphs of lines. PDFs only
+// position individual glyphs, so spaces and line breaks are inferred from the
+// gaps between them.
+func pdfPageParagraphs(page pdf.Page) []string {
+	var paragraphs []string
+	var current strings.Builder
+	flush := func() {
+		if p := strings.TrimSpace(current.String()); p != "" {
+			paragraphs = append(paragraphs, p)
+		}
+		current.Reset()
+	}
+
+	// The library appends a "\n" to every run of text, decoded with the run's
+	// font like any other character code, so it can come out as any glyph
+	lineEnds := map[string]string{"": "\n"}
+	for _, name := range page.Fonts() {
+		font := page.Font(name)
+		if enc := font.Encoder(); enc != nil {
+			baseFont := font.BaseFont()
+			lineEnds[baseFont[strings.Index(baseFont, "+")+1:]] = enc.Decode("\n")
+		}
+	}
+
+	var prev *pdf.Text
+	texts := page.Content().Text
+	for i := range texts {
+		t := &texts[i]
+		if t.S == "\n" || t.S == lineEnds[t.Font] {
+			continue
+		}
+		if prev != nil {
+			size := math.Max(prev.FontSize, 1)
+			dy := math.Abs(t.Y - prev.Y)
+			switch {
+			case dy > size*pdfParagraphGap:
+				flush()
+			case dy > size*pdfLineGap:
+				current.WriteByte('\n')
+			case t.X-(prev.X+prev.W) > size*pdfWordGap:
+				current.WriteByte(' ')
+			}
+		}
+		current.WriteString(pdfLigatures.Replace(t.S))
+		prev = t
+	}
+	flush()
+	return paragraphs
+}
+
+// joinPDFLines reflows a paragraph's lines, rejoining words hyphenated at the
+// end of a line.
+func joinPDFLines(paragraph string) string {
+	var joined string
+	for _, line := range strings.Split(paragraph, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		switch {
+		case line == "":
+		case joined == "":
+			joined = line
+		case strings.HasSuffix(joined, "-") && !strings.HasSuffix(joined, " -"):
+			joined = strings.TrimSuffix(joined, "-") + line
+		default:
+			joined += " " + line
+		}
+	}
+	return joined
+}
+
+// pdfTitle returns the document's title field, or "" when it is just the name
+// of the file the PDF was made from.
+func pdfTitle(title string) string {
+	title = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(title), "Microsoft Word - "))
+	if pdfFileTitleExts[strings.ToLower(path.Ext(title))] {
+		return ""
+	}
+	return textTitle(title)
+}
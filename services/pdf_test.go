@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readPDF(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "content", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestParsePDFArticle(t *testing.T) {
+	parsed, err := parsePDFArticle(context.Background(), readPDF(t, "small.pdf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The title field is the name of a Word file, so the first line is used
+	if want := "A Small PDF"; parsed.Title != want {
+		t.Errorf("title = %q, want %q", parsed.Title, want)
+	}
+	if parsed.PageCount != 2 {
+		t.Errorf("page count = %d, want 2", parsed.PageCount)
+	}
+	wantText := "A Small PDF\n\nThe first page of a document used to test text extraction from PDFs.\n\nThe second page has a paragraph of its own."
+	if parsed.Content.Text != wantText {
+		t.Errorf("text = %q, want %q", parsed.Content.Text, wantText)
+	}
+	if parsed.Metadata.Author != "Ada Lovelace" || parsed.Metadata.Description != "A test document" || parsed.Metadata.Language != "en" {
+		t.Errorf("metadata = %+v, want the author, subject and language of the document", parsed.Metadata)
+	}
+}
+
+func TestParsePDFArticleUnreadable(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"truncated", readPDF(t, "truncated.pdf")},
+		{"malformed page content", readPDF(t, "corrupt.pdf")}, // Makes the PDF library panic
+		{"not a PDF", []byte("%PDF-1.4\nthis is not a PDF")},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parsePDFArticle(context.Background(), tt.body)
+			if !errors.Is(err, ErrUnreadablePDF) {
+				t.Fatalf("got %v, want ErrUnreadablePDF", err)
+			}
+			if !isPermanent(err) {
+				t.Errorf("isPermanent(%v) = false, want true", err)
+			}
+		})
+	}
+}
+
+func TestParsePDFArticleCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := parsePDFArticle(ctx, readPDF(t, "small.pdf"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if isPermanent(err) {
+		t.Errorf("isPermanent(%v) = true, want false", err)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+)
+
+// Prompts shared by the LLM-backed providers.
+const (
+	summarizePrompt = "Summarize the following article: "
+	tagPrompt       = "Generate a comma-separated list of tags for the following article: "
+)
+
+// Summarizer produces a short summary of an article's text.
+type Summarizer interface {
+	Summarize(ctx context.Context, text string) (string, error)
+}
+
+// Tagger produces a list of topical tags for an article's text.
+type Tagger interface {
+	Tag(ctx context.Context, text string) ([]string, error)
+}
+
+// Provider is a backend that can both summarize and tag articles.
+type Provider interface {
+	Summarizer
+	Tagger
+	Name() string
+}
+
+// provider is the backend used by ProcessNewArticle. It defaults to the
+// offline local provider so processing never depends on network access.
+var provider Provider = NewLocalProvider()
+
+// SetProvider replaces the backend used to summarize and tag articles.
+func SetProvider(p Provider) {
+	provider = p
+	log.Printf("Using %s summarizer/tagger provider", p.Name())
+}
+
+// ProviderFromConfig builds the provider selected by the ai section of the configuration.
+// Misconfigured remote providers fall back to the local provider instead of
+// stopping the server.
+func ProviderFromConfig() Provider {
+	cfg := config.Current.AI
+	name := strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if name == "" {
+		name = "local"
+		if cfg.Gemini.APIKey != "" {
+			name = "gemini"
+		}
+	}
+
+	switch name {
+	case "gemini":
+		p, err := NewGeminiProvider(context.Background(), cfg.Gemini.APIKey, cfg.Gemini.Model)
+		if err == nil {
+			return p
+		}
+		log.Printf("Gemini provider unavailable, falling back to local provider: %v", err)
+	case "openai":
+		p, err := NewOpenAIProvider(cfg.OpenAI.BaseURL, cfg.OpenAI.APIKey, cfg.OpenAI.Model)
+		if err == nil {
+			return p
+		}
+		log.Printf("OpenAI-compatible provider unavailable, falling back to local provider: %v", err)
+	case "local":
+	default:
+		log.Printf("Unknown AI provider %q, falling back to local provider", name)
+	}
+	return NewLocalProvider()
+}
+
+// parseTagList splits a comma-separated list of tags as returned by an LLM.
+func parseTagList(text string) []string {
+	var tags []string
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
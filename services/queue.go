@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+const (
+	// retryBaseDelay is the delay before the first retry; it doubles with every attempt.
+	retryBaseDelay = 30 * time.Second
+	// retryMaxDelay caps the exponential backoff between attempts.
+	retryMaxDelay = 30 * time.Minute
+	// pollInterval is how often idle workers check for due jobs (e.g. scheduled retries).
+	pollInterval = 5 * time.Second
+)
+
+// JobQueueStore is the storage the worker pool needs: the job queue and the
+// articles the jobs process.
+type JobQueueStore interface {
+	ArticleProcessStore
+	models.JobStore
+}
+
+// WorkerPool processes articles from the persistent job queue with a bounded number of workers.
+type WorkerPool struct {
+	store  JobQueueStore
+	size   int
+	wake   chan struct{} // Signals idle workers that a new job was enqueued
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWorkerPool creates a pool that runs at most size jobs concurrently.
+func NewWorkerPool(store JobQueueStore, size int) *WorkerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &WorkerPool{store: store, size: size, wake: make(chan struct{}, 1)}
+}
+
+// EnqueueArticle persists a processing job for the article and wakes up a worker.
+// It returns models.ErrProcessingJobActive if the article's job is still pending or running.
+func (p *WorkerPool) EnqueueArticle(ctx context.Context, article *models.Article) error {
+	if err := p.store.EnqueueProcessingJob(ctx, article.ID, article.UserID, config.Current.Workers.MaxAttempts); err != nil {
+		return err
+	}
+	publishArticleEvent(models.EventArticleQueued, article)
+	select {
+	case p.wake <- struct{}{}:
+	default: // A wake-up is already pending
+	}
+	return nil
+}
+
+// Recover re-enqueues work left behind by a previous run: jobs that were
+// running when the process stopped, and articles stuck in "processing"
+// without any job at all.
+func (p *WorkerPool) Recover(ctx context.Context) error {
+	requeued, err := p.store.RequeueRunningProcessingJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := p.store.GetOrphanedProcessingArticles(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range orphans {
+		err := p.store.EnqueueProcessingJob(ctx, orphans[i].ID, orphans[i].UserID, config.Current.Workers.MaxAttempts)
+		if err != nil && !errors.Is(err, models.ErrProcessingJobActive) { // Queued by another replica meanwhile
+			return err
+		}
+	}
+
+	if requeued > 0 || len(orphans) > 0 {
+		log.Printf("Recovered %d interrupted processing jobs and %d orphaned articles", requeued, len(orphans))
+	}
+	return nil
+}
+
+// Start launches the workers. They run until Stop is called.
+func (p *WorkerPool) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	for i := 0; i < p.size; i++ {
+		p.wg.Add(1)
+		go p.worker(ctx)
+	}
+	log.Printf("Started %d article processing workers", p.size)
+}
+
+// Stop signals the workers to exit and waits for in-flight jobs until ctx expires.
+// Jobs that are interrupted are picked up again on the next start.
+func (p *WorkerPool) Stop(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *WorkerPool) worker(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain all due jobs before going idle
+		for ctx.Err() == nil {
+			job, err := p.store.ClaimNextProcessingJob(ctx)
+			if err != nil {
+				log.Printf("Error claiming processing job: %v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			p.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run processes a single claimed job and records the outcome.
+func (p *WorkerPool) run(ctx context.Context, job *models.ProcessingJob) {
+	// Bookkeeping must be recorded even when ctx is cancelled by shutdown
+	dbCtx := context.WithoutCancel(ctx)
+
+	article, err := p.store.GetArticleByID(dbCtx, job.ArticleID, job.UserID)
+	if err == nil && article == nil {
+		// The article was deleted after the job was claimed; nothing left to do
+		if err := p.store.FailProcessingJob(dbCtx, job.ArticleID, "article no longer exists"); err != nil {
+			log.Printf("Error updating processing job for article %s: %v", job.ArticleID, err)
+		}
+		return
+	}
+	if err == nil {
+		err = ProcessNewArticle(ctx, p.store, article)
+	}
+
+	if err == nil {
+		if err := p.store.CompleteProcessingJob(dbCtx, job.ArticleID); err != nil {
+			log.Printf("Error completing processing job for article %s: %v", job.ArticleID, err)
+		}
+		notifyWebhooks(dbCtx, models.WebhookEventArticleProcessed, article)
+		return
+	}
+
+	// Shutting down: give the attempt back so the job runs again on the next start
+	if ctx.Err() != nil {
+		if err := p.store.ReleaseProcessingJob(dbCtx, job.ArticleID); err != nil {
+			log.Printf("Error releasing processing job for article %s: %v", job.ArticleID, err)
+		}
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts || isPermanent(err) {
+		log.Printf("Giving up on article %s after %d attempts: %v", job.ArticleID, job.Attempts, err)
+		if err := p.store.FailProcessingJob(dbCtx, job.ArticleID, err.Error()); err != nil {
+			log.Printf("Error failing processing job for article %s: %v", job.ArticleID, err)
+		}
+		if article != nil {
+			article.Status = "failed"
+			article.FailureReason = err.Error()
+			article.FailureStage = failureStage(err)
+			if err := p.store.SaveArticle(dbCtx, article); err != nil {
+				log.Printf("Failed to update article status to 'failed' for article %s: %v", article.ID, err)
+			}
+			publishArticleEvent(models.EventArticleFailed, article)
+			notifyWebhooks(dbCtx, models.WebhookEventArticleFailed, article)
+		}
+		return
+	}
+
+	delay := retryDelay(job.Attempts)
+	log.Printf("Processing attempt %d/%d for article %s failed, retrying in %s: %v", job.Attempts, job.MaxAttempts, job.ArticleID, delay, err)
+	if err := p.store.RetryProcessingJob(dbCtx, job.ArticleID, err.Error(), time.Now().Add(delay)); err != nil {
+		log.Printf("Error rescheduling processing job for article %s: %v", job.ArticleID, err)
+	}
+}
+
+// isPermanent reports whether an error will recur on every attempt, such as a
+// blocked URL, a page that is gone or a document type that can't be processed.
+func isPermanent(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		// Client errors won't go away, except timeouts and rate limits
+		code := statusErr.StatusCode
+		return code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+	}
+	return errors.Is(err, ErrURLNotAllowed) || errors.Is(err, ErrBodyTooLarge) ||
+		errors.Is(err, ErrTooManyRedirects) || errors.Is(err, ErrUnsupportedContentType) || errors.Is(err, ErrUnreadablePDF)
+}
+
+// retryDelay returns the exponential backoff delay after the given number of attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
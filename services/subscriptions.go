@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/config"
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+const (
+	// subscriptionCheckInterval is how often the poller looks for subscriptions that are due.
+	subscriptionCheckInterval = time.Minute
+	// subscriptionPollLease postpones a claimed subscription's next check, so it
+	// is checked again if the process stops while checking it.
+	subscriptionPollLease = 10 * time.Minute
+	// subscriptionMaxBackoff caps the delay between checks of a failing feed.
+	subscriptionMaxBackoff = 24 * time.Hour
+)
+
+// SubscriptionRunStore is the storage the subscription poller needs: the
+// subscriptions and the articles their entries become.
+type SubscriptionRunStore interface {
+	models.ArticleStore
+	models.SubscriptionStore
+}
+
+// SubscriptionPoller checks subscribed feeds when they are due, adding their
+// new entries to the subscribers' reading lists and queueing them for
+// processing.
+type SubscriptionPoller struct {
+	store    SubscriptionRunStore
+	queue    *WorkerPool
+	interval time.Duration // Between checks of a feed
+	maxNew   int           // Articles added per check at most
+	wake     chan struct{} // Signals that a subscription became due
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewSubscriptionPoller creates a poller that queues articles on queue.
+func NewSubscriptionPoller(store SubscriptionRunStore, queue *WorkerPool, cfg config.SubscriptionsConfig) *SubscriptionPoller {
+	return &SubscriptionPoller{
+		store:    store,
+		queue:    queue,
+		interval: cfg.PollInterval,
+		maxNew:   cfg.MaxNewEntries,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Wake makes the poller look for due subscriptions now, such as one that was
+// just created.
+func (sp *SubscriptionPoller) Wake() {
+	select {
+	case sp.wake <- struct{}{}:
+	default: // A wake-up is already pending
+	}
+}
+
+// Start launches the poller. It runs until Stop is called.
+func (sp *SubscriptionPoller) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sp.cancel = cancel
+	sp.wg.Add(1)
+	go sp.run(ctx)
+	log.Printf("Started subscription poller, checking feeds every %s", sp.interval)
+}
+
+// Stop signals the poller to exit and waits for the check in progress until
+// ctx expires. An interrupted check is made again after a restart.
+func (sp *SubscriptionPoller) Stop(ctx context.Context) error {
+	if sp.cancel == nil {
+		return nil
+	}
+	sp.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		sp.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (sp *SubscriptionPoller) run(ctx context.Context) {
+	defer sp.wg.Done()
+
+	ticker := time.NewTicker(subscriptionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		// Check all due subscriptions before going idle
+		for ctx.Err() == nil {
+			sub, err := sp.store.ClaimNextSubscription(ctx, subscriptionPollLease)
+			if err != nil {
+				log.Printf("Error claiming subscription: %v", err)
+				break
+			}
+			if sub == nil {
+				break
+			}
+			sp.poll(ctx, sub)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sp.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll checks a subscription's feed and records the outcome: on success the
+// next check is a poll interval away; each failure in a row doubles that, up
+// to a day.
+func (sp *SubscriptionPoller) poll(ctx context.Context, sub *models.Subscription) {
+	// The outcome must be recorded even when ctx is cancelled by shutdown
+	dbCtx := context.WithoutCancel(ctx)
+
+	now := time.Now().UTC()
+	added, err := sp.check(ctx, sub)
+	if added > 0 {
+		log.Printf("Added %d articles from subscription %s (%s)", added, sub.ID, sub.URL)
+	}
+	if err != nil {
+		log.Printf("Error checking subscription %s (%s): %v", sub.ID, sub.URL, err)
+		sub.LastError = err.Error()
+		sub.ErrorCount++
+		sub.NextPollAt = now.Add(subscriptionBackoff(sp.interval, sub.ErrorCount))
+	} else {
+		sub.LastPolledAt = &now
+		sub.LastError = ""
+		sub.ErrorCount = 0
+		sub.NextPollAt = now.Add(sp.interval)
+	}
+	if err := sp.store.SaveSubscriptionPoll(dbCtx, sub); err != nil {
+		log.Printf("Error saving check of subscription %s: %v", sub.ID, err)
+	}
+}
+
+// check downloads a subscription's feed unless it is unchanged, and adds the
+// entries the subscription hasn't seen. It returns how many articles it added.
+func (sp *SubscriptionPoller) check(ctx context.Context, sub *models.Subscription) (int, error) {
+	result, err := fetcher.FetchFeed(ctx, sub.URL, sub.ETag, sub.LastModified)
+	if errors.Is(err, ErrNotModified) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	feed, err := parseFeed(result.Body, result.URL)
+	if err != nil {
+		return 0, err
+	}
+	if sub.Title == "" {
+		sub.Title = feed.Title
+	}
+
+	// Save and queue the articles together, even if shutdown starts in between
+	dbCtx := context.WithoutCancel(ctx)
+
+	// Feeds list their newest entries first. The newest new ones are added, and
+	// the rest are skipped for good, so a feed that republishes its archive
+	// doesn't flood the reading list.
+	var newEntries []feedEntry
+	pending := make(map[string]bool) // Keys and URLs of the new entries, which a feed may list twice
+	for _, entry := range feed.Entries {
+		if pending[entry.Key] || pending[entry.URL] {
+			continue
+		}
+		seen, err := sp.store.SubscriptionEntrySeen(dbCtx, sub.ID, entry.Key)
+		if err != nil {
+			return 0, err
+		}
+		if seen {
+			continue
+		}
+		skip := len(newEntries) >= sp.maxNew || !matchesKeywords(entry, sub.Keywords)
+		if !skip {
+			// Already saved, by hand or from another subscription
+			if skip, err = sp.store.ArticleURLExists(dbCtx, sub.UserID, entry.URL); err != nil {
+				return 0, err
+			}
+		}
+		if skip {
+			if err := sp.store.MarkSubscriptionEntrySeen(dbCtx, sub.ID, entry.Key); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		newEntries = append(newEntries, entry)
+		pending[entry.Key], pending[entry.URL] = true, true
+	}
+
+	// Add the oldest first, so the reading list keeps the feed's order. An
+	// entry is only marked seen once its article is saved, so one that fails
+	// is added by the next check.
+	added := 0
+	for i := len(newEntries) - 1; i >= 0; i-- {
+		entry := newEntries[i]
+		article := &models.Article{
+			UserID: sub.UserID,
+			URL:    entry.URL,
+			Title:  entry.Title, // Replaced by the page's title once processed
+			Tags:   sub.Tags,
+			Status: "processing",
+		}
+		if err := sp.store.SaveArticle(dbCtx, article); err != nil {
+			return added, err
+		}
+		added++
+		if err := sp.store.MarkSubscriptionEntrySeen(dbCtx, sub.ID, entry.Key); err != nil {
+			return added, err
+		}
+		if err := sp.queue.EnqueueArticle(dbCtx, article); err != nil {
+			return added, err
+		}
+		notifyWebhooks(dbCtx, models.WebhookEventArticleCreated, article)
+	}
+
+	// Only now the feed's entries are all handled can it be skipped while unchanged
+	sub.ETag = result.Header.Get("ETag")
+	sub.LastModified = result.Header.Get("Last-Modified")
+	return added, nil
+}
+
+// matchesKeywords reports whether an entry's title or text mentions one of the
+// keywords, ignoring case. Every entry matches an empty list.
+func matchesKeywords(entry feedEntry, keywords []string) bool {
+	if len(keywords) == 0 {
+		return true
+	}
+	text := strings.ToLower(entry.Title + " " + entry.Text)
+	for _, keyword := range keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscriptionBackoff returns the delay before checking a feed again after
+// the given number of failed checks in a row.
+func subscriptionBackoff(interval time.Duration, failures int) time.Duration {
+	delay := interval
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= subscriptionMaxBackoff {
+			return subscriptionMaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// TokenSweepInterval is how often expired token revocations and refresh tokens are deleted.
+const TokenSweepInterval = time.Hour
+
+// RunTokenSweeper periodically deletes revoked_tokens and refresh_tokens rows
+// whose tokens have expired, until ctx is cancelled. Expired tokens are rejected
+// on their own, so they no longer need to be stored.
+func RunTokenSweeper(ctx context.Context, tokens models.TokenStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := tokens.DeleteExpiredRevokedTokens(ctx)
+		if err != nil {
+			log.Printf("Error deleting expired revoked tokens: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d expired revoked tokens", deleted)
+		}
+
+		deleted, err = tokens.DeleteExpiredRefreshTokens(ctx)
+		if err != nil {
+			log.Printf("Error deleting expired refresh tokens: %v", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d expired refresh tokens", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+const (
+	// webhookMaxAttempts is how many times a delivery is tried before it fails.
+	// With the queue's backoff, the last attempt is about half an hour after the first.
+	webhookMaxAttempts = 6
+	// webhookWorkers is how many deliveries are sent concurrently.
+	webhookWorkers = 2
+)
+
+// WebhookPayload is the JSON body of a webhook delivery.
+type WebhookPayload struct {
+	ID        string          `json:"id"` // Delivery ID, the same for every attempt
+	Event     string          `json:"event" example:"article.processed"`
+	CreatedAt time.Time       `json:"created_at"`
+	Article   *models.Article `json:"article,omitempty"` // Omitted from test deliveries
+}
+
+// SignWebhook returns the X-Webhook-Signature of a delivery: the hex HMAC-SHA256,
+// keyed with the webhook's secret, of the X-Webhook-Timestamp, a dot and the body.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDispatcher queues article events for the users' webhooks and sends
+// them in the background, retrying failed deliveries with backoff.
+type WebhookDispatcher struct {
+	store  models.WebhookStore
+	wake   chan struct{} // Signals idle workers that a delivery was queued
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookDispatcher creates a dispatcher backed by the delivery log in store.
+func NewWebhookDispatcher(store models.WebhookStore) *WebhookDispatcher {
+	return &WebhookDispatcher{store: store, wake: make(chan struct{}, 1)}
+}
+
+// Notify queues a delivery of the event to each of the article owner's
+// webhooks that subscribe to it.
+func (d *WebhookDispatcher) Notify(ctx context.Context, event string, article *models.Article) error {
+	webhooks, err := d.store.GetWebhooksByUserID(ctx, article.UserID)
+	if err != nil {
+		return err
+	}
+
+	queued := false
+	for i := range webhooks {
+		if !webhooks[i].Subscribes(event) {
+			continue
+		}
+		delivery, err := newWebhookDelivery(&webhooks[i], event, article)
+		if err != nil {
+			return err
+		}
+		delivery.MaxAttempts = webhookMaxAttempts
+		if err := d.store.CreateWebhookDelivery(ctx, delivery); err != nil {
+			return err
+		}
+		queued = true
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default: // A wake-up is already pending
+		}
+	}
+	return nil
+}
+
+// SendTest sends a test event to a webhook right away, without retries, and
+// returns the logged delivery.
+func (d *WebhookDispatcher) SendTest(ctx context.Context, webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	delivery, err := newWebhookDelivery(webhook, models.WebhookEventTest, nil)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = models.DeliveryStatusSending
+	delivery.Attempts = 1
+	delivery.MaxAttempts = 1
+	if err := d.store.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	delivery.ResponseStatus, err = send(ctx, webhook, delivery)
+	if err != nil {
+		delivery.Status = models.DeliveryStatusFailed
+		delivery.LastError = err.Error()
+		err = d.store.FailWebhookDelivery(ctx, delivery.ID, delivery.ResponseStatus, delivery.LastError)
+	} else {
+		delivery.Status = models.DeliveryStatusSucceeded
+		err = d.store.CompleteWebhookDelivery(ctx, delivery.ID, delivery.ResponseStatus)
+	}
+	if err != nil {
+		return nil, err
+	}
+	delivery.UpdatedAt = time.Now().UTC()
+	return delivery, nil
+}
+
+// newWebhookDelivery builds a pending delivery of an event to a webhook.
+func newWebhookDelivery(webhook *models.Webhook, event string, article *models.Article) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{
+		ID:        models.GenerateUUID(),
+		WebhookID: webhook.ID,
+		UserID:    webhook.UserID,
+		Event:     event,
+		Status:    models.DeliveryStatusPending,
+	}
+	payload, err := json.Marshal(WebhookPayload{ID: delivery.ID, Event: event, CreatedAt: time.Now().UTC(), Article: article})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	delivery.Payload = payload
+	return delivery, nil
+}
+
+// send makes one delivery attempt. Any status other than 2xx is an error.
+func send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Webhook-Event", delivery.Event)
+	header.Set("X-Webhook-Delivery", delivery.ID)
+	header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	header.Set("X-Webhook-Signature", SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	status, err := fetcher.Post(ctx, webhook.URL, header, delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+	if status < 200 || status > 299 {
+		return status, fmt.Errorf("HTTP %d", status)
+	}
+	return status, nil
+}
+
+// Recover re-queues deliveries that were being sent when the process stopped.
+func (d *WebhookDispatcher) Recover(ctx context.Context) error {
+	requeued, err := d.store.RequeueSendingWebhookDeliveries(ctx)
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		log.Printf("Recovered %d interrupted webhook deliveries", requeued)
+	}
+	return nil
+}
+
+// Start launches the delivery workers. They run until Stop is called.
+func (d *WebhookDispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	for i := 0; i < webhookWorkers; i++ {
+		d.wg.Add(1)
+		go d.worker(ctx)
+	}
+	log.Printf("Started %d webhook delivery workers", webhookWorkers)
+}
+
+// Stop signals the workers to exit and waits for in-flight deliveries until ctx expires.
+func (d *WebhookDispatcher) Stop(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+	d.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *WebhookDispatcher) worker(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain all due deliveries before going idle
+		for ctx.Err() == nil {
+			delivery, err := d.store.ClaimNextWebhookDelivery(ctx)
+			if err != nil {
+				log.Printf("Error claiming webhook delivery: %v", err)
+				break
+			}
+			if delivery == nil {
+				break
+			}
+			d.deliver(ctx, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver makes an attempt at a claimed delivery and records the outcome.
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	// Bookkeeping must be recorded even when ctx is cancelled by shutdown
+	dbCtx := context.WithoutCancel(ctx)
+
+	webhook, err := d.store.GetWebhookByID(dbCtx, delivery.WebhookID, delivery.UserID)
+	var status int
+	switch {
+	case err != nil:
+	case webhook == nil:
+		err = fmt.Errorf("webhook no longer exists")
+	case !webhook.Active:
+		err = fmt.Errorf("webhook is disabled")
+	default:
+		status, err = send(ctx, webhook, delivery)
+	}
+
+	if err == nil {
+		if err := d.store.CompleteWebhookDelivery(dbCtx, delivery.ID, status); err != nil {
+			log.Printf("Error completing webhook delivery %s: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	if delivery.Attempts >= delivery.MaxAttempts || webhook == nil || !webhook.Active || isPermanent(err) {
+		log.Printf("Giving up on webhook delivery %s after %d attempts: %v", delivery.ID, delivery.Attempts, err)
+		if err := d.store.FailWebhookDelivery(dbCtx, delivery.ID, status, err.Error()); err != nil {
+			log.Printf("Error failing webhook delivery %s: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	delay := retryDelay(delivery.Attempts)
+	log.Printf("Webhook delivery %s attempt %d/%d failed, retrying in %s: %v", delivery.ID, delivery.Attempts, delivery.MaxAttempts, delay, err)
+	if err := d.store.RetryWebhookDelivery(dbCtx, delivery.ID, status, err.Error(), time.Now().Add(delay)); err != nil {
+		log.Printf("Error rescheduling webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// webhooks delivers article events from background processing. It is nil,
+// and events are dropped, until SetWebhookDispatcher is called.
+var webhooks *WebhookDispatcher
+
+// SetWebhookDispatcher sets the dispatcher background processing notifies.
+func SetWebhookDispatcher(d *WebhookDispatcher) {
+	webhooks = d
+}
+
+// notifyWebhooks queues an article event for the owner's webhooks, logging any error.
+func notifyWebhooks(ctx context.Context, event string, article *models.Article) {
+	if webhooks == nil {
+		return
+	}
+	if err := webhooks.Notify(ctx, event, article); err != nil {
+		log.Printf("Error queueing %s webhooks for article %s: %v", event, article.ID, err)
+	}
+}
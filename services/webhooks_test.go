@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeana-hines/personal-reading-list-api/models"
+)
+
+// skipBackoffStore records the delay before each retry the dispatcher
+// schedules, then makes the retry due right away so tests don't wait for it.
+type skipBackoffStore struct {
+	models.WebhookStore
+
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (s *skipBackoffStore) RetryWebhookDelivery(ctx context.Context, id string, responseStatus int, lastError string, nextAttemptAt time.Time) error {
+	s.mu.Lock()
+	s.delays = append(s.delays, time.Until(nextAttemptAt))
+	s.mu.Unlock()
+	return s.WebhookStore.RetryWebhookDelivery(ctx, id, responseStatus, lastError, time.Now())
+}
+
+// webhookReceiver is a webhook endpoint that answers with the given statuses
+// in turn, repeating the last, and verifies every request's signature.
+type webhookReceiver struct {
+	*countingServer
+
+	mu       sync.Mutex
+	bodies   [][]byte
+	headers  []http.Header
+	statuses []int
+}
+
+func newWebhookReceiver(t *testing.T, secret string, statuses ...int) *webhookReceiver {
+	t.Helper()
+	r := &webhookReceiver{statuses: statuses}
+	r.countingServer = newCountingServer(t, func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Errorf("reading webhook body: %v", err)
+		}
+		timestamp, err := strconv.ParseInt(req.Header.Get("X-Webhook-Timestamp"), 10, 64)
+		if err != nil {
+			t.Errorf("X-Webhook-Timestamp = %q: %v", req.Header.Get("X-Webhook-Timestamp"), err)
+		}
+		if got, want := req.Header.Get("X-Webhook-Signature"), SignWebhook(secret, timestamp, body); got != want {
+			t.Errorf("X-Webhook-Signature = %q, want %q", got, want)
+		}
+
+		r.mu.Lock()
+		r.bodies = append(r.bodies, body)
+		r.headers = append(r.headers, req.Header.Clone())
+		status := r.statuses[0]
+		if len(r.statuses) > 1 {
+			r.statuses = r.statuses[1:]
+		}
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	})
+	return r
+}
+
+// webhookTest is a dispatcher delivering to a webhook on a local receiver.
+type webhookTest struct {
+	store      *skipBackoffStore
+	dispatcher *WebhookDispatcher
+	webhook    *models.Webhook
+	receiver   *webhookReceiver
+}
+
+func newWebhookTest(t *testing.T, statuses ...int) *webhookTest {
+	t.Helper()
+	// Webhooks are posted with the package fetcher, which refuses loopback addresses
+	defaultFetcher := fetcher
+	fetcher = newTestFetcher([]string{"127.0.0.1"}, nil)
+	t.Cleanup(func() { fetcher = defaultFetcher })
+
+	const secret = "webhook-secret"
+	receiver := newWebhookReceiver(t, secret, statuses...)
+	store := &skipBackoffStore{WebhookStore: models.NewMemoryStore()}
+	webhook := &models.Webhook{UserID: "user-1", URL: receiver.URL + "/hook", Secret: secret, Active: true}
+	if err := store.CreateWebhook(context.Background(), webhook); err != nil {
+		t.Fatal(err)
+	}
+	return &webhookTest{store: store, dispatcher: NewWebhookDispatcher(store), webhook: webhook, receiver: receiver}
+}
+
+// deliver queues an event for the webhook, starts the dispatcher and waits
+// until the delivery succeeds or fails.
+func (wt *webhookTest) deliver(t *testing.T, article *models.Article) models.WebhookDelivery {
+	t.Helper()
+	ctx := context.Background()
+	if err := wt.dispatcher.Notify(ctx, models.WebhookEventArticleProcessed, article); err != nil {
+		t.Fatal(err)
+	}
+	wt.dispatcher.Start()
+	t.Cleanup(func() { wt.dispatcher.Stop(ctx) })
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := wt.store.GetWebhookDeliveries(ctx, wt.webhook.ID, wt.webhook.UserID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) != 1 {
+			t.Fatalf("%d deliveries were queued, want 1", len(deliveries))
+		}
+		if d := deliveries[0]; d.Status == models.DeliveryStatusSucceeded || d.Status == models.DeliveryStatusFailed {
+			return d
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery still %s after 5s: %+v", deliveries[0].Status, deliveries[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebhookDeliveryIsSigned(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusNoContent)
+	article := &models.Article{ID: "article-1", UserID: wt.webhook.UserID, URL: "https://example.com/post", Status: "unread"}
+
+	delivery := wt.deliver(t, article)
+	if delivery.Status != models.DeliveryStatusSucceeded || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("delivery = %+v, want succeeded on the first attempt", delivery)
+	}
+	if len(wt.receiver.bodies) != 1 {
+		t.Fatalf("received %d requests, want 1", len(wt.receiver.bodies))
+	}
+	header := wt.receiver.headers[0]
+	if header.Get("X-Webhook-Event") != models.WebhookEventArticleProcessed || header.Get("X-Webhook-Delivery") != delivery.ID ||
+		header.Get("Content-Type") != "application/json" {
+		t.Errorf("request headers = %v", header)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(wt.receiver.bodies[0], &payload); err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	if payload.ID != delivery.ID || payload.Event != models.WebhookEventArticleProcessed || payload.Article == nil || payload.Article.ID != article.ID {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	// The signature covers the body: any change to it invalidates the signature
+	timestamp, _ := strconv.ParseInt(header.Get("X-Webhook-Timestamp"), 10, 64)
+	tampered := append([]byte(nil), wt.receiver.bodies[0]...)
+	tampered[len(tampered)-2] ^= 1
+	if SignWebhook("webhook-secret", timestamp, tampered) == header.Get("X-Webhook-Signature") {
+		t.Error("a tampered body has the same signature")
+	}
+	if SignWebhook("other-secret", timestamp, wt.receiver.bodies[0]) == header.Get("X-Webhook-Signature") {
+		t.Error("another secret gives the same signature")
+	}
+}
+
+func TestWebhookRetriesServerErrors(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusInternalServerError, http.StatusOK)
+
+	delivery := wt.deliver(t, &models.Article{ID: "article-1", UserID: wt.webhook.UserID})
+	if delivery.Status != models.DeliveryStatusSucceeded || delivery.Attempts != 2 || delivery.ResponseStatus != http.StatusOK || delivery.LastError != "" {
+		t.Errorf("delivery = %+v, want succeeded on the second attempt", delivery)
+	}
+	if n := wt.receiver.requests.Load(); n != 2 {
+		t.Errorf("received %d requests, want 2", n)
+	}
+	// Both attempts are the same delivery
+	for i, header := range wt.receiver.headers {
+		if header.Get("X-Webhook-Delivery") != delivery.ID {
+			t.Errorf("attempt %d has delivery ID %q, want %q", i+1, header.Get("X-Webhook-Delivery"), delivery.ID)
+		}
+	}
+	if len(wt.store.delays) != 1 || !roughly(wt.store.delays[0], retryDelay(1)) {
+		t.Errorf("retry delays = %v, want [%s]", wt.store.delays, retryDelay(1))
+	}
+}
+
+func TestWebhookGivesUpAfterMaxAttempts(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusInternalServerError)
+
+	delivery := wt.deliver(t, &models.Article{ID: "article-1", UserID: wt.webhook.UserID})
+	if delivery.Status != models.DeliveryStatusFailed || delivery.Attempts != webhookMaxAttempts ||
+		delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError != "HTTP 500" {
+		t.Errorf("delivery = %+v, want failed after %d attempts", delivery, webhookMaxAttempts)
+	}
+	if n := wt.receiver.requests.Load(); n != webhookMaxAttempts {
+		t.Errorf("received %d requests, want %d", n, webhookMaxAttempts)
+	}
+	// Every retry waits twice as long as the one before
+	if len(wt.store.delays) != webhookMaxAttempts-1 {
+		t.Fatalf("scheduled %d retries, want %d", len(wt.store.delays), webhookMaxAttempts-1)
+	}
+	for i, delay := range wt.store.delays {
+		if want := retryBaseDelay << i; !roughly(delay, want) {
+			t.Errorf("retry %d was scheduled in %s, want %s", i+1, delay, want)
+		}
+	}
+}
+
+func TestWebhookDisabled(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusOK)
+	ctx := context.Background()
+	article := &models.Article{ID: "article-1", UserID: wt.webhook.UserID}
+	if err := wt.dispatcher.Notify(ctx, models.WebhookEventArticleCreated, article); err != nil {
+		t.Fatal(err)
+	}
+
+	// Disabling the webhook fails the delivery already queued, without sending it
+	wt.webhook.Active = false
+	if err := wt.store.UpdateWebhook(ctx, wt.webhook); err != nil {
+		t.Fatal(err)
+	}
+	wt.dispatcher.Start()
+	t.Cleanup(func() { wt.dispatcher.Stop(ctx) })
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := wt.store.GetWebhookDeliveries(ctx, wt.webhook.ID, wt.webhook.UserID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Status == models.DeliveryStatusFailed {
+			if d := deliveries[0]; d.Attempts != 1 || d.LastError != "webhook is disabled" {
+				t.Errorf("delivery = %+v, want failed on the first attempt because the webhook is disabled", d)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("deliveries after 5s: %+v", deliveries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := wt.receiver.requests.Load(); n != 0 {
+		t.Errorf("received %d requests, want none", n)
+	}
+	if len(wt.store.delays) != 0 {
+		t.Errorf("scheduled retries %v, want none", wt.store.delays)
+	}
+
+	// New events aren't queued for a disabled webhook
+	if err := wt.dispatcher.Notify(ctx, models.WebhookEventArticleProcessed, article); err != nil {
+		t.Fatal(err)
+	}
+	if deliveries, _ := wt.store.GetWebhookDeliveries(ctx, wt.webhook.ID, wt.webhook.UserID, 10); len(deliveries) != 1 {
+		t.Errorf("%d deliveries after notifying a disabled webhook, want 1", len(deliveries))
+	}
+}
+
+// roughly reports whether a delay measured with time.Until is want, give or
+// take the time the test took to measure it.
+func roughly(got, want time.Duration) bool {
+	return got > want-5*time.Second && got <= want
+}